@@ -9,6 +9,7 @@ import (
 	"context"
 	gogo "github.com/gogo/protobuf/types"
 	"github.com/onosproject/fabric-sim/pkg/simulator"
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
 	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
 	"github.com/onosproject/onos-api/go/onos/misc"
 	"github.com/onosproject/onos-api/go/onos/stratum"
@@ -17,7 +18,10 @@ import (
 	p4api "github.com/p4lang/p4runtime/go/p4/v1"
 	"google.golang.org/genproto/googleapis/rpc/code"
 	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
 	"io"
 	"time"
 )
@@ -60,12 +64,37 @@ func (s *Server) Write(ctx context.Context, request *p4api.WriteRequest) (*p4api
 	if err := s.checkForwardingPipeline(); err != nil {
 		return nil, errors.Status(err).Err()
 	}
-	if err := s.deviceSim.ProcessWrite(request.Atomicity, request.Updates); err != nil {
-		return nil, errors.Status(err).Err()
+	updateErrors, err := s.deviceSim.ProcessWriteWithDetails(request.Atomicity, request.Updates)
+	if err != nil {
+		if updateErrors == nil {
+			return nil, errors.Status(err).Err()
+		}
+		return nil, writeFailure(updateErrors).Err()
 	}
 	return &p4api.WriteResponse{}, nil
 }
 
+// Returns the status of a failed write, carrying the outcome of each update as the details, in update order;
+// the status of a write of a single update carries the code of its failure
+func writeFailure(updateErrors []error) *grpcstatus.Status {
+	st := grpcstatus.New(codes.Unknown, "write failure")
+	if len(updateErrors) == 1 {
+		st = entries.ErrorStatus(updateErrors[0])
+	}
+	details := make([]protoiface.MessageV1, 0, len(updateErrors))
+	for _, err := range updateErrors {
+		updateStatus := entries.ErrorStatus(err)
+		details = append(details, &p4api.Error{
+			CanonicalCode: int32(updateStatus.Code()),
+			Message:       updateStatus.Message(),
+		})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		return withDetails
+	}
+	return st
+}
+
 // Makes sure that the specified role and election ID have mastership over the given device; returns error if not
 func (s *Server) checkMastership(deviceID uint64, role string, electionID *p4api.Uint128) error {
 	return s.deviceSim.IsMaster(deviceID, role, electionID)
@@ -98,6 +127,30 @@ func (s *Server) SetForwardingPipelineConfig(ctx context.Context, request *p4api
 	if err := s.checkMastership(request.DeviceId, request.Role, request.ElectionId); err != nil {
 		return nil, errors.Status(err).Err()
 	}
+	switch request.Action {
+	case p4api.SetForwardingPipelineConfigRequest_VERIFY,
+		p4api.SetForwardingPipelineConfigRequest_VERIFY_AND_SAVE,
+		p4api.SetForwardingPipelineConfigRequest_VERIFY_AND_COMMIT:
+		if err := s.deviceSim.VerifyPipelineConfig(request.Config); err != nil {
+			return nil, errors.Status(err).Err()
+		}
+		if request.Action == p4api.SetForwardingPipelineConfigRequest_VERIFY {
+			return &p4api.SetForwardingPipelineConfigResponse{}, nil
+		}
+	case p4api.SetForwardingPipelineConfigRequest_RECONCILE_AND_COMMIT:
+		if err := s.deviceSim.VerifyPipelineConfig(request.Config); err != nil {
+			return nil, errors.Status(err).Err()
+		}
+		// Existing entries are kept only if they all remain valid under the new pipeline
+		if diff, err := s.deviceSim.ReconcilePipelineConfig(request.Config); err != nil {
+			if diff != nil {
+				log.Warnf("Device %s: Unable to reconcile pipeline: %d orphaned and %d invalidated entries",
+					s.deviceID, len(diff.Orphaned), len(diff.Invalidated))
+			}
+			return nil, errors.Status(err).Err()
+		}
+		return &p4api.SetForwardingPipelineConfigResponse{}, nil
+	}
 	if err := s.deviceSim.SetPipelineConfig(request.Config); err != nil {
 		return nil, errors.Status(err).Err()
 	}
@@ -139,13 +192,13 @@ func (state *streamState) Send(response *p4api.StreamMessageResponse) {
 }
 
 func (state *streamState) SendMastershipArbitration(role *p4api.Role, masterElectionID *p4api.Uint128, failCode code.Code) {
-	if role != state.role {
+	if role.GetName() != state.role.GetName() {
 		return
 	}
 
 	// Send failed election status code unless we are the master
 	electionStatus := &status.Status{Code: int32(failCode)}
-	if state.electionID == masterElectionID && state.role == role {
+	if masterElectionID != nil && state.IsMaster(role, masterElectionID) {
 		electionStatus.Code = int32(code.Code_OK)
 	}
 
@@ -183,7 +236,7 @@ func (state *streamState) LatchMastershipArbitration(arbitration *p4api.MasterAr
 
 // IsMaster returns true if the responder is the current master, i.e. has the master election ID, for the given role.
 func (state *streamState) IsMaster(role *p4api.Role, masterElectionID *p4api.Uint128) bool {
-	return (state.role == role || (state.role != nil && role != nil && state.role.Name == role.Name)) &&
+	return state.role.GetName() == role.GetName() && state.electionID != nil &&
 		state.electionID.High == masterElectionID.High && state.electionID.Low == masterElectionID.Low
 }
 
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/onos-api/go/onos/misc"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"sort"
+)
+
+// Arbitration provides introspection of the mastership arbitration state of a device
+type Arbitration struct {
+	ds *DeviceSimulator
+}
+
+// Controller represents a controller connected to the device via a stream channel
+type Controller struct {
+	Connection *misc.Connection
+	// PrimaryRoles lists the names of the roles for which the controller is currently the primary, in order
+	PrimaryRoles []string
+}
+
+// Arbitration returns the mastership arbitration view of the device
+func (ds *DeviceSimulator) Arbitration() *Arbitration {
+	return &Arbitration{ds: ds}
+}
+
+// PrimaryElectionID returns the election ID of the current primary controller for the specified role;
+// nil if no election ID has been recorded for the role
+func (a *Arbitration) PrimaryElectionID(role string) *p4api.Uint128 {
+	a.ds.lock.RLock()
+	defer a.ds.lock.RUnlock()
+	if rc, ok := a.ds.roleConfigs[role]; ok {
+		return &p4api.Uint128{High: rc.electionID.High, Low: rc.electionID.Low}
+	}
+	return nil
+}
+
+// Controllers returns the controllers currently connected to the device, in order of connection
+func (a *Arbitration) Controllers() []*Controller {
+	a.ds.lock.RLock()
+	defer a.ds.lock.RUnlock()
+
+	roleNames := make([]string, 0, len(a.ds.roleConfigs))
+	for name := range a.ds.roleConfigs {
+		roleNames = append(roleNames, name)
+	}
+	sort.Strings(roleNames)
+
+	controllers := make([]*Controller, 0, len(a.ds.streamResponders))
+	for _, r := range a.ds.streamResponders {
+		controller := &Controller{Connection: r.GetConnection(), PrimaryRoles: make([]string, 0)}
+		for _, name := range roleNames {
+			var role *p4api.Role
+			if name != "" {
+				role = &p4api.Role{Name: name}
+			}
+			if r.IsMaster(role, a.ds.roleConfigs[name].electionID) {
+				controller.PrimaryRoles = append(controller.PrimaryRoles, name)
+			}
+		}
+		controllers = append(controllers, controller)
+	}
+	return controllers
+}
+
+// Compares the given election IDs as unsigned 128-bit values; returns -1, 0 or 1 if the first is respectively lower
+// than, equal to or greater than the second; a missing election ID compares as 0
+func compareElectionIDs(a *p4api.Uint128, b *p4api.Uint128) int {
+	switch {
+	case a.GetHigh() < b.GetHigh():
+		return -1
+	case a.GetHigh() > b.GetHigh():
+		return 1
+	case a.GetLow() < b.GetLow():
+		return -1
+	case a.GetLow() > b.GetLow():
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
+	"github.com/onosproject/onos-api/go/onos/misc"
+	"github.com/onosproject/onos-api/go/onos/stratum"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"testing"
+)
+
+// Stream responder which tracks its latched role and election ID
+type arbitrationResponder struct {
+	connection *misc.Connection
+	role       *p4api.Role
+	electionID *p4api.Uint128
+	responses  []*p4api.StreamMessageResponse
+}
+
+func (r *arbitrationResponder) GetConnection() *misc.Connection {
+	return r.connection
+}
+
+func (r *arbitrationResponder) GetRoleConfig() *stratum.P4RoleConfig {
+	return nil
+}
+
+func (r *arbitrationResponder) LatchMastershipArbitration(arbitration *p4api.MasterArbitrationUpdate) *p4api.MasterArbitrationUpdate {
+	r.role, r.electionID = arbitration.Role, arbitration.ElectionId
+	return arbitration
+}
+
+func (r *arbitrationResponder) SendMastershipArbitration(role *p4api.Role, masterElectionID *p4api.Uint128, failCode code.Code) {
+}
+
+func (r *arbitrationResponder) Send(response *p4api.StreamMessageResponse) {
+	r.responses = append(r.responses, response)
+}
+
+func (r *arbitrationResponder) IsMaster(role *p4api.Role, masterElectionID *p4api.Uint128) bool {
+	return r.role.GetName() == role.GetName() && r.electionID != nil &&
+		r.electionID.High == masterElectionID.High && r.electionID.Low == masterElectionID.Low
+}
+
+// Connects a new controller, latches its arbitration update and runs the mastership arbitration
+func arbitrate(t *testing.T, ds *DeviceSimulator, r *arbitrationResponder, role *p4api.Role, electionID uint64) {
+	r.LatchMastershipArbitration(&p4api.MasterArbitrationUpdate{Role: role, ElectionId: &p4api.Uint128{Low: electionID}})
+	assert.NoError(t, ds.RunMastershipArbitration(role, r.electionID))
+}
+
+func TestArbitrationIntrospection(t *testing.T) {
+	ds := &DeviceSimulator{Device: &simapi.Device{}, roleConfigs: make(map[string]*roleConfig)}
+	arbitration := ds.Arbitration()
+	assert.Nil(t, arbitration.PrimaryElectionID(""))
+	assert.Len(t, arbitration.Controllers(), 0)
+
+	c1 := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c1"}}
+	c2 := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c2"}}
+	c3 := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c3"}}
+	ds.AddStreamResponder(c1)
+	ds.AddStreamResponder(c2)
+	ds.AddStreamResponder(c3)
+
+	arbitrate(t, ds, c1, nil, 5)
+	arbitrate(t, ds, c2, nil, 3)
+	assert.Equal(t, uint64(5), arbitration.PrimaryElectionID("").Low)
+
+	controllers := arbitration.Controllers()
+	assert.Len(t, controllers, 3)
+	assert.Equal(t, "c1", controllers[0].Connection.FromAddress)
+	assert.Equal(t, []string{""}, controllers[0].PrimaryRoles)
+	assert.Len(t, controllers[1].PrimaryRoles, 0)
+	assert.Len(t, controllers[2].PrimaryRoles, 0)
+
+	// Higher election ID takes over the default role; another role gets its own primary
+	arbitrate(t, ds, c2, nil, 7)
+	arbitrate(t, ds, c3, &p4api.Role{Name: "foo"}, 1)
+	assert.Equal(t, uint64(7), arbitration.PrimaryElectionID("").Low)
+	assert.Equal(t, uint64(1), arbitration.PrimaryElectionID("foo").Low)
+	assert.Nil(t, arbitration.PrimaryElectionID("bar"))
+
+	controllers = arbitration.Controllers()
+	assert.Len(t, controllers[0].PrimaryRoles, 0)
+	assert.Equal(t, []string{""}, controllers[1].PrimaryRoles)
+	assert.Equal(t, []string{"foo"}, controllers[2].PrimaryRoles)
+
+	// Disconnected controllers are no longer listed
+	ds.RemoveStreamResponder(c2)
+	controllers = arbitration.Controllers()
+	assert.Len(t, controllers, 2)
+	assert.Equal(t, "c3", controllers[1].Connection.FromAddress)
+}
+
+func TestWriteMastership(t *testing.T) {
+	ds := &DeviceSimulator{Device: &simapi.Device{ChassisID: 1}, roleConfigs: make(map[string]*roleConfig)}
+	assert.True(t, errors.IsForbidden(ds.IsMaster(1, "", &p4api.Uint128{Low: 1})))
+
+	// Election IDs compare as 128-bit values, with the high word being the most significant
+	c1 := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c1"}}
+	c2 := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c2"}}
+	ds.AddStreamResponder(c1)
+	ds.AddStreamResponder(c2)
+	c1.LatchMastershipArbitration(&p4api.MasterArbitrationUpdate{ElectionId: &p4api.Uint128{High: 1, Low: 0}})
+	assert.NoError(t, ds.RunMastershipArbitration(nil, c1.electionID))
+	c2.LatchMastershipArbitration(&p4api.MasterArbitrationUpdate{ElectionId: &p4api.Uint128{High: 0, Low: ^uint64(0)}})
+	assert.NoError(t, ds.RunMastershipArbitration(nil, c2.electionID))
+
+	// Only the primary may write; other clients are denied permission
+	assert.NoError(t, ds.IsMaster(1, "", &p4api.Uint128{High: 1}))
+	assert.True(t, errors.IsForbidden(ds.IsMaster(1, "", &p4api.Uint128{Low: ^uint64(0)})))
+	assert.True(t, errors.IsForbidden(ds.IsMaster(1, "", nil)))
+	assert.True(t, errors.IsForbidden(ds.IsMaster(1, "foo", &p4api.Uint128{High: 1})))
+	assert.True(t, errors.IsConflict(ds.IsMaster(2, "", &p4api.Uint128{High: 1})))
+
+	assert.Equal(t, -1, compareElectionIDs(&p4api.Uint128{Low: ^uint64(0)}, &p4api.Uint128{High: 1}))
+	assert.Equal(t, 1, compareElectionIDs(&p4api.Uint128{Low: 1}, nil))
+	assert.Equal(t, 0, compareElectionIDs(nil, &p4api.Uint128{}))
+}
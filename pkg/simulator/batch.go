@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Records the current state of the entity targeted by the given update, returning a function which restores it
+func (ds *DeviceSimulator) recordUndo(update *p4api.Update) (entries.Undo, error) {
+	entity := update.Entity
+	if entity.GetTableEntry() != nil || entity.GetDirectCounterEntry() != nil || entity.GetDirectMeterEntry() != nil {
+		return ds.tables.RecordUndo(entity)
+	}
+
+	prior, err := ds.readPrior(entity)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		// If the entity did not exist, the update must have inserted it
+		if len(prior) == 0 {
+			if err := ds.processDelete(&p4api.Update{Type: p4api.Update_DELETE, Entity: entity}); err != nil {
+				log.Warnf("Device %s: Unable to roll back insert: %+v", ds.Device.ID, err)
+			}
+			return
+		}
+		// Otherwise, re-insert the deleted entity or modify it back to its prior state
+		for _, e := range prior {
+			if err := ds.processModify(&p4api.Update{Entity: e}, update.Type == p4api.Update_DELETE); err != nil {
+				log.Warnf("Device %s: Unable to roll back update: %+v", ds.Device.ID, err)
+			}
+		}
+	}, nil
+}
+
+// Reads copies of the current state of the given entity; an entity which does not exist yields no entities
+func (ds *DeviceSimulator) readPrior(entity *p4api.Entity) ([]*p4api.Entity, error) {
+	prior := make([]*p4api.Entity, 0)
+	err := ds.processRead(entity, func(entities []*p4api.Entity) error {
+		for _, e := range entities {
+			if sameEntity(entity, e) {
+				prior = append(prior, proto.Clone(e).(*p4api.Entity))
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	return prior, nil
+}
+
+// Returns true if the read entity is the one identified by the given entity; action profile reads yield all
+// members or groups of the profile, while reads of other entities are already narrowed down by their ID
+func sameEntity(entity *p4api.Entity, read *p4api.Entity) bool {
+	switch {
+	case entity.GetActionProfileMember() != nil:
+		return read.GetActionProfileMember().GetMemberId() == entity.GetActionProfileMember().MemberId
+	case entity.GetActionProfileGroup() != nil:
+		return read.GetActionProfileGroup().GetGroupId() == entity.GetActionProfileGroup().GroupId
+	}
+	return true
+}
+
+// Rolls back the updates of a failed write by running their undos in reverse order
+func (ds *DeviceSimulator) rollBack(undos []entries.Undo) {
+	log.Warnf("Device %s: Rolling back %d updates", ds.Device.ID, len(undos))
+	entries.RollBack(undos)
+	ds.checkPuntToCPU()
+	ds.cancelStaleProgramFaults()
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRollbackOnError(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	p1 := ds.Device.Ports[0].InternalNumber
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(1), multicastUpdate(p4api.Update_INSERT, 1, p1)}))
+
+	// A failed batch rolls back the inserts, modifies and deletes applied before the failed update
+	err := ds.ProcessWrite(p4api.WriteRequest_ROLLBACK_ON_ERROR, []*p4api.Update{
+		insertUpdate(2),
+		multicastUpdate(p4api.Update_MODIFY, 1),
+		multicastUpdate(p4api.Update_INSERT, 2, p1),
+		{Type: p4api.Update_DELETE, Entity: insertUpdate(1).Entity},
+		insertUpdate(3),
+		insertUpdate(3),
+	})
+	assert.Error(t, err)
+	table := ds.Tables().Table(1)
+	assert.Equal(t, 1, table.Size())
+	lr, err := table.Lookup(entries.FieldValues{1: {1}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+	ports, err := ds.pre.ReplicaPorts(1)
+	assert.NoError(t, err)
+	assert.Len(t, ports, 1)
+	_, err = ds.pre.ReplicaPorts(2)
+	assert.Error(t, err)
+
+	// Without rollback, the updates applied before the failed update remain in effect
+	assert.Error(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(2), insertUpdate(2)}))
+	assert.Equal(t, 2, table.Size())
+}
+
+func TestWriteUpdateErrors(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	updates := []*p4api.Update{insertUpdate(1), insertUpdate(2), insertUpdate(1), insertUpdate(3), insertUpdate(2)}
+
+	// Without rollback, every update is attempted and the first failure is returned
+	updateErrors, err := ds.ProcessWriteWithDetails(p4api.WriteRequest_CONTINUE_ON_ERROR, updates)
+	assert.True(t, errors.IsAlreadyExists(err))
+	assert.Len(t, updateErrors, 5)
+	assert.NoError(t, updateErrors[0])
+	assert.NoError(t, updateErrors[1])
+	assert.Equal(t, err, updateErrors[2])
+	assert.NoError(t, updateErrors[3])
+	assert.True(t, errors.IsAlreadyExists(updateErrors[4]))
+	assert.Equal(t, 3, ds.Tables().Table(1).Size())
+
+	// Updates rolled back due to a later failure are reported as such
+	updates = []*p4api.Update{insertUpdate(4), insertUpdate(1), insertUpdate(5)}
+	updateErrors, err = ds.ProcessWriteWithDetails(p4api.WriteRequest_ROLLBACK_ON_ERROR, updates)
+	assert.True(t, errors.IsAlreadyExists(err))
+	assert.True(t, errors.IsUnknown(updateErrors[0]))
+	assert.Contains(t, updateErrors[0].Error(), "rolled back")
+	assert.True(t, errors.IsAlreadyExists(updateErrors[1]))
+	assert.Contains(t, updateErrors[2].Error(), "not attempted")
+	assert.Equal(t, 3, ds.Tables().Table(1).Size())
+
+	updateErrors, err = ds.ProcessWriteWithDetails(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(6)})
+	assert.NoError(t, err)
+	assert.Nil(t, updateErrors)
+}
@@ -46,11 +46,23 @@ type DeviceSimulator struct {
 	simulation               *Simulation
 	sdnPorts                 map[uint32]*simapi.Port
 
-	tables   *entries.Tables
-	counters *entries.Counters
-	meters   *entries.Meters
-	profiles *entries.ActionProfiles
-	pre      *entries.PacketReplication
+	tables    *entries.Tables
+	counters  *entries.Counters
+	meters    *entries.Meters
+	registers *entries.Registers
+	valueSets *entries.ValueSets
+	profiles  *entries.ActionProfiles
+	pre       *entries.PacketReplication
+	digests   *entries.Digests
+
+	programFault    *AsyncProgramFault
+	programFaults   map[*pendingProgramFault]bool
+	keySalt         []byte
+	readBatchSize   int
+	maxEntries      int
+	forwardingModel *ForwardingModel
+	packetCounting  bool
+	metering        bool
 
 	config     *configtree.Node
 	codec      *p4utils.ControllerMetadataCodec
@@ -61,6 +73,9 @@ type DeviceSimulator struct {
 	cancel context.CancelFunc
 
 	ioStatsLock sync.RWMutex
+
+	portModelLock sync.Mutex
+	portModels    map[simapi.PortID]*PortModel
 }
 
 // IOStats represents cumulative I/O stats
@@ -168,6 +183,33 @@ func (ds *DeviceSimulator) Tables() *entries.Tables {
 	return ds.tables
 }
 
+// TableOccupancy returns the occupancy of the device tables, keyed by table ID; returns nil if no pipeline
+// has been configured
+func (ds *DeviceSimulator) TableOccupancy() map[uint32]entries.TableOccupancy {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+	if ds.tables == nil {
+		return nil
+	}
+	return ds.tables.Stats()
+}
+
+// TableByName returns the device table with the specified P4 name; returns nil if there is no such table or if no
+// pipeline has been configured
+func (ds *DeviceSimulator) TableByName(name string) *entries.Table {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+	if ds.tables == nil {
+		return nil
+	}
+	return ds.tables.TableByName(name)
+}
+
+// Digests returns the device digests store
+func (ds *DeviceSimulator) Digests() *entries.Digests {
+	return ds.digests
+}
+
 // Counters returns the device counters store
 func (ds *DeviceSimulator) Counters() *entries.Counters {
 	return ds.counters
@@ -178,6 +220,11 @@ func (ds *DeviceSimulator) Meters() *entries.Meters {
 	return ds.meters
 }
 
+// Registers returns the device registers store
+func (ds *DeviceSimulator) Registers() *entries.Registers {
+	return ds.registers
+}
+
 // SnapshotStats snapshots any dynamic device stats, e.g. pipeline info
 func (ds *DeviceSimulator) SnapshotStats() *DeviceSimulator {
 	ds.snapshotTables()
@@ -195,6 +242,8 @@ func (ds *DeviceSimulator) Start(simulation *Simulation) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	ds.cancel = cancel
 	config.SimulateTrafficCounters(ctx, 4*time.Second, ds.config)
+	ds.simulateIdleTimeouts(ctx, IdleSweepInterval)
+	ds.simulateDigestTimeouts(ctx, DigestSweepInterval)
 
 	// Starts the simulated device agent
 	err := ds.Agent.Start(simulation, ds)
@@ -276,14 +325,17 @@ func (ds *DeviceSimulator) updatePortStatus(portName string, enabled bool) {
 	})
 }
 
-// IsMaster returns an error if the given election ID is not the master for the specified device (chassis) and role.
+// IsMaster returns an error if the given election ID is not the master for the specified device (chassis) and role;
+// the error maps to the PERMISSION_DENIED status expected by controllers which are not the primary
 func (ds *DeviceSimulator) IsMaster(chassisID uint64, role string, electionID *p4api.Uint128) error {
 	if chassisID != ds.Device.ChassisID {
 		return errors.NewConflict("incorrect device ID: %d", chassisID)
 	}
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
 	rolleWinner, ok := ds.roleConfigs[role]
-	if !ok || rolleWinner.electionID.High != electionID.High || rolleWinner.electionID.Low != electionID.Low {
-		return errors.NewUnauthorized("not master for role %s on device ID: %d", role, chassisID)
+	if !ok || compareElectionIDs(rolleWinner.electionID, electionID) != 0 {
+		return errors.NewForbidden("not master for role %s on device ID: %d", role, chassisID)
 	}
 	return nil
 }
@@ -302,10 +354,10 @@ func (ds *DeviceSimulator) RecordRoleElection(role *p4api.Role, electionID *p4ap
 	}
 
 	winner, ok := ds.roleConfigs[roleName]
-	if !ok || winner.electionID.High < electionID.High || (winner.electionID.High == electionID.High && winner.electionID.Low < electionID.Low) {
+	if !ok || compareElectionIDs(winner.electionID, electionID) < 0 {
 		ds.roleConfigs[roleName] = ds.getRoleConfig(role, electionID)
 		return electionID
-	} else if winner.electionID.High == electionID.High && winner.electionID.Low == electionID.Low {
+	} else if compareElectionIDs(winner.electionID, electionID) == 0 {
 		return nil // this role and election ID has already been claimed
 	}
 	return winner.electionID
@@ -402,10 +454,106 @@ func (ds *DeviceSimulator) SendToAllResponders(response *p4api.StreamMessageResp
 	}
 }
 
+// SetKeySalt sets the salt mixed into the keys of the device table entries, scoping them to the device
+func (ds *DeviceSimulator) SetKeySalt(salt []byte) error {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.keySalt = salt
+	if ds.tables != nil {
+		return ds.tables.SetKeySalt(salt)
+	}
+	return nil
+}
+
+// SetReadBatchSize sets the number of entities sent per batch by reads of the device entities, e.g. to keep read
+// responses within the gRPC message size limit; 0 restores the default batch size
+func (ds *DeviceSimulator) SetReadBatchSize(size int) error {
+	if size < 0 {
+		return errors.NewInvalid("invalid batch size %d", size)
+	}
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.readBatchSize = size
+	if ds.tables != nil {
+		ds.applyReadBatchSize()
+	}
+	return nil
+}
+
+// Applies the read batch size to all entity stores of the pipeline
+func (ds *DeviceSimulator) applyReadBatchSize() {
+	// The batch size has been validated, so setting it cannot fail
+	_ = ds.tables.SetBatchSize(ds.readBatchSize)
+	_ = ds.counters.SetBatchSize(ds.readBatchSize)
+	_ = ds.meters.SetBatchSize(ds.readBatchSize)
+	_ = ds.registers.SetBatchSize(ds.readBatchSize)
+	_ = ds.valueSets.SetBatchSize(ds.readBatchSize)
+	_ = ds.profiles.SetBatchSize(ds.readBatchSize)
+	_ = ds.pre.SetBatchSize(ds.readBatchSize)
+	_ = ds.digests.SetBatchSize(ds.readBatchSize)
+}
+
+// VerifyPipelineConfig verifies the consistency of the specified forwarding pipeline configuration
+func (ds *DeviceSimulator) VerifyPipelineConfig(fpc *p4api.ForwardingPipelineConfig) error {
+	if fpc == nil {
+		return errors.NewInvalid("forwarding pipeline configuration is missing")
+	}
+	return entries.VerifyP4Info(fpc.P4Info)
+}
+
 // SetPipelineConfig sets the forwarding pipeline configuration for the device
 func (ds *DeviceSimulator) SetPipelineConfig(fpc *p4api.ForwardingPipelineConfig) error {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
+	ds.applyPipelineInfo(fpc)
+	ds.newStores(fpc.P4Info)
+	return nil
+}
+
+// Creates the required entities, e.g. tables, counters, meters, etc., from the given P4 info
+func (ds *DeviceSimulator) newStores(info *p4info.P4Info) {
+	ds.tables = entries.NewTables(info.Tables)
+	_ = ds.tables.SetKeySalt(ds.keySalt) // re-keying the new empty tables cannot fail
+	ds.tables.BindDirectResources(info.DirectCounters, info.DirectMeters)
+	ds.tables.SetActions(info.Actions)
+	ds.profiles = entries.NewActionProfiles(info.ActionProfiles)
+	ds.pre = entries.NewPacketReplication()
+	ds.pre.SetEgressQueues(ds.egressQueues)
+	ds.createStores(info)
+}
+
+// ReconcilePipelineConfig sets the forwarding pipeline configuration for the device while preserving the existing
+// table entries, along with the action profiles and packet replication entries they may refer to; returns the
+// entries which would not survive the new P4 info and a conflict error, leaving the current pipeline in place, if
+// any entry would be orphaned or invalidated. Other entities are created afresh, as when setting the pipeline
+func (ds *DeviceSimulator) ReconcilePipelineConfig(fpc *p4api.ForwardingPipelineConfig) (*entries.TablesDiff, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	if ds.tables == nil {
+		// Without a prior pipeline there is nothing to preserve
+		ds.tables = entries.NewTables(nil)
+		_ = ds.tables.SetKeySalt(ds.keySalt)
+		ds.profiles = entries.NewActionProfiles(fpc.P4Info.ActionProfiles)
+		ds.pre = entries.NewPacketReplication()
+		ds.pre.SetEgressQueues(ds.egressQueues)
+	}
+	info := fpc.P4Info
+	diff := ds.tables.Diff(info.Tables)
+	if !diff.IsEmpty() {
+		return diff, errors.NewConflict("unable to reconcile pipeline of %s: %d orphaned and %d invalidated entries",
+			ds.Device.ID, len(diff.Orphaned), len(diff.Invalidated))
+	}
+	ds.applyPipelineInfo(fpc)
+
+	// Carry the tables over to the new P4 info, keeping the action profiles and PRE they refer to
+	ds.tables.Reconfigure(info)
+	ds.tables.SetActions(info.Actions)
+	ds.createStores(info)
+	return diff, nil
+}
+
+// Records the given forwarding pipeline configuration as the device pipeline info
+func (ds *DeviceSimulator) applyPipelineInfo(fpc *p4api.ForwardingPipelineConfig) {
 	ds.forwardingPipelineConfig = fpc
 
 	// Update the device pipeline info
@@ -415,14 +563,19 @@ func (ds *DeviceSimulator) SetPipelineConfig(fpc *p4api.ForwardingPipelineConfig
 	}
 
 	ds.codec = p4utils.NewControllerMetadataCodec(fpc.P4Info)
+}
 
-	// Create the required entities, e.g. tables, counters, meters, etc.
-	info := fpc.P4Info
-	ds.tables = entries.NewTables(info.Tables)
+// Creates the entity stores not referenced by table entries from the given P4 info, binds the action profiles and
+// PRE to the tables and snapshots the resulting pipeline info
+func (ds *DeviceSimulator) createStores(info *p4info.P4Info) {
 	ds.counters = entries.NewCounters(info.Counters)
 	ds.meters = entries.NewMeters(info.Meters)
-	ds.profiles = entries.NewActionProfiles(info.ActionProfiles)
-	ds.pre = entries.NewPacketReplication()
+	ds.registers = entries.NewRegisters(info.Registers)
+	ds.valueSets = entries.NewValueSets(info.ValueSets)
+	ds.tables.SetActionProfiles(ds.profiles)
+	ds.tables.SetPacketReplication(ds.pre)
+	ds.digests = entries.NewDigests(info.Digests)
+	ds.applyReadBatchSize()
 
 	ds.findPuntToCPUTables()
 
@@ -433,7 +586,6 @@ func (ds *DeviceSimulator) SetPipelineConfig(fpc *p4api.ForwardingPipelineConfig
 	ds.snapshotGroups()
 	ds.snapshotMulticast()
 	ds.snapshotCloneSessions()
-	return nil
 }
 
 func (ds *DeviceSimulator) snapshotTables() {
@@ -549,8 +701,16 @@ func (ds *DeviceSimulator) ProcessPacketOut(packetOut *p4api.PacketOut, responde
 
 // ProcessDigestAck handles the specified digest list ack message
 func (ds *DeviceSimulator) ProcessDigestAck(ack *p4api.DigestListAck, responder StreamResponder) error {
-	log.Infof("Device %s: received digest ack: %+v", ds.Device.ID, ack)
-	// TODO: Implement this
+	log.Debugf("Device %s: received digest ack: %+v", ds.Device.ID, ack)
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	if ds.digests == nil {
+		return errors.NewInvalid("pipeline config not set yet for %s", ds.Device.ID)
+	}
+	// Stale acks, e.g. of lists re-sent after the ack timeout, are not fatal to the stream
+	if err := ds.digests.AckDigestList(ack); err != nil {
+		log.Warnf("Device %s: Unable to process digest ack: %+v", ds.Device.ID, err)
+	}
 	return nil
 }
 
@@ -572,6 +732,11 @@ func (ds *DeviceSimulator) processLLDPPacket(packet gopacket.Packet, packetOut *
 		return
 	}
 
+	// Drop the frame if it exceeds the egress port MTU
+	if _, err := ds.transmit(egressPort, packetOut.Payload); err != nil {
+		return
+	}
+
 	// Check if the given port has a link originating from it
 	if link := ds.simulation.GetLinkFromPort(egressPort.ID); link != nil {
 		// Now that we found the link, let's determine whether the link is an external one.
@@ -673,33 +838,94 @@ func matchesMetaData(roleConfig *stratum.P4RoleConfig, metadata []*p4api.PacketM
 	return false
 }
 
-// ProcessWrite processes the specified batch of updates
+// ProcessWrite processes the specified batch of updates; returns the error of the first failed update, if any
 func (ds *DeviceSimulator) ProcessWrite(atomicity p4api.WriteRequest_Atomicity, updates []*p4api.Update) error {
+	_, err := ds.ProcessWriteWithDetails(atomicity, updates)
+	return err
+}
+
+// ProcessWriteWithDetails processes the specified batch of updates in order. With CONTINUE_ON_ERROR atomicity, every
+// update is attempted; with ROLLBACK_ON_ERROR or DATAPLANE_ATOMIC atomicity, processing stops at the first failed
+// update and the updates applied before it are rolled back. If any update fails, returns the error of the first
+// failed update, along with the outcome of each update by index: nil for updates which remain applied, the error of
+// each failed update and Unknown errors for updates which were rolled back or not attempted. Errors which do not
+// pertain to any particular update are returned without the per-update outcomes.
+func (ds *DeviceSimulator) ProcessWriteWithDetails(atomicity p4api.WriteRequest_Atomicity, updates []*p4api.Update) ([]error, error) {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 	if ds.forwardingPipelineConfig == nil {
-		return errors.NewUnavailable("Device %s: Pipeline configuration not set yet", ds.Device.ID)
+		return nil, errors.NewUnavailable("Device %s: Pipeline configuration not set yet", ds.Device.ID)
+	}
+
+	// With ROLLBACK_ON_ERROR or DATAPLANE_ATOMIC atomicity, record how to undo each update, so that the updates
+	// already applied can be reverted if a later one fails
+	rollback := entries.RollsBack(atomicity)
+	undos := make([]entries.Undo, 0, len(updates))
+	var errs []error
+	var failure error
+	for i, update := range updates {
+		var undo entries.Undo
+		var err error
+		if rollback {
+			undo, err = ds.recordUndo(update)
+		}
+		if err == nil {
+			err = ds.processUpdate(update)
+		}
+		if err != nil {
+			if rollback {
+				ds.rollBack(undos)
+				return updateErrors(updates, i, err), err
+			}
+			if errs == nil {
+				errs = make([]error, len(updates))
+				failure = err
+			}
+			errs[i] = err
+			continue
+		}
+		if undo != nil {
+			undos = append(undos, undo)
+		}
 	}
+	return errs, failure
+}
 
-	for _, update := range updates {
+// Processes a single update of a write
+func (ds *DeviceSimulator) processUpdate(update *p4api.Update) error {
+	var err error
+	switch {
+	case update.Type == p4api.Update_INSERT:
+		if err = ds.checkEntryLimit(); err == nil {
+			err = ds.processModify(update, true)
+		}
+		if err != nil {
+			log.Warnf("Device %s: Unable to insert entry: %+v", ds.Device.ID, err)
+		}
+	case update.Type == p4api.Update_MODIFY:
+		if err = ds.processModify(update, false); err != nil {
+			log.Warnf("Device %s: Unable to update entry: %+v", ds.Device.ID, err)
+		}
+	case update.Type == p4api.Update_DELETE:
+		err = ds.processDelete(update)
+	}
+	return err
+}
+
+// Returns the outcome of each update of a rolled back write whose update with the given index failed with the given error
+func updateErrors(updates []*p4api.Update, failed int, err error) []error {
+	errs := make([]error, len(updates))
+	for i := range updates {
 		switch {
-		case update.Type == p4api.Update_INSERT:
-			if err := ds.processModify(update, true); err != nil {
-				log.Warnf("Device %s: Unable to insert entry: %+v", ds.Device.ID, err)
-				return err
-			}
-		case update.Type == p4api.Update_MODIFY:
-			if err := ds.processModify(update, false); err != nil {
-				log.Warnf("Device %s: Unable to update entry: %+v", ds.Device.ID, err)
-				return err
-			}
-		case update.Type == p4api.Update_DELETE:
-			if err := ds.processDelete(update); err != nil {
-				return err
-			}
+		case i < failed:
+			errs[i] = errors.NewUnknown("update %d rolled back due to failure of update %d", i, failed)
+		case i == failed:
+			errs[i] = err
+		case i > failed:
+			errs[i] = errors.NewUnknown("update %d not attempted due to failure of update %d", i, failed)
 		}
 	}
-	return nil
+	return errs
 }
 
 func (ds *DeviceSimulator) processModify(update *p4api.Update, isInsert bool) error {
@@ -710,6 +936,9 @@ func (ds *DeviceSimulator) processModify(update *p4api.Update, isInsert bool) er
 		err = ds.tables.ModifyTableEntry(entity.GetTableEntry(), isInsert)
 		if err == nil {
 			ds.checkPuntToCPU()
+			if isInsert {
+				ds.injectProgramFault(entity.GetTableEntry())
+			}
 		}
 	case entity.GetCounterEntry() != nil:
 		err = ds.counters.ModifyCounterEntry(entity.GetCounterEntry(), isInsert)
@@ -734,11 +963,11 @@ func (ds *DeviceSimulator) processModify(update *p4api.Update, isInsert bool) er
 		}
 
 	case entity.GetRegisterEntry() != nil:
-		log.Warnf("Device %s: RegisterEntry write is not supported yet: %+v", ds.Device.ID, entity.GetRegisterEntry())
+		err = ds.registers.ModifyRegisterEntry(entity.GetRegisterEntry(), isInsert)
 	case entity.GetValueSetEntry() != nil:
-		log.Warnf("Device %s: ValueSetEntry write is not supported yet: %+v", ds.Device.ID, entity.GetValueSetEntry())
+		err = ds.valueSets.ModifyValueSetEntry(entity.GetValueSetEntry(), isInsert)
 	case entity.GetDigestEntry() != nil:
-		log.Warnf("Device %s: DigestEntry write is not supported yet: %+v", ds.Device.ID, entity.GetDigestEntry())
+		err = ds.digests.ModifyDigestEntry(entity.GetDigestEntry(), isInsert)
 	case entity.GetExternEntry() != nil:
 		log.Warnf("Device %s: ExternEntry write is not supported yet: %+v", ds.Device.ID, entity.GetExternEntry())
 	default:
@@ -754,6 +983,7 @@ func (ds *DeviceSimulator) processDelete(update *p4api.Update) error {
 		err = ds.tables.RemoveTableEntry(entity.GetTableEntry())
 		if err == nil {
 			ds.checkPuntToCPU()
+			ds.cancelStaleProgramFaults()
 		}
 	case entity.GetCounterEntry() != nil:
 		return errors.NewInvalid("counter cannot be deleted")
@@ -778,8 +1008,11 @@ func (ds *DeviceSimulator) processDelete(update *p4api.Update) error {
 		}
 
 	case entity.GetRegisterEntry() != nil:
+		return errors.NewInvalid("register cannot be deleted")
 	case entity.GetValueSetEntry() != nil:
+		return errors.NewInvalid("value set cannot be deleted")
 	case entity.GetDigestEntry() != nil:
+		err = ds.digests.DeleteDigestEntry(entity.GetDigestEntry())
 	case entity.GetExternEntry() != nil:
 	default:
 	}
@@ -806,11 +1039,13 @@ func (ds *DeviceSimulator) processRead(request *p4api.Entity, sender entries.Bat
 	case request.GetTableEntry() != nil:
 		return ds.tables.ReadTableEntries(request.GetTableEntry(), entries.ReadTableEntry, sender)
 	case request.GetCounterEntry() != nil:
+		return ds.counters.ReadCounterEntries(request.GetCounterEntry(), sender)
 	case request.GetDirectCounterEntry() != nil:
-		return ds.tables.ReadTableEntries(request.GetTableEntry(), entries.ReadDirectCounter, sender)
+		return ds.tables.ReadTableEntries(tableEntryOrWildcard(request.GetDirectCounterEntry().TableEntry), entries.ReadDirectCounter, sender)
 	case request.GetMeterEntry() != nil:
+		return ds.meters.ReadMeterEntries(request.GetMeterEntry(), sender)
 	case request.GetDirectMeterEntry() != nil:
-		return ds.tables.ReadTableEntries(request.GetTableEntry(), entries.ReadDirectMeter, sender)
+		return ds.tables.ReadTableEntries(tableEntryOrWildcard(request.GetDirectMeterEntry().TableEntry), entries.ReadDirectMeter, sender)
 
 	case request.GetActionProfileGroup() != nil:
 		return ds.profiles.ReadActionProfileGroups(request.GetActionProfileGroup(), sender)
@@ -826,14 +1061,25 @@ func (ds *DeviceSimulator) processRead(request *p4api.Entity, sender entries.Bat
 		}
 
 	case request.GetRegisterEntry() != nil:
+		return ds.registers.ReadRegisterEntries(request.GetRegisterEntry(), sender)
 	case request.GetValueSetEntry() != nil:
+		return ds.valueSets.ReadValueSetEntries(request.GetValueSetEntry(), sender)
 	case request.GetDigestEntry() != nil:
+		return ds.digests.ReadDigestEntries(request.GetDigestEntry(), sender)
 	case request.GetExternEntry() != nil:
 	default:
 	}
 	return nil
 }
 
+// Returns the given table entry or a wildcard table entry if nil
+func tableEntryOrWildcard(entry *p4api.TableEntry) *p4api.TableEntry {
+	if entry == nil {
+		return &p4api.TableEntry{}
+	}
+	return entry
+}
+
 // ProcessConfigGet handles the configuration get request
 func (ds *DeviceSimulator) ProcessConfigGet(prefix *gnmi.Path, paths []*gnmi.Path) ([]*gnmi.Notification, error) {
 	notifications := make([]*gnmi.Notification, 0, len(paths))
@@ -11,9 +11,11 @@ import (
 	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
 	"github.com/onosproject/onos-api/go/onos/misc"
 	"github.com/onosproject/onos-api/go/onos/stratum"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-net-lib/pkg/configtree"
 	"github.com/onosproject/onos-net-lib/pkg/gnmiutils"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
 	p4api "github.com/p4lang/p4runtime/go/p4/v1"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/genproto/googleapis/rpc/code"
@@ -146,6 +148,39 @@ func TestDeviceProcessSet(t *testing.T) {
 	assert.False(t, n[0].Update[0].Val.GetBoolVal())
 }
 
+func TestReconcilePipelineConfig(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(1), insertUpdate(2)}))
+	pipeline := func(bitwidth int32, cookie uint64) *p4api.ForwardingPipelineConfig {
+		return &p4api.ForwardingPipelineConfig{
+			P4Info: &p4info.P4Info{Tables: []*p4info.Table{
+				{
+					Preamble:    &p4info.Preamble{Id: 1, Name: "exact"},
+					MatchFields: []*p4info.MatchField{{Id: 1, Name: "f1", Bitwidth: bitwidth, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+				},
+				{Preamble: &p4info.Preamble{Id: 2, Name: "added"}},
+			}},
+			Cookie: &p4api.ForwardingPipelineConfig_Cookie{Cookie: cookie},
+		}
+	}
+
+	// Compatible pipelines keep the existing entries
+	diff, err := ds.ReconcilePipelineConfig(pipeline(8, 2))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+	assert.Equal(t, uint64(2), ds.GetPipelineConfig().Cookie.Cookie)
+	assert.Equal(t, 2, ds.Tables().Table(1).Size())
+	assert.NotNil(t, ds.TableByName("added"))
+
+	// Incompatible pipelines are refused, leaving the current pipeline in place
+	diff, err = ds.ReconcilePipelineConfig(&p4api.ForwardingPipelineConfig{P4Info: &p4info.P4Info{},
+		Cookie: &p4api.ForwardingPipelineConfig_Cookie{Cookie: 3}})
+	assert.True(t, errors.IsConflict(err))
+	assert.Len(t, diff.Orphaned, 2)
+	assert.Equal(t, uint64(2), ds.GetPipelineConfig().Cookie.Cookie)
+	assert.Equal(t, 2, ds.Tables().Table(1).Size())
+}
+
 // CreateSwitchConfig creates a test device configuration
 func CreateSwitchConfig(portCount uint32) *configtree.Node {
 	ports := make(map[simapi.PortID]*simapi.Port)
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"time"
+)
+
+// DigestSweepInterval is the interval at which the device digests are swept for digest data held for the maximum
+// timeout of their digest
+const DigestSweepInterval = 100 * time.Millisecond
+
+// Periodically flushes the device digests until the context is cancelled
+func (ds *DeviceSimulator) simulateDigestTimeouts(ctx context.Context, interval time.Duration) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+				ds.FlushDigests()
+			}
+		}
+	}()
+}
+
+// EmitDigest queues the given data for delivery by the specified digest, as if generated by the data path, and sends
+// the resulting digest list to the controllers once the digest config calls for it
+func (ds *DeviceSimulator) EmitDigest(digestID uint32, data *p4api.P4Data) error {
+	ds.lock.Lock()
+	if ds.digests == nil {
+		ds.lock.Unlock()
+		return errors.NewInvalid("pipeline config not set yet for %s", ds.Device.ID)
+	}
+	list, err := ds.digests.AddDigestData(digestID, data)
+	ds.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if list != nil {
+		ds.sendDigestList(list)
+	}
+	return nil
+}
+
+// FlushDigests sends the digest lists whose data has been held for the maximum timeout of their digest; returns the
+// number of lists sent
+func (ds *DeviceSimulator) FlushDigests() int {
+	ds.lock.Lock()
+	var lists []*p4api.DigestList
+	if ds.digests != nil {
+		lists = ds.digests.FlushDigestLists()
+	}
+	ds.lock.Unlock()
+
+	for _, list := range lists {
+		ds.sendDigestList(list)
+	}
+	return len(lists)
+}
+
+// Sends the given digest list to all controllers
+func (ds *DeviceSimulator) sendDigestList(list *p4api.DigestList) {
+	log.Debugf("Device %s: Sending digest list %d of digest %d with %d entries", ds.Device.ID, list.ListId, list.DigestId, len(list.Data))
+	ds.SendToAllResponders(&p4api.StreamMessageResponse{
+		Update: &p4api.StreamMessageResponse_Digest{Digest: list},
+	})
+}
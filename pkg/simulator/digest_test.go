@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/topo"
+	"github.com/onosproject/onos-api/go/onos/misc"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEmitDigest(t *testing.T) {
+	topology := &topo.Topology{}
+	assert.NoError(t, topo.LoadTopologyFile("../../topologies/custom.yaml", topology))
+	ds := NewDeviceSimulator(topo.ConstructDevice(topology.Devices[0]), nil, nil)
+	assert.Error(t, ds.EmitDigest(1, &p4api.P4Data{}))
+	assert.NoError(t, ds.SetPipelineConfig(&p4api.ForwardingPipelineConfig{
+		P4Info: &p4info.P4Info{Digests: []*p4info.Digest{{Preamble: &p4info.Preamble{Id: 1, Name: "mac_learn"}}}},
+		Cookie: &p4api.ForwardingPipelineConfig_Cookie{Cookie: 1},
+	}))
+	now := time.Unix(1000, 0)
+	ds.Digests().SetClock(func() time.Time { return now })
+
+	controller := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c1"}}
+	ds.AddStreamResponder(controller)
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{{
+		Type: p4api.Update_INSERT,
+		Entity: &p4api.Entity{Entity: &p4api.Entity_DigestEntry{DigestEntry: &p4api.DigestEntry{DigestId: 1,
+			Config: &p4api.DigestEntry_Config{MaxListSize: 2, MaxTimeoutNs: int64(time.Second), AckTimeoutNs: int64(time.Minute)}}}},
+	}}))
+
+	data := func(b byte) *p4api.P4Data {
+		return &p4api.P4Data{Data: &p4api.P4Data_Bitstring{Bitstring: []byte{b}}}
+	}
+
+	// Digest data is sent once it fills a list
+	assert.NoError(t, ds.EmitDigest(1, data(1)))
+	assert.Len(t, controller.responses, 0)
+	assert.NoError(t, ds.EmitDigest(1, data(2)))
+	assert.Len(t, controller.responses, 1)
+	list := controller.responses[0].GetDigest()
+	assert.NotNil(t, list)
+	assert.Len(t, list.Data, 2)
+
+	// Further data waits for the ack and the maximum timeout
+	assert.NoError(t, ds.EmitDigest(1, data(3)))
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 0, ds.FlushDigests())
+	assert.NoError(t, ds.ProcessDigestAck(&p4api.DigestListAck{DigestId: 1, ListId: list.ListId}, controller))
+	assert.Equal(t, 1, ds.FlushDigests())
+	assert.Len(t, controller.responses, 2)
+	assert.Len(t, controller.responses[1].GetDigest().Data, 1)
+	assert.NoError(t, ds.ProcessDigestAck(&p4api.DigestListAck{DigestId: 1, ListId: list.ListId}, controller))
+
+	// Digest entries are read back
+	entities := make([]*p4api.Entity, 0)
+	errs := ds.ProcessRead([]*p4api.Entity{{Entity: &p4api.Entity_DigestEntry{DigestEntry: &p4api.DigestEntry{}}}},
+		func(batch []*p4api.Entity) error {
+			entities = append(entities, batch...)
+			return nil
+		})
+	assert.NoError(t, errs[0])
+	assert.Len(t, entities, 1)
+}
@@ -5,7 +5,9 @@
 package entries
 
 import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
 	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
 )
 
 // Action represents a P4 action
@@ -19,3 +21,65 @@ func NewAction(table *p4info.Action) *Action {
 		Action: table,
 	}
 }
+
+// Validates that the given direct action can be used by a table entry, i.e. that it is among the table action refs
+// and is not restricted to the default action, and that its parameters match the action signature; tables which
+// declare no action refs accept any action
+func (t *Table) validateEntryAction(action *p4api.Action) error {
+	if action == nil {
+		return nil
+	}
+	if len(t.info.ActionRefs) > 0 {
+		found := false
+		for _, ref := range t.info.ActionRefs {
+			if ref.Id == action.ActionId {
+				if ref.Scope == p4info.ActionRef_DEFAULT_ONLY {
+					return errors.NewInvalid("action %d can only be the default action of table %s", action.ActionId, t.Name())
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.NewInvalid("action %d is not an action of table %s", action.ActionId, t.Name())
+		}
+	}
+	return t.tables.validateActionParams(action)
+}
+
+// Validates that the parameters of the given action are exactly those declared by the action in the P4 info and
+// that their values fit the declared bitwidths; actions are not validated if no P4 info actions have been set
+func (ts *Tables) validateActionParams(action *p4api.Action) error {
+	if len(ts.actions) == 0 {
+		return nil
+	}
+	info, ok := ts.actions[action.ActionId]
+	if !ok {
+		return errors.NewInvalid("unknown action %d", action.ActionId)
+	}
+	declared := make(map[uint32]*p4info.Action_Param, len(info.Params))
+	for _, param := range info.Params {
+		declared[param.Id] = param
+	}
+	seen := make(map[uint32]bool, len(action.Params))
+	for _, param := range action.Params {
+		pi, ok := declared[param.ParamId]
+		if !ok {
+			return errors.NewInvalid("param %d is not a param of action %s", param.ParamId, info.Preamble.Name)
+		}
+		if seen[param.ParamId] {
+			return errors.NewInvalid("param %s of action %s is given more than once", pi.Name, info.Preamble.Name)
+		}
+		seen[param.ParamId] = true
+		if pi.Bitwidth > 0 && len(param.Value) > int(pi.Bitwidth+7)/8 {
+			return errors.NewInvalid("value of param %s of action %s has %d bytes; expected at most %d bytes for %d bits",
+				pi.Name, info.Preamble.Name, len(param.Value), int(pi.Bitwidth+7)/8, pi.Bitwidth)
+		}
+	}
+	for _, param := range info.Params {
+		if !seen[param.Id] {
+			return errors.NewInvalid("param %s of action %s is missing", param.Name, info.Preamble.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Undo is a function which reverts the effects of an applied update
+type Undo func()
+
+// ApplyUpdates applies the given table entry, direct counter and direct meter updates in order, stopping at the
+// first failed update. If the atomicity is ROLLBACK_ON_ERROR or DATAPLANE_ATOMIC, the updates already applied are
+// rolled back before the error is returned; otherwise they remain in effect.
+func (ts *Tables) ApplyUpdates(atomicity p4api.WriteRequest_Atomicity, updates []*p4api.Update) error {
+	undos := make([]Undo, 0, len(updates))
+	for _, update := range updates {
+		undo, err := ts.RecordUndo(update.Entity)
+		if err == nil {
+			err = ts.applyUpdate(update)
+		}
+		if err != nil {
+			if RollsBack(atomicity) {
+				RollBack(undos)
+			}
+			return err
+		}
+		undos = append(undos, undo)
+	}
+	return nil
+}
+
+// Applies a single table entry, direct counter or direct meter update
+func (ts *Tables) applyUpdate(update *p4api.Update) error {
+	entity := update.Entity
+	insert := update.Type == p4api.Update_INSERT
+	switch {
+	case entity.GetTableEntry() != nil:
+		if update.Type == p4api.Update_DELETE {
+			return ts.RemoveTableEntry(entity.GetTableEntry())
+		}
+		return ts.ModifyTableEntry(entity.GetTableEntry(), insert)
+	case entity.GetDirectCounterEntry() != nil:
+		if update.Type == p4api.Update_DELETE {
+			return errors.NewInvalid("direct counter entry cannot be deleted")
+		}
+		return ts.ModifyDirectCounterEntry(entity.GetDirectCounterEntry(), insert)
+	case entity.GetDirectMeterEntry() != nil:
+		if update.Type == p4api.Update_DELETE {
+			return errors.NewInvalid("direct meter entry cannot be deleted")
+		}
+		return ts.ModifyDirectMeterEntry(entity.GetDirectMeterEntry(), insert)
+	}
+	return errors.NewInvalid("entity is not a table entry, direct counter or direct meter entry: %v", entity)
+}
+
+// RecordUndo records the current state of the table entry addressed by the given table entry, direct counter or
+// direct meter entity, returning a function which restores the entry, along with its direct resources, to that
+// state; entities of other types yield an undo which does nothing
+func (ts *Tables) RecordUndo(entity *p4api.Entity) (Undo, error) {
+	var entry *p4api.TableEntry
+	switch {
+	case entity.GetTableEntry() != nil:
+		entry = entity.GetTableEntry()
+	case entity.GetDirectCounterEntry() != nil:
+		entry = entity.GetDirectCounterEntry().TableEntry
+	case entity.GetDirectMeterEntry() != nil:
+		entry = entity.GetDirectMeterEntry().TableEntry
+	}
+	if entry == nil {
+		return func() {}, nil
+	}
+	table, ok := ts.tables[entry.TableId]
+	if !ok {
+		return nil, errors.NewNotFound("table %d not found", entry.TableId)
+	}
+	return table.recordUndo(entry)
+}
+
+// Records the current state of the row addressed by the given entry
+func (t *Table) recordUndo(entry *p4api.TableEntry) (Undo, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pollCounters()
+	if entry.IsDefaultAction {
+		var saved *Row
+		if t.defaultRow != nil {
+			saved = t.defaultRow.checkpoint()
+		}
+		return func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.defaultRow = saved
+			t.mutated()
+		}, nil
+	}
+
+	// Compute the key from a copy, leaving the canonicalization of the entry itself to the update
+	entry = proto.Clone(entry).(*p4api.TableEntry)
+	if err := t.canonicalizeMatches(entry); err != nil {
+		return nil, err
+	}
+	if t.IsWildcard(entry) {
+		return t.recordWildcardUndo(entry), nil
+	}
+	key, err := t.entryKey(entry)
+	if err != nil {
+		return nil, err
+	}
+	var saved *Row
+	if row, ok := t.rows[key]; ok {
+		saved = row.checkpoint()
+	}
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		t.restoreRow(key, saved)
+	}, nil
+}
+
+// Restores the row under the given key to the saved row, removing the row if none was saved
+func (t *Table) restoreRow(key string, saved *Row) {
+	if saved == nil {
+		t.removeRow(key)
+		return
+	}
+	if row, ok := t.rows[key]; ok {
+		// Replace the row in place, keeping its position in the insertion order
+		t.recordWrite(key)
+		t.slotsUsed -= row.slots
+		saved.slots = t.slotCost(saved)
+		t.slotsUsed += saved.slots
+		t.rows[key] = saved
+		t.mutated()
+		return
+	}
+	t.addRow(key, saved)
+}
+
+// RollsBack returns true if the given write atomicity requires rolling back the applied updates of a failed write
+func RollsBack(atomicity p4api.WriteRequest_Atomicity) bool {
+	return atomicity == p4api.WriteRequest_ROLLBACK_ON_ERROR || atomicity == p4api.WriteRequest_DATAPLANE_ATOMIC
+}
+
+// RollBack reverts the effects of the applied updates by running their undos in reverse order
+func RollBack(undos []Undo) {
+	for i := len(undos) - 1; i >= 0; i-- {
+		undos[i]()
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func tableUpdate(updateType p4api.Update_Type, entry *p4api.TableEntry) *p4api.Update {
+	return &p4api.Update{Type: updateType, Entity: &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: entry}}}
+}
+
+func TestApplyUpdates(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.NoError(t, tables.ApplyUpdates(p4api.WriteRequest_ROLLBACK_ON_ERROR, []*p4api.Update{
+		tableUpdate(p4api.Update_INSERT, withAction(exactEntry(1, 1), 1)),
+		tableUpdate(p4api.Update_INSERT, withAction(exactEntry(2, 2), 2)),
+	}))
+	assert.Equal(t, 2, table.Size())
+
+	// A failed batch rolls back the inserts, modifies, deletes and direct counter updates applied before it
+	counter := &p4api.DirectCounterEntry{TableEntry: exactEntry(1, 1), Data: &p4api.CounterData{PacketCount: 7}}
+	err := tables.ApplyUpdates(p4api.WriteRequest_ROLLBACK_ON_ERROR, []*p4api.Update{
+		tableUpdate(p4api.Update_INSERT, exactEntry(3, 3)),
+		tableUpdate(p4api.Update_MODIFY, withAction(exactEntry(1, 1), 9)),
+		{Type: p4api.Update_MODIFY, Entity: &p4api.Entity{Entity: &p4api.Entity_DirectCounterEntry{DirectCounterEntry: counter}}},
+		tableUpdate(p4api.Update_DELETE, exactEntry(2, 2)),
+		tableUpdate(p4api.Update_MODIFY, &p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 4)}),
+		tableUpdate(p4api.Update_INSERT, exactEntry(1, 1)),
+	})
+	assert.True(t, errors.IsAlreadyExists(err))
+	assert.Equal(t, 2, table.Size())
+	assert.Nil(t, table.defaultRow)
+	for _, value := range []byte{1, 2} {
+		lr, err := table.Lookup(FieldValues{1: {value}, 2: {value}})
+		assert.NoError(t, err)
+		assert.True(t, lr.Hit)
+		assert.Equal(t, []byte{value}, lr.Action.Params[0].Value)
+	}
+	key := mustKey(t, table, exactEntry(1, 1))
+	assert.Equal(t, int64(0), table.rows[key].counterData.PacketCount)
+
+	// Without rollback, the updates applied before the failed update remain in effect
+	err = tables.ApplyUpdates(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		tableUpdate(p4api.Update_INSERT, exactEntry(3, 3)),
+		tableUpdate(p4api.Update_INSERT, exactEntry(3, 3)),
+	})
+	assert.True(t, errors.IsAlreadyExists(err))
+	assert.Equal(t, 3, table.Size())
+}
+
+func withAction(entry *p4api.TableEntry, value byte) *p4api.TableEntry {
+	entry.Action = directAction(1, value)
+	return entry
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"container/list"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// LRU cache of read results keyed by the canonicalized read request
+type readCache struct {
+	capacity int
+	results  map[string]*list.Element
+	order    *list.List
+	hits     uint64
+	misses   uint64
+}
+
+type cachedRead struct {
+	key      string
+	entities []*p4api.Entity
+}
+
+func newReadCache(capacity int) *readCache {
+	return &readCache{
+		capacity: capacity,
+		results:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// Returns the cache key for the specified request and read type; the request is expected to be canonicalized
+func readCacheKey(request *p4api.TableEntry, readType ReadType) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	return string(append(b, byte(readType))), nil
+}
+
+// Returns the cached entities for the given key, if any
+func (c *readCache) get(key string) ([]*p4api.Entity, bool) {
+	if e, ok := c.results[key]; ok {
+		c.hits++
+		c.order.MoveToFront(e)
+		return e.Value.(*cachedRead).entities, true
+	}
+	c.misses++
+	return nil, false
+}
+
+// Records the entities under the given key, evicting the least recently used result if at capacity
+func (c *readCache) put(key string, entities []*p4api.Entity) {
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.results, oldest.Value.(*cachedRead).key)
+	}
+	c.results[key] = c.order.PushFront(&cachedRead{key: key, entities: entities})
+}
+
+// Drops all cached results
+func (c *readCache) clear() {
+	c.results = make(map[string]*list.Element, c.capacity)
+	c.order.Init()
+}
+
+// EnableReadCache enables caching of up to the specified number of distinct read results; the cache is
+// invalidated on any table mutation; capacity of 0 disables the cache
+func (t *Table) EnableReadCache(capacity int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if capacity <= 0 {
+		t.cache = nil
+		return
+	}
+	t.cache = newReadCache(capacity)
+}
+
+// ReadCacheStats returns the number of read cache hits and misses
+func (t *Table) ReadCacheStats() (uint64, uint64) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.cache == nil {
+		return 0, 0
+	}
+	return t.cache.hits, t.cache.misses
+}
+
+// Records that the table has been mutated, invalidating any cached reads
+func (t *Table) mutated() {
+	if t.cache != nil {
+		t.cache.clear()
+	}
+}
+
+// Returns the entities for the specified request from the read cache, reading them from the table on a miss
+func (t *Table) cachedEntities(request *p4api.TableEntry, readType ReadType) ([]*p4api.Entity, error) {
+	entities := make([]*p4api.Entity, 0)
+	visit := func(request *p4api.TableEntry) {
+		_ = t.visitRows(request, func(row *Row) error {
+			entities = append(entities, t.getEntry(readType, row))
+			return nil
+		})
+	}
+
+	// Canonicalize a copy of the request, leaving the request of the caller untouched, so that requests which are
+	// equal once canonicalized share the cached result; requests which cannot be canonicalized are not cached
+	request = proto.Clone(request).(*p4api.TableEntry)
+	if err := t.canonicalizeMatches(request); err != nil {
+		visit(request)
+		return entities, nil
+	}
+	for _, m := range request.Match {
+		canonicalizeMatchValues(m)
+	}
+	key, err := readCacheKey(request, readType)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := t.cache.get(key); ok {
+		return cached, nil
+	}
+	visit(request)
+	t.cache.put(key, entities)
+	return entities, nil
+}
+
+// Strips the leading zeros of the values of the field match; matches compare values numerically, so this does not
+// change which entries the match selects
+func canonicalizeMatchValues(m *p4api.FieldMatch) {
+	switch {
+	case m.GetExact() != nil:
+		m.GetExact().Value = CanonicalValue(m.GetExact().Value)
+	case m.GetLpm() != nil:
+		m.GetLpm().Value = CanonicalValue(m.GetLpm().Value)
+	case m.GetTernary() != nil:
+		m.GetTernary().Value = CanonicalValue(m.GetTernary().Value)
+		m.GetTernary().Mask = CanonicalValue(m.GetTernary().Mask)
+	case m.GetRange() != nil:
+		m.GetRange().Low = CanonicalValue(m.GetRange().Low)
+		m.GetRange().High = CanonicalValue(m.GetRange().High)
+	case m.GetOptional() != nil:
+		m.GetOptional().Value = CanonicalValue(m.GetOptional().Value)
+	}
+}
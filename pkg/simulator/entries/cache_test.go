@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadCache(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	table.EnableReadCache(2)
+	for i := byte(0); i < 5; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(i, i), true))
+	}
+
+	read := func(request *p4api.TableEntry) []*p4api.Entity {
+		all := make([]*p4api.Entity, 0)
+		assert.NoError(t, table.ReadTableEntries(request, ReadTableEntry, func(entities []*p4api.Entity) error {
+			all = append(all, entities...)
+			return nil
+		}))
+		return all
+	}
+
+	first := read(&p4api.TableEntry{TableId: 1})
+	assert.Len(t, first, 5)
+	second := read(&p4api.TableEntry{TableId: 1})
+	assert.Equal(t, first, second)
+	hits, misses := table.ReadCacheStats()
+	assert.Equal(t, uint64(1), hits)
+	assert.Equal(t, uint64(1), misses)
+
+	// A different read type is cached separately
+	assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, func([]*p4api.Entity) error { return nil }))
+	_, misses = table.ReadCacheStats()
+	assert.Equal(t, uint64(2), misses)
+
+	// Mutation invalidates the cache
+	assert.NoError(t, tables.RemoveTableEntry(exactEntry(0, 0)))
+	third := read(&p4api.TableEntry{TableId: 1})
+	assert.Len(t, third, 4)
+	hits, misses = table.ReadCacheStats()
+	assert.Equal(t, uint64(1), hits)
+	assert.Equal(t, uint64(3), misses)
+
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(9, 9), true))
+	assert.Len(t, read(&p4api.TableEntry{TableId: 1}), 5)
+
+	// Requests equal once canonicalized share the cached result, and are left as they were
+	partial := func(value ...byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Match: []*p4api.FieldMatch{
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: value}}},
+		}}
+	}
+	hits, misses = table.ReadCacheStats()
+	assert.Len(t, read(partial(9)), 1)
+	padded := partial(0, 9)
+	assert.Len(t, read(padded), 1)
+	assert.Equal(t, []byte{0, 9}, padded.Match[0].GetExact().Value)
+	newHits, newMisses := table.ReadCacheStats()
+	assert.Equal(t, hits+1, newHits)
+	assert.Equal(t, misses+1, newMisses)
+
+	// Disabled cache records no stats
+	table.EnableReadCache(0)
+	assert.Len(t, read(&p4api.TableEntry{TableId: 1}), 5)
+	hits, misses = table.ReadCacheStats()
+	assert.Equal(t, uint64(0), hits+misses)
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"encoding/hex"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// CASDirectCounter atomically replaces the direct counter data of the entry with the given key, as produced by
+// EntryKey, with the new data, provided its current data, as read according to the direct counter unit, has the
+// packet and byte counts of the expected data; nil expected data stands for a zeroed counter and nil new data zeroes
+// the counter. Returns true if the data was swapped. Swaps are atomic with respect to one another, allowing
+// concurrent read-modify-write aggregation of counters without lost updates.
+func (t *Table) CASDirectCounter(entryKey string, expected *p4api.CounterData, new *p4api.CounterData) (bool, error) {
+	key, err := hex.DecodeString(entryKey)
+	if err != nil {
+		return false, errors.NewInvalid("invalid entry key %s: %v", entryKey, err)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.directCounter == nil {
+		return false, errors.NewInvalid("table %s has no direct counter", t.Name())
+	}
+	row, ok := t.rows[string(key)]
+	if !ok {
+		return false, errors.NewNotFound("entry with key %s doesn't exist", entryKey)
+	}
+
+	// Fold any staged increments which have become visible, so the comparison is against the current data
+	if t.pollInterval > 0 && row.staged != nil && row.staged.period < t.pollPeriod() {
+		t.foldStaged(row)
+	}
+	current := t.directCounterData(row.counterData)
+	if current.GetPacketCount() != expected.GetPacketCount() || current.GetByteCount() != expected.GetByteCount() {
+		return false, nil
+	}
+	if new != nil {
+		new = &p4api.CounterData{PacketCount: new.PacketCount, ByteCount: new.ByteCount}
+	}
+	row.setCounterData(new)
+	t.mutated()
+	return true, nil
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"encoding/hex"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestCASDirectCounter(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	entry := exactEntry(1, 1)
+	assert.NoError(t, table.ModifyTableEntry(entry, true))
+	key, err := table.EntryKey(entry)
+	assert.NoError(t, err)
+
+	// Swaps succeed only if the current data matches the expected data
+	swapped, err := table.CASDirectCounter(key, nil, &p4api.CounterData{PacketCount: 1, ByteCount: 100})
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+	swapped, err = table.CASDirectCounter(key, nil, &p4api.CounterData{PacketCount: 2, ByteCount: 200})
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+	swapped, err = table.CASDirectCounter(key, &p4api.CounterData{PacketCount: 1, ByteCount: 99}, nil)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+	assert.Equal(t, int64(100), table.rows[mustDecodeKey(t, key)].counterData.ByteCount)
+
+	_, err = table.CASDirectCounter("00", nil, nil)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = table.CASDirectCounter("not hex", nil, nil)
+	assert.True(t, errors.IsInvalid(err))
+
+	// Concurrent read-modify-write aggregation loses no updates
+	current := func() *p4api.CounterData {
+		table.lock.RLock()
+		defer table.lock.RUnlock()
+		return table.rows[mustDecodeKey(t, key)].counterData
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; {
+				expected := current()
+				swapped, err := table.CASDirectCounter(key, expected, &p4api.CounterData{
+					PacketCount: expected.PacketCount + 1, ByteCount: expected.ByteCount + 100})
+				assert.NoError(t, err)
+				if swapped {
+					i++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(801), current().PacketCount)
+	assert.Equal(t, int64(80100), current().ByteCount)
+}
+
+// Decodes the hex entry key into the internal row key
+func mustDecodeKey(t *testing.T, key string) string {
+	raw, err := hex.DecodeString(key)
+	assert.NoError(t, err)
+	return string(raw)
+}
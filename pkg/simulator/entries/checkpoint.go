@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+	"sort"
+)
+
+// Checkpoint records the current state of all table entries, along with their direct resources, under the given
+// checkpoint ID, replacing any previous checkpoint with the same ID
+func (ts *Tables) Checkpoint(checkpointID string) {
+	checkpoint := make(map[uint32]*Table, len(ts.tables))
+	for id, table := range ts.tables {
+		checkpoint[id] = table.checkpoint()
+	}
+	ts.checkpointLock.Lock()
+	defer ts.checkpointLock.Unlock()
+	if ts.checkpoints == nil {
+		ts.checkpoints = make(map[string]map[uint32]*Table)
+	}
+	ts.checkpoints[checkpointID] = checkpoint
+}
+
+// DeleteCheckpoint deletes the checkpoint with the given ID
+func (ts *Tables) DeleteCheckpoint(checkpointID string) {
+	ts.checkpointLock.Lock()
+	defer ts.checkpointLock.Unlock()
+	delete(ts.checkpoints, checkpointID)
+}
+
+// ReadAtCheckpoint reads the table entries matching the specified table entry as they existed when the given
+// checkpoint was recorded, without disturbing the current state of the tables
+func (ts *Tables) ReadAtCheckpoint(checkpointID string, request *p4api.TableEntry, readType ReadType, sender BatchSender) error {
+	ts.checkpointLock.RLock()
+	checkpoint, ok := ts.checkpoints[checkpointID]
+	ts.checkpointLock.RUnlock()
+	if !ok {
+		return errors.NewNotFound("checkpoint %s not found", checkpointID)
+	}
+
+	// If the table ID is 0, read all tables, ordered by ID
+	if request.TableId == 0 {
+		tables := make([]*Table, 0, len(checkpoint))
+		for _, table := range checkpoint {
+			tables = append(tables, table)
+		}
+		sort.Slice(tables, func(i, j int) bool { return tables[i].ID() < tables[j].ID() })
+		for _, table := range tables {
+			// Skip tables which do not have the requested direct resources
+			if table.validateReadType(readType) != nil {
+				continue
+			}
+			if err := table.ReadTableEntries(request, readType, sender); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	table, ok := checkpoint[request.TableId]
+	if !ok {
+		return errors.NewNotFound("table %d not found", request.TableId)
+	}
+	return table.ReadTableEntries(request, readType, sender)
+}
+
+// Returns a detached copy of the table holding copies of its current rows; the copy is read-only and is served
+// without read faults, caching, counter polling or read staleness
+func (t *Table) checkpoint() *Table {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	c := &Table{
+		tables:        t.tables,
+		info:          t.info,
+		rows:          make(map[string]*Row, len(t.rows)),
+		directCounter: t.directCounter,
+		directMeter:   t.directMeter,
+	}
+	for key, row := range t.rows {
+		c.rows[key] = row.checkpoint()
+	}
+	if t.defaultRow != nil {
+		c.defaultRow = t.defaultRow.checkpoint()
+	}
+	return c
+}
+
+// Returns a copy of the row with the visible state of its entry and direct resources
+func (r *Row) checkpoint() *Row {
+	c := &Row{
+		entry:        proto.Clone(r.entry).(*p4api.TableEntry),
+		modifiedAt:   r.modifiedAt,
+		lastHit:      r.lastHit,
+		idleNotified: r.idleNotified,
+		hits:         r.hits,
+	}
+	if r.counterData != nil {
+		c.counterData = proto.Clone(r.counterData).(*p4api.CounterData)
+	}
+	if r.meterConfig != nil {
+		c.meterConfig = proto.Clone(r.meterConfig).(*p4api.MeterConfig)
+	}
+	if r.meterData != nil {
+		c.meterData = proto.Clone(r.meterData).(*p4api.MeterCounterData)
+	}
+	return c
+}
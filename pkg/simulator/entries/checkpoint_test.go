@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestReadAtCheckpoint(t *testing.T) {
+	tables := newExactTables()
+	tables.SetActions(testActions)
+	table := tables.Table(1)
+	output := func(v1 byte, port byte) *p4api.TableEntry {
+		entry := exactEntry(v1, 0)
+		entry.Action = directAction(1, port)
+		return entry
+	}
+
+	// Reads the output ports of the entries, keyed by the value of the first field, either current or at checkpoint
+	read := func(checkpointID string, request *p4api.TableEntry) map[byte]byte {
+		ports := make(map[byte]byte)
+		sender := func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				entry := entity.GetTableEntry()
+				ports[entry.Match[0].GetExact().Value[0]] = entry.Action.GetAction().Params[0].Value[0]
+			}
+			return nil
+		}
+		if checkpointID == "" {
+			assert.NoError(t, tables.ReadTableEntries(request, ReadTableEntry, sender))
+		} else {
+			assert.NoError(t, tables.ReadAtCheckpoint(checkpointID, request, ReadTableEntry, sender))
+		}
+		return ports
+	}
+
+	assert.NoError(t, table.ModifyTableEntry(output(1, 1), true))
+	assert.NoError(t, table.ModifyTableEntry(output(2, 1), true))
+	assert.NoError(t, table.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(1, 0),
+		Data: &p4api.CounterData{PacketCount: 5}}))
+	tables.Checkpoint("before")
+
+	// Mutate the entries and their direct counters after the checkpoint
+	assert.NoError(t, table.ModifyTableEntry(output(1, 5), false))
+	assert.NoError(t, table.ModifyTableEntry(output(3, 1), true))
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(2, 0)))
+	assert.NoError(t, table.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(1, 0),
+		Data: &p4api.CounterData{PacketCount: 9}}))
+
+	assert.Equal(t, map[byte]byte{1: 5, 3: 1}, read("", &p4api.TableEntry{}))
+	assert.Equal(t, map[byte]byte{1: 1, 2: 1}, read("before", &p4api.TableEntry{}))
+	assert.Equal(t, map[byte]byte{1: 1, 2: 1}, read("before", &p4api.TableEntry{TableId: 1}))
+	assert.Equal(t, map[byte]byte{2: 1}, read("before", exactEntry(2, 0)))
+
+	// Direct counters are read as they were at the checkpoint
+	packets := func(read func(sender BatchSender) error) int64 {
+		count := int64(0)
+		assert.NoError(t, read(func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				count += entity.GetDirectCounterEntry().Data.PacketCount
+			}
+			return nil
+		}))
+		return count
+	}
+	assert.Equal(t, int64(9), packets(func(sender BatchSender) error {
+		return tables.ReadTableEntries(exactEntry(1, 0), ReadDirectCounter, sender)
+	}))
+	assert.Equal(t, int64(5), packets(func(sender BatchSender) error {
+		return tables.ReadAtCheckpoint("before", exactEntry(1, 0), ReadDirectCounter, sender)
+	}))
+
+	// Reading the checkpoint does not disturb the current state
+	assert.Equal(t, 2, table.Size())
+	assert.Equal(t, map[byte]byte{1: 5, 3: 1}, read("", &p4api.TableEntry{}))
+
+	// Unknown and deleted checkpoints are not found
+	unknown := tables.ReadAtCheckpoint("unknown", &p4api.TableEntry{}, ReadTableEntry, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(unknown))
+	tables.DeleteCheckpoint("before")
+	deleted := tables.ReadAtCheckpoint("before", &p4api.TableEntry{}, ReadTableEntry, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(deleted))
+}
+
+func TestReadAtCheckpointOrder(t *testing.T) {
+	infos := make([]*p4info.Table, 0)
+	for id := uint32(1); id <= 8; id++ {
+		infos = append(infos, &p4info.Table{Preamble: &p4info.Preamble{Id: id}, MatchFields: []*p4info.MatchField{
+			{Id: 1, Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		}})
+	}
+	tables := NewTables(infos)
+	for id := uint32(1); id <= 8; id++ {
+		assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: id, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1}}}},
+		}}, true))
+	}
+
+	// Checkpoints are recorded and deleted while being read; run with -race to detect unsynchronized accesses
+	tables.Checkpoint("c")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			tables.Checkpoint("other")
+			tables.DeleteCheckpoint("other")
+		}
+	}()
+
+	// All tables are read ordered by ID, like current reads
+	for i := 0; i < 50; i++ {
+		ids := make([]uint32, 0)
+		assert.NoError(t, tables.ReadAtCheckpoint("c", &p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				ids = append(ids, entity.GetTableEntry().TableId)
+			}
+			return nil
+		}))
+		assert.Equal(t, []uint32{1, 2, 3, 4, 5, 6, 7, 8}, ids)
+	}
+	wg.Wait()
+}
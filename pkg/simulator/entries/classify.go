@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+// Color represents the result of metering a packet
+type Color byte
+
+const (
+	// Green indicates the packet conforms to the committed rate
+	Green Color = iota
+	// Yellow indicates the packet exceeds the committed rate, but conforms to the peak rate
+	Yellow
+	// Red indicates the packet exceeds the peak rate
+	Red
+)
+
+// Two rate, three color token buckets of a single meter cell
+type tokenBuckets struct {
+	committed  float64
+	peak       float64
+	lastUpdate time.Time
+}
+
+// SetClock sets the clock used to replenish the meter token buckets
+func (ms *Meters) SetClock(clock Clock) {
+	ms.clock = clock
+}
+
+// Classify meters a packet of the given size, in bytes, against the specified meter cell using the two rate, three
+// color marker (RFC 2698) in color-blind mode. The token buckets are kept per cell, so all entries referencing the
+// same meter index share the same buckets. Cells without a configuration classify all packets as green. Packets
+// metered at an index outside of the meter size are classified as red and counted as out of range.
+func (ms *Meters) Classify(meterID uint32, index int64, size int64) (Color, error) {
+	meter, ok := ms.meters[meterID]
+	if !ok {
+		return Red, errors.NewNotFound("meter not found")
+	}
+	if index < 0 || int(index) >= len(meter.cells) {
+		meter.outOfRange++
+		return Red, errors.NewNotFound("meter index out of bounds")
+	}
+	config := meter.cells[index].Config
+	if config == nil {
+		return Green, nil
+	}
+	if meter.info.GetSpec().GetUnit() == p4info.MeterSpec_PACKETS {
+		size = 1
+	}
+
+	now := ms.clock()
+	buckets := meter.buckets[index]
+	if buckets == nil {
+		buckets = newTokenBuckets(config, now)
+		meter.buckets[index] = buckets
+	}
+	return buckets.classify(config, size, now), nil
+}
+
+// Creates full token buckets for the given meter configuration
+func newTokenBuckets(config *p4api.MeterConfig, now time.Time) *tokenBuckets {
+	return &tokenBuckets{committed: float64(config.Cburst), peak: float64(config.Pburst), lastUpdate: now}
+}
+
+// Replenishes the buckets at the configured rates and classifies a packet consuming the given number of tokens,
+// taking the tokens from the buckets the packet conforms to
+func (b *tokenBuckets) classify(config *p4api.MeterConfig, size int64, now time.Time) Color {
+	elapsed := now.Sub(b.lastUpdate).Seconds()
+	b.committed = minFloat(b.committed+elapsed*float64(config.Cir), float64(config.Cburst))
+	b.peak = minFloat(b.peak+elapsed*float64(config.Pir), float64(config.Pburst))
+	b.lastUpdate = now
+
+	tokens := float64(size)
+	switch {
+	case b.peak < tokens:
+		return Red
+	case b.committed < tokens:
+		b.peak -= tokens
+		return Yellow
+	default:
+		b.peak -= tokens
+		b.committed -= tokens
+		return Green
+	}
+}
+
+// MeterDirect meters a packet of the given size, in bytes, against the direct meter of the specified entry using
+// the two rate, three color marker (RFC 2698) in color-blind mode, adding the packet to the meter counter data of
+// the entry for its color. The token buckets are kept per entry and are refilled whenever the entry's meter
+// configuration changes. Entries without a meter configuration classify all packets as green.
+func (t *Table) MeterDirect(entry *p4api.TableEntry, size int64) (Color, error) {
+	if t.directMeter == nil {
+		return Red, errors.NewInvalid("table %s has no direct meter", t.Name())
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	sortFieldMatches(entry.Match)
+	key, err := t.entryKey(entry)
+	if err != nil {
+		return Red, err
+	}
+	row, ok := t.row(key, entry)
+	if !ok {
+		return Red, errors.NewNotFound("entry doesn't exist: %v", entry)
+	}
+
+	color := Green
+	if config := row.meterConfig; config != nil {
+		tokens := size
+		if t.directMeter.GetSpec().GetUnit() == p4info.MeterSpec_PACKETS {
+			tokens = 1
+		}
+		now := t.tables.clock()
+		if row.buckets == nil || !proto.Equal(row.buckets.config, config) {
+			row.buckets = &rowBuckets{tokenBuckets: newTokenBuckets(config, now), config: config}
+		}
+		color = row.buckets.classify(config, tokens, now)
+	}
+	row.addColorCounts(color, size)
+	t.mutated()
+	return color, nil
+}
+
+// Token buckets of a direct meter, along with the configuration they were filled for
+type rowBuckets struct {
+	*tokenBuckets
+	config *p4api.MeterConfig
+}
+
+// Adds a packet of the given size to the meter counter data of the given color; like the counter data, the meter
+// counter data is replaced rather than updated in place, as it may have been handed out by previous reads
+func (r *Row) addColorCounts(color Color, size int64) {
+	data := &p4api.MeterCounterData{}
+	if r.meterData != nil {
+		data = proto.Clone(r.meterData).(*p4api.MeterCounterData)
+	}
+	counts := &data.Red
+	switch color {
+	case Green:
+		counts = &data.Green
+	case Yellow:
+		counts = &data.Yellow
+	}
+	*counts = &p4api.CounterData{PacketCount: (*counts).GetPacketCount() + 1, ByteCount: (*counts).GetByteCount() + size}
+	r.meterData = data
+}
+
+// Returns the smaller of the two values
+func minFloat(a float64, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Resets the token buckets of the specified meter cell, e.g. when its configuration changes
+func (m *Meter) resetBuckets(index int64) {
+	m.buckets[index] = nil
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Creates meters with a single 4-cell meter metering bytes
+func newTestMeters(clock *fakeClock) *Meters {
+	meters := NewMeters([]*p4info.Meter{{
+		Preamble: &p4info.Preamble{Id: 7, Name: "policer"},
+		Spec:     &p4info.MeterSpec{Unit: p4info.MeterSpec_BYTES},
+		Size:     4,
+	}})
+	meters.SetClock(clock.Now)
+	return meters
+}
+
+func TestMeterClassify(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	meters := newTestMeters(clock)
+
+	// Unconfigured cells do not rate limit
+	color, err := meters.Classify(7, 0, 10000)
+	assert.NoError(t, err)
+	assert.Equal(t, Green, color)
+
+	assert.NoError(t, meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 7, Index: &p4api.Index{Index: 0},
+		Config: &p4api.MeterConfig{Cir: 1000, Cburst: 1000, Pir: 2000, Pburst: 2000}}, false))
+
+	colors := make([]Color, 0)
+	for i := 0; i < 5; i++ {
+		color, err = meters.Classify(7, 0, 500)
+		assert.NoError(t, err)
+		colors = append(colors, color)
+	}
+	assert.Equal(t, []Color{Green, Green, Yellow, Yellow, Red}, colors)
+
+	// Tokens are replenished at the configured rates
+	clock.Advance(500 * time.Millisecond)
+	color, _ = meters.Classify(7, 0, 500)
+	assert.Equal(t, Green, color)
+	color, _ = meters.Classify(7, 0, 500)
+	assert.Equal(t, Yellow, color)
+
+	_, err = meters.Classify(7, 4, 500)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = meters.Classify(8, 0, 500)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestSharedMeterClassify(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	meters := newTestMeters(clock)
+	for _, index := range []int64{1, 2} {
+		assert.NoError(t, meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 7, Index: &p4api.Index{Index: index},
+			Config: &p4api.MeterConfig{Cir: 1000, Cburst: 1000, Pir: 1000, Pburst: 1000}}, false))
+	}
+
+	tables := newExactTables()
+	tables.SetActions([]*p4info.Action{{Preamble: &p4info.Preamble{Id: 5, Name: "police"},
+		Params: []*p4info.Action_Param{{Id: 1, Name: "meter_index", Bitwidth: 8}}}})
+	table := tables.Table(1)
+	policed := func(v1 byte, index byte) *p4api.TableEntry {
+		entry := exactEntry(v1, 1)
+		entry.Action = &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{
+			ActionId: 5, Params: []*p4api.Action_Param{{ParamId: 1, Value: []byte{index}}}}}}
+		return entry
+	}
+	// Entries 1 and 2 share meter index 1; entry 3 has its own meter index 2
+	assert.NoError(t, table.ModifyTableEntry(policed(1, 1), true))
+	assert.NoError(t, table.ModifyTableEntry(policed(2, 1), true))
+	assert.NoError(t, table.ModifyTableEntry(policed(3, 2), true))
+
+	send := func(v1 byte, size int64) Color {
+		lr, err := table.Lookup(FieldValues{1: {v1}, 2: {1}})
+		assert.NoError(t, err)
+		value, ok := table.actionParamValue(lr.Entry.Action, "meter_index")
+		assert.True(t, ok)
+		color, err := meters.Classify(7, int64(DecodeValue(value, BigEndian)), size)
+		assert.NoError(t, err)
+		return color
+	}
+
+	assert.Equal(t, Green, send(1, 600))
+	// The second entry draws from the bucket drained by the first one
+	assert.Equal(t, Red, send(2, 600))
+	assert.Equal(t, Green, send(2, 400))
+	assert.Equal(t, Red, send(1, 100))
+	// The entry with its own meter index is not affected
+	assert.Equal(t, Green, send(3, 600))
+}
+
+func TestDirectMeterClassify(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables := newExactTables()
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+	assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(1, 1), 1), true))
+	meterData := func() *p4api.MeterCounterData {
+		var data *p4api.MeterCounterData
+		assert.NoError(t, table.ReadTableEntries(exactEntry(1, 1), ReadDirectMeter, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				data = entity.GetDirectMeterEntry().CounterData
+			}
+			return nil
+		}))
+		return data
+	}
+
+	// Entries without meter configuration do not rate limit
+	color, err := table.MeterDirect(exactEntry(1, 1), 10000)
+	assert.NoError(t, err)
+	assert.Equal(t, Green, color)
+	unlimited := meterData()
+
+	assert.NoError(t, table.ModifyDirectMeterEntry(&p4api.DirectMeterEntry{TableEntry: exactEntry(1, 1),
+		Config: &p4api.MeterConfig{Cir: 1000, Cburst: 1000, Pir: 2000, Pburst: 2000}}))
+	colors := make([]Color, 0)
+	for i := 0; i < 5; i++ {
+		color, err = table.MeterDirect(exactEntry(1, 1), 500)
+		assert.NoError(t, err)
+		colors = append(colors, color)
+	}
+	assert.Equal(t, []Color{Green, Green, Yellow, Yellow, Red}, colors)
+
+	// Packets are counted per color, without disturbing the data handed out by previous reads
+	data := meterData()
+	assert.Equal(t, int64(3), data.Green.PacketCount)
+	assert.Equal(t, int64(11000), data.Green.ByteCount)
+	assert.Equal(t, int64(2), data.Yellow.PacketCount)
+	assert.Equal(t, int64(1), data.Red.PacketCount)
+	assert.Equal(t, int64(500), data.Red.ByteCount)
+	assert.Equal(t, int64(1), unlimited.Green.PacketCount)
+
+	// Changing the meter configuration refills the buckets
+	assert.NoError(t, table.ModifyDirectMeterEntry(&p4api.DirectMeterEntry{TableEntry: exactEntry(1, 1),
+		Config: &p4api.MeterConfig{Cir: 1000, Cburst: 500, Pir: 2000, Pburst: 1000}}))
+	color, _ = table.MeterDirect(exactEntry(1, 1), 500)
+	assert.Equal(t, Green, color)
+
+	_, err = table.MeterDirect(exactEntry(2, 2), 500)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = newLPMTables().Table(2).MeterDirect(lpmEntry(1, []byte{10, 0, 0, 0}, 8), 500)
+	assert.True(t, errors.IsInvalid(err))
+}
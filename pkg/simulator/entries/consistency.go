@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"time"
+)
+
+// State of an entry as it was before writes which are not yet visible to reads
+type staleRow struct {
+	visibleAt time.Time
+	before    *Row
+}
+
+// SetReadStaleness sets the window after which table entry writes become visible to reads, modeling targets which
+// buffer writes; until then, reads see the entry as it was before the write. Window of 0 disables the staleness
+// model, making any pending writes immediately visible.
+func (t *Table) SetReadStaleness(window time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.readStaleness = window
+	if window == 0 {
+		t.stale = nil
+	}
+}
+
+// Records a write of the entry with the specified key, which is about to be applied; the state of the entry
+// before the first of any consecutive writes within the window remains visible until the window of the last
+// of those writes elapses
+func (t *Table) recordWrite(key string) {
+	if t.readStaleness == 0 {
+		return
+	}
+	if t.stale == nil {
+		t.stale = make(map[string]*staleRow)
+	}
+	now := t.tables.clock()
+	pending, ok := t.stale[key]
+	if !ok || !now.Before(pending.visibleAt) {
+		pending = &staleRow{}
+		if row, ok := t.rows[key]; ok {
+			before := *row
+			pending.before = &before
+		}
+		t.stale[key] = pending
+	}
+	pending.visibleAt = now.Add(t.readStaleness)
+}
+
+// Discards the state of entries whose writes have become visible; returns true if some writes are still pending
+func (t *Table) settleWrites() bool {
+	if len(t.stale) == 0 {
+		return false
+	}
+	now := t.tables.clock()
+	for key, pending := range t.stale {
+		if !now.Before(pending.visibleAt) {
+			delete(t.stale, key)
+		}
+	}
+	return len(t.stale) > 0
+}
+
+// Visits the rows as visible to reads, i.e. with any entries with pending writes in their state before the writes,
+// ordered by descending priority and then by their keys
+func (t *Table) visitVisibleRows(visitor func(row *Row) error) error {
+	stale := t.settleWrites()
+	rows := make([]keyedRow, 0, len(t.rows))
+	for key, row := range t.rows {
+		if pending, ok := t.stale[key]; ok && stale {
+			row = pending.before
+		}
+		if row != nil {
+			rows = append(rows, keyedRow{key: key, row: row})
+		}
+	}
+
+	// Include entries deleted by pending writes
+	if stale {
+		for key, pending := range t.stale {
+			if _, ok := t.rows[key]; !ok && pending.before != nil {
+				rows = append(rows, keyedRow{key: key, row: pending.before})
+			}
+		}
+	}
+
+	sortRows(rows)
+	for _, kr := range rows {
+		if err := visitor(kr.row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
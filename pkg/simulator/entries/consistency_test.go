@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReadStaleness(t *testing.T) {
+	tables := newExactTables()
+	tables.SetActions(testActions)
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+	table.EnableReadCache(16)
+
+	// Reads the output ports of the visible entries, keyed by the value of the first field
+	read := func(request *p4api.TableEntry) map[byte]byte {
+		ports := make(map[byte]byte)
+		assert.NoError(t, table.ReadTableEntries(request, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				entry := entity.GetTableEntry()
+				ports[entry.Match[0].GetExact().Value[0]] = entry.Action.GetAction().Params[0].Value[0]
+			}
+			return nil
+		}))
+		return ports
+	}
+	output := func(v1 byte, port byte) *p4api.TableEntry {
+		entry := exactEntry(v1, 0)
+		entry.Action = directAction(1, port)
+		return entry
+	}
+
+	assert.NoError(t, table.ModifyTableEntry(output(1, 1), true))
+	assert.NoError(t, table.ModifyTableEntry(output(2, 1), true))
+	table.SetReadStaleness(time.Second)
+
+	// Writes within the window are not yet visible, neither via scans nor via point reads
+	assert.NoError(t, table.ModifyTableEntry(output(1, 5), false))
+	assert.NoError(t, table.ModifyTableEntry(output(3, 1), true))
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(2, 0)))
+	assert.Equal(t, map[byte]byte{1: 1, 2: 1}, read(&p4api.TableEntry{}))
+	assert.Equal(t, map[byte]byte{1: 1}, read(exactEntry(1, 0)))
+	assert.Equal(t, map[byte]byte{}, read(exactEntry(3, 0)))
+
+	// Later writes extend the window of the entry, which still shows its state before the first write
+	clock.Advance(500 * time.Millisecond)
+	assert.NoError(t, table.ModifyTableEntry(output(1, 7), false))
+	clock.Advance(600 * time.Millisecond)
+	assert.Equal(t, map[byte]byte{1: 1, 3: 1}, read(&p4api.TableEntry{}))
+
+	// Once all windows elapse, reads see the latest writes
+	clock.Advance(400 * time.Millisecond)
+	assert.Equal(t, map[byte]byte{1: 7, 3: 1}, read(&p4api.TableEntry{}))
+	assert.Equal(t, map[byte]byte{1: 7}, read(exactEntry(1, 0)))
+
+	// Without the staleness model, writes are immediately visible
+	assert.NoError(t, table.ModifyTableEntry(output(3, 2), false))
+	table.SetReadStaleness(0)
+	assert.Equal(t, map[byte]byte{1: 7, 3: 2}, read(&p4api.TableEntry{}))
+}
@@ -8,17 +8,26 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
 	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"sync"
 )
 
 // Counter represents all cells of a specific counter
 type Counter struct {
-	info  *p4info.Counter
-	cells []*p4api.CounterEntry
+	info       *p4info.Counter
+	cells      []*p4api.CounterEntry
+	outOfRange uint64
+
+	// lock guards the cells and the out of range count; cells are replaced rather than changed in place, so that
+	// cells handed out to readers are never changed
+	lock sync.RWMutex
 }
 
-// Counters represents a set of P4 counters
+// Counters represents a set of P4 counters; the set itself is fixed on creation, while each counter guards its cells
 type Counters struct {
-	counters map[uint32]*Counter
+	counters  map[uint32]*Counter
+	readFault *LatencyFault
+
+	batching
 }
 
 // NewCounters creates a new counters store
@@ -36,8 +45,7 @@ func NewCounters(info []*p4info.Counter) *Counters {
 func (cs *Counters) NewCounter(info *p4info.Counter) *Counter {
 	cells := make([]*p4api.CounterEntry, info.Size)
 	for i := 0; i < int(info.Size); i++ {
-		// TODO: properly setup the counter spec
-		cells[i] = &p4api.CounterEntry{CounterId: info.Preamble.Id, Index: &p4api.Index{Index: int64(i)}}
+		cells[i] = &p4api.CounterEntry{CounterId: info.Preamble.Id, Index: &p4api.Index{Index: int64(i)}, Data: &p4api.CounterData{}}
 	}
 	return &Counter{
 		info:  info,
@@ -68,7 +76,105 @@ func (cs *Counters) ModifyCounterEntry(entry *p4api.CounterEntry, insert bool) e
 		return errors.NewNotFound("counter index out of bounds")
 	}
 
-	counter.cells[entry.Index.Index] = entry
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+	counter.cells[entry.Index.Index] = &p4api.CounterEntry{
+		CounterId: entry.CounterId,
+		Index:     &p4api.Index{Index: entry.Index.Index},
+		Data:      counter.unitData(entry.Data.GetPacketCount(), entry.Data.GetByteCount()),
+	}
+	return nil
+}
+
+// Increment adds the given packet and byte counts to the specified counter cell, as if counted by the datapath;
+// counts which the counter unit does not track are ignored. Increments at an index outside of the counter size
+// are dropped and counted as out of range.
+func (cs *Counters) Increment(counterID uint32, index int64, packets int64, bytes int64) error {
+	counter, ok := cs.counters[counterID]
+	if !ok {
+		return errors.NewNotFound("counter not found")
+	}
+
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+	if index < 0 || int(index) >= len(counter.cells) {
+		counter.outOfRange++
+		return errors.NewNotFound("counter index out of bounds")
+	}
+	cell := counter.cells[index]
+	counter.cells[index] = &p4api.CounterEntry{
+		CounterId: cell.CounterId,
+		Index:     cell.Index,
+		Data:      counter.unitData(cell.Data.GetPacketCount()+packets, cell.Data.GetByteCount()+bytes),
+	}
+	return nil
+}
+
+// Counter returns the counter with the specified ID; nil if there is no such counter
+func (cs *Counters) Counter(id uint32) *Counter {
+	return cs.counters[id]
+}
+
+// Returns counter data with only the counts tracked by the counter unit; counters with unspecified unit track both
+func (c *Counter) unitData(packets int64, bytes int64) *p4api.CounterData {
+	switch c.info.GetSpec().GetUnit() {
+	case p4info.CounterSpec_PACKETS:
+		return &p4api.CounterData{PacketCount: packets}
+	case p4info.CounterSpec_BYTES:
+		return &p4api.CounterData{ByteCount: bytes}
+	default:
+		return &p4api.CounterData{PacketCount: packets, ByteCount: bytes}
+	}
+}
+
+// SetReadFault sets the latency fault to be injected into counter reads; nil disables the fault
+func (cs *Counters) SetReadFault(fault *LatencyFault) {
+	cs.readFault = fault
+}
+
+// ReadCounterEntries reads the counter cells matching the specified counter entry; counter ID of 0 reads all
+// counters and a nil index reads all cells of the counter
+func (cs *Counters) ReadCounterEntries(request *p4api.CounterEntry, sender BatchSender) error {
+	cs.readFault.inject()
+	buffer := cs.newBuffer(sender)
+	if request.CounterId == 0 {
+		for _, counter := range cs.counters {
+			if err := counter.readCells(nil, buffer); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
+
+	counter, ok := cs.counters[request.CounterId]
+	if !ok {
+		return errors.NewNotFound("counter not found")
+	}
+	if err := counter.readCells(request.Index, buffer); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Sends either the cell at the specified index or all cells if the index is nil
+func (c *Counter) readCells(index *p4api.Index, buffer *entityBuffer) error {
+	if index != nil {
+		cell := c.Cell(index.Index)
+		if cell == nil {
+			return errors.NewNotFound("counter index out of bounds")
+		}
+		return buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_CounterEntry{CounterEntry: cell}})
+	}
+
+	// Send a snapshot of the cells, so that the sender is not called while holding the lock
+	c.lock.RLock()
+	cells := append([]*p4api.CounterEntry{}, c.cells...)
+	c.lock.RUnlock()
+	for _, cell := range cells {
+		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_CounterEntry{CounterEntry: cell}}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -87,7 +193,19 @@ func (c *Counter) Name() string {
 	return c.info.Preamble.Name
 }
 
-// Cell returns the specified cell of the counter
+// Cell returns the specified cell of the counter; nil if the index is outside of the counter size
 func (c *Counter) Cell(index int64) *p4api.CounterEntry {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if index < 0 || int(index) >= len(c.cells) {
+		return nil
+	}
 	return c.cells[index]
 }
+
+// OutOfRangeIncrements returns the number of datapath increments dropped due to an index outside of the counter size
+func (c *Counter) OutOfRangeIncrements() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.outOfRange
+}
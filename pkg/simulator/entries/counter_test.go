@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// Reads the counter cells matching the request, returning their data keyed by counter ID and index
+func readCounterData(t *testing.T, counters *Counters, request *p4api.CounterEntry) map[uint32]map[int64]*p4api.CounterData {
+	data := make(map[uint32]map[int64]*p4api.CounterData)
+	assert.NoError(t, counters.ReadCounterEntries(request, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			cell := entity.GetCounterEntry()
+			if data[cell.CounterId] == nil {
+				data[cell.CounterId] = make(map[int64]*p4api.CounterData)
+			}
+			data[cell.CounterId][cell.Index.Index] = cell.Data
+		}
+		return nil
+	}))
+	return data
+}
+
+func TestIndirectCounters(t *testing.T) {
+	counters := NewCounters([]*p4info.Counter{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "packets"}, Spec: &p4info.CounterSpec{Unit: p4info.CounterSpec_PACKETS}, Size: 4},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "bytes"}, Spec: &p4info.CounterSpec{Unit: p4info.CounterSpec_BYTES}, Size: 300},
+		{Preamble: &p4info.Preamble{Id: 3, Name: "both"}, Spec: &p4info.CounterSpec{Unit: p4info.CounterSpec_BOTH}, Size: 2},
+	})
+
+	// Cells exist from the start, with zero counts
+	data := readCounterData(t, counters, &p4api.CounterEntry{CounterId: 2})
+	assert.Len(t, data[2], 300)
+	assert.Equal(t, int64(0), data[2][299].ByteCount)
+
+	// Modifications and increments respect the counter unit
+	assert.True(t, errors.IsInvalid(counters.ModifyCounterEntry(&p4api.CounterEntry{CounterId: 1, Index: &p4api.Index{Index: 1}}, true)))
+	assert.True(t, errors.IsNotFound(counters.ModifyCounterEntry(&p4api.CounterEntry{CounterId: 1, Index: &p4api.Index{Index: 4}}, false)))
+	assert.True(t, errors.IsNotFound(counters.Increment(4, 0, 1, 1)))
+	assert.True(t, errors.IsNotFound(counters.Increment(1, 4, 1, 1)))
+	assert.NoError(t, counters.ModifyCounterEntry(&p4api.CounterEntry{CounterId: 1, Index: &p4api.Index{Index: 1},
+		Data: &p4api.CounterData{PacketCount: 10, ByteCount: 1000}}, false))
+	assert.NoError(t, counters.Increment(1, 1, 1, 100))
+	assert.NoError(t, counters.Increment(2, 7, 1, 100))
+	assert.NoError(t, counters.Increment(3, 0, 1, 100))
+	assert.NoError(t, counters.Increment(3, 0, 2, 200))
+
+	data = readCounterData(t, counters, &p4api.CounterEntry{CounterId: 1, Index: &p4api.Index{Index: 1}})
+	assert.Len(t, data[1], 1)
+	assert.Equal(t, int64(11), data[1][1].PacketCount)
+	assert.Equal(t, int64(0), data[1][1].ByteCount)
+
+	// Unset counter ID and index read all cells of all counters
+	data = readCounterData(t, counters, &p4api.CounterEntry{})
+	assert.Len(t, data, 3)
+	assert.Len(t, data[1], 4)
+	assert.Equal(t, &p4api.CounterData{ByteCount: 100}, data[2][7])
+	assert.Equal(t, &p4api.CounterData{PacketCount: 3, ByteCount: 300}, data[3][0])
+	assert.Equal(t, &p4api.CounterData{}, data[3][1])
+
+	// Modifying a cell without data resets it
+	assert.NoError(t, counters.ModifyCounterEntry(&p4api.CounterEntry{CounterId: 3, Index: &p4api.Index{Index: 0}}, false))
+	data = readCounterData(t, counters, &p4api.CounterEntry{CounterId: 3})
+	assert.Equal(t, &p4api.CounterData{}, data[3][0])
+}
+
+func TestCounterIncrementOutOfRange(t *testing.T) {
+	counters := NewCounters([]*p4info.Counter{{Preamble: &p4info.Preamble{Id: 1, Name: "c1"}, Size: 2}})
+	counter := counters.Counters()[0]
+
+	assert.NoError(t, counters.Increment(1, 0, 1, 100))
+	assert.NoError(t, counters.Increment(1, 1, 1, 100))
+	for _, index := range []int64{-1, 2, 1 << 40} {
+		assert.True(t, errors.IsNotFound(counters.Increment(1, index, 1, 100)))
+		assert.Nil(t, counter.Cell(index))
+	}
+	assert.Equal(t, uint64(3), counter.OutOfRangeIncrements())
+
+	// Valid cells are unaffected by the dropped increments
+	assert.Equal(t, int64(1), counter.Cell(0).Data.PacketCount)
+	assert.Equal(t, int64(1), counter.Cell(1).Data.PacketCount)
+}
+
+func TestDirectCounterUnits(t *testing.T) {
+	for _, c := range []struct {
+		unit     p4info.CounterSpec_Unit
+		expected *p4api.CounterData
+	}{
+		{p4info.CounterSpec_PACKETS, &p4api.CounterData{PacketCount: 3}},
+		{p4info.CounterSpec_BYTES, &p4api.CounterData{ByteCount: 300}},
+		{p4info.CounterSpec_BOTH, &p4api.CounterData{PacketCount: 3, ByteCount: 300}},
+		{p4info.CounterSpec_UNSPECIFIED, &p4api.CounterData{PacketCount: 3, ByteCount: 300}},
+	} {
+		tables := newExactTables()
+		tables.BindDirectResources([]*p4info.DirectCounter{{
+			Preamble:      &p4info.Preamble{Id: 11},
+			Spec:          &p4info.CounterSpec{Unit: c.unit},
+			DirectTableId: 1,
+		}}, nil)
+		table := tables.Table(1)
+		assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(1, 1), 1), true))
+		assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{
+			TableEntry: exactEntry(1, 1),
+			Data:       &p4api.CounterData{PacketCount: 3, ByteCount: 300},
+		}, false))
+
+		var data *p4api.CounterData
+		assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				data = entity.GetDirectCounterEntry().Data
+			}
+			return nil
+		}))
+		assert.Equal(t, c.expected.PacketCount, data.PacketCount, c.unit.String())
+		assert.Equal(t, c.expected.ByteCount, data.ByteCount, c.unit.String())
+
+		// Swaps compare against the data as read
+		key, err := table.EntryKey(exactEntry(1, 1))
+		assert.NoError(t, err)
+		ok, err := table.CASDirectCounter(key, data, nil)
+		assert.NoError(t, err)
+		assert.True(t, ok, c.unit.String())
+	}
+}
+
+func TestConcurrentCounterIncrements(t *testing.T) {
+	counters := NewCounters([]*p4info.Counter{{Preamble: &p4info.Preamble{Id: 1, Name: "c1"}, Size: 4}})
+
+	// Readers hold on to the cells they read while the datapath increments them; the cells read must not change
+	// afterwards. Run with -race to detect unsynchronized accesses.
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				assert.NoError(t, counters.Increment(1, int64(i%4), 1, 100))
+			}
+		}()
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				data := readCounterData(t, counters, &p4api.CounterEntry{CounterId: 1})
+				packets := data[1][0].PacketCount
+				assert.Equal(t, packets*100, data[1][0].ByteCount)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data := readCounterData(t, counters, &p4api.CounterEntry{CounterId: 1})
+	for index := int64(0); index < 4; index++ {
+		assert.Equal(t, &p4api.CounterData{PacketCount: 100, ByteCount: 10000}, data[1][index])
+	}
+}
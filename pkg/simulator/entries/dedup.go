@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// DedupedEntries is a compact export form of table entries in which each distinct action is stored only once
+type DedupedEntries struct {
+	Actions []*p4api.TableAction
+	Entries []*DedupedEntry
+}
+
+// DedupedEntry is a table entry stripped of its action along with index of its action in the unique actions;
+// index of -1 indicates an entry without an action
+type DedupedEntry struct {
+	Entry       *p4api.TableEntry
+	ActionIndex int
+}
+
+// ReadDeduplicated reads the table entries matching the specified request in the deduplicated export form
+func (t *Table) ReadDeduplicated(request *p4api.TableEntry) (*DedupedEntries, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	deduped := &DedupedEntries{
+		Actions: make([]*p4api.TableAction, 0),
+		Entries: make([]*DedupedEntry, 0, len(t.rows)+1),
+	}
+	indexes := make(map[string]int)
+	marshaller := proto.MarshalOptions{Deterministic: true}
+
+	add := func(entry *p4api.TableEntry) error {
+		de := &DedupedEntry{ActionIndex: -1}
+		if entry.Action != nil {
+			b, err := marshaller.Marshal(entry.Action)
+			if err != nil {
+				return err
+			}
+			index, ok := indexes[string(b)]
+			if !ok {
+				index = len(deduped.Actions)
+				indexes[string(b)] = index
+				deduped.Actions = append(deduped.Actions, entry.Action)
+			}
+			de.ActionIndex = index
+		}
+		de.Entry = proto.Clone(entry).(*p4api.TableEntry)
+		de.Entry.Action = nil
+		deduped.Entries = append(deduped.Entries, de)
+		return nil
+	}
+
+	// Select the entries, including the default entry, the same way as reads do
+	if err := t.visitRows(request, func(row *Row) error { return add(row.entry) }); err != nil {
+		return nil, err
+	}
+	return deduped, nil
+}
+
+// Reconstruct returns the original table entries with their actions restored; returns an error if any entry
+// refers to an action outside of the unique actions
+func (d *DedupedEntries) Reconstruct() ([]*p4api.TableEntry, error) {
+	entries := make([]*p4api.TableEntry, 0, len(d.Entries))
+	for _, de := range d.Entries {
+		if de.ActionIndex < -1 || de.ActionIndex >= len(d.Actions) {
+			return nil, errors.NewInvalid("action index %d out of range of %d actions", de.ActionIndex, len(d.Actions))
+		}
+		entry := proto.Clone(de.Entry).(*p4api.TableEntry)
+		if de.ActionIndex >= 0 {
+			entry.Action = proto.Clone(d.Actions[de.ActionIndex]).(*p4api.TableAction)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
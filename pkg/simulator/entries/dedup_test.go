@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+// Creates a direct table action with the given action ID and single parameter value
+func directAction(actionID uint32, value byte) *p4api.TableAction {
+	return &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{
+		ActionId: actionID,
+		Params:   []*p4api.Action_Param{{ParamId: 1, Value: []byte{value}}},
+	}}}
+}
+
+func TestReadDeduplicated(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	originals := make(map[string]*p4api.TableEntry)
+	for i := byte(1); i <= 10; i++ {
+		entry := exactEntry(i, 1)
+		entry.Action = directAction(1, i%3)
+		originals[mustKey(t, table, entry)] = proto.Clone(entry).(*p4api.TableEntry)
+		assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	}
+	noAction := exactEntry(0, 0)
+	originals[mustKey(t, table, noAction)] = proto.Clone(noAction).(*p4api.TableEntry)
+	assert.NoError(t, tables.ModifyTableEntry(noAction, true))
+
+	deduped, err := table.ReadDeduplicated(&p4api.TableEntry{})
+	assert.NoError(t, err)
+	assert.Len(t, deduped.Actions, 3)
+	assert.Len(t, deduped.Entries, 11)
+	for _, de := range deduped.Entries {
+		assert.Nil(t, de.Entry.Action)
+	}
+
+	entries, err := deduped.Reconstruct()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 11)
+	for _, entry := range entries {
+		original, ok := originals[mustKey(t, table, entry)]
+		assert.True(t, ok)
+		assert.True(t, proto.Equal(original, entry))
+	}
+
+	// Repeated reads yield the same export
+	again, err := table.ReadDeduplicated(&p4api.TableEntry{})
+	assert.NoError(t, err)
+	assert.Equal(t, len(deduped.Entries), len(again.Entries))
+	for i := range deduped.Entries {
+		assert.True(t, proto.Equal(deduped.Entries[i].Entry, again.Entries[i].Entry))
+		assert.Equal(t, deduped.Entries[i].ActionIndex, again.Entries[i].ActionIndex)
+	}
+	for i := range deduped.Actions {
+		assert.True(t, proto.Equal(deduped.Actions[i], again.Actions[i]))
+	}
+
+	// Action indexes outside of the unique actions are refused
+	for _, index := range []int{-2, len(deduped.Actions)} {
+		deduped.Entries[0].ActionIndex = index
+		_, err = deduped.Reconstruct()
+		assert.True(t, errors.IsInvalid(err), "index %d", index)
+	}
+
+	// The default entry is read only by requests which select it, as by regular reads
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+	for _, c := range []struct {
+		request  *p4api.TableEntry
+		entries  int
+		defaults int
+	}{
+		{&p4api.TableEntry{}, 12, 1},
+		{exactEntry(1, 1), 1, 0},
+		{&p4api.TableEntry{IsDefaultAction: true}, 1, 1},
+	} {
+		deduped, err = table.ReadDeduplicated(c.request)
+		assert.NoError(t, err)
+		assert.Len(t, deduped.Entries, c.entries)
+		defaults := 0
+		for _, de := range deduped.Entries {
+			if de.Entry.IsDefaultAction {
+				defaults++
+			}
+		}
+		assert.Equal(t, c.defaults, defaults, "request %v", c.request)
+	}
+}
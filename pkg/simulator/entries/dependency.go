@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// TableDependency describes a reference from the entries of a table to the entries of another table, by way of an
+// action parameter whose value is matched by a field of the referenced table, e.g. the next ID set by a forwarding
+// entry and matched by the next hop table
+type TableDependency struct {
+	// ParamName is the name of the action parameter carrying the reference
+	ParamName string
+	// TableID is the ID of the referenced table
+	TableID uint32
+	// FieldID is the ID of the match field of the referenced table matching the parameter value
+	FieldID uint32
+}
+
+// DependencyStats represents the install ordering statistics of a table with dependencies
+type DependencyStats struct {
+	// OrderingViolations is the number of entries installed while an entry they reference was absent
+	OrderingViolations uint64
+	// TransientMisses is the number of lookups which hit an entry referencing an absent entry, which a real
+	// target would drop
+	TransientMisses uint64
+}
+
+// AddDependency enables tracking of the validity of references from the table entries to the entries of another
+// table; entries installed before the entries they reference are counted as ordering violations and lookups
+// hitting them, while the referenced entry is absent, are counted as transient misses
+func (t *Table) AddDependency(dependency TableDependency) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	target, ok := t.tables.tables[dependency.TableID]
+	if !ok {
+		return errors.NewNotFound("table %d not found", dependency.TableID)
+	}
+	found := false
+	for _, field := range target.info.MatchFields {
+		found = found || field.Id == dependency.FieldID
+	}
+	if !found {
+		return errors.NewInvalid("field %d not found in table %s", dependency.FieldID, target.Name())
+	}
+	t.dependencies = append(t.dependencies, dependency)
+	return nil
+}
+
+// DependencyStats returns the install ordering statistics of the table
+func (t *Table) DependencyStats() DependencyStats {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.dependencyStats
+}
+
+// Counts an ordering violation if the given entry, just installed, references an absent entry
+func (t *Table) checkInstallOrder(entry *p4api.TableEntry) {
+	if len(t.dependencies) > 0 && !t.referencesResolved(entry) {
+		t.dependencyStats.OrderingViolations++
+	}
+}
+
+// Returns true if all references of the given entry resolve to entries present in the referenced tables
+func (t *Table) referencesResolved(entry *p4api.TableEntry) bool {
+	for _, dependency := range t.dependencies {
+		value, ok := t.actionParamValue(entry.Action, dependency.ParamName)
+		if !ok {
+			continue
+		}
+		target := t.tables.tables[dependency.TableID]
+		if !target.hasFieldValueLocked(t, dependency.FieldID, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if the table has an entry whose match of the specified field matches the given value, read-locking
+// the table unless it is the given table, whose lock is already held; as tables only lock the tables they depend
+// on, dependencies must not form cycles across distinct tables
+func (t *Table) hasFieldValueLocked(holder *Table, fieldID uint32, value []byte) bool {
+	if t != holder {
+		t.lock.RLock()
+		defer t.lock.RUnlock()
+	}
+	return t.hasFieldValue(fieldID, value)
+}
+
+// Returns true if the table has an entry whose match of the specified field matches the given value
+func (t *Table) hasFieldValue(fieldID uint32, value []byte) bool {
+	width := t.fieldWidth(fieldID)
+	for _, row := range t.rows {
+		for _, m := range row.entry.Match {
+			if m.FieldId == fieldID && fieldMatchesValue(m, value, width) {
+				return true
+			}
+		}
+	}
+	return false
+}
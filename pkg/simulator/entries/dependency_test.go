@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Creates forwarding and next hop tables, with forwarding entries referencing next hops by next ID
+func newDependentTables() *Tables {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "forwarding"}, MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "dst", Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "next"}, MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "next_id", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		}},
+	})
+	tables.SetActions([]*p4info.Action{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "set_next_id"}, Params: []*p4info.Action_Param{{Id: 1, Name: "next_id", Bitwidth: 32}}},
+	})
+	return tables
+}
+
+// Creates an entry of the given table matching the single field with the given value and optional next ID action
+func singleFieldEntry(tableID uint32, value byte, nextID byte) *p4api.TableEntry {
+	entry := &p4api.TableEntry{TableId: tableID, Match: []*p4api.FieldMatch{
+		{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{value}}}},
+	}}
+	if nextID != 0 {
+		entry.Action = directAction(1, nextID)
+	}
+	return entry
+}
+
+func TestInstallOrdering(t *testing.T) {
+	tables := newDependentTables()
+	forwarding, next := tables.Table(1), tables.Table(2)
+	assert.True(t, errors.IsNotFound(forwarding.AddDependency(TableDependency{ParamName: "next_id", TableID: 3, FieldID: 1})))
+	assert.True(t, errors.IsInvalid(forwarding.AddDependency(TableDependency{ParamName: "next_id", TableID: 2, FieldID: 2})))
+	assert.NoError(t, forwarding.AddDependency(TableDependency{ParamName: "next_id", TableID: 2, FieldID: 1}))
+
+	// Installing in dependency order causes no violations nor misses
+	assert.NoError(t, next.ModifyTableEntry(singleFieldEntry(2, 10, 0), true))
+	assert.NoError(t, forwarding.ModifyTableEntry(singleFieldEntry(1, 1, 10), true))
+	lr, err := forwarding.Lookup(FieldValues{1: {1}})
+	assert.NoError(t, err)
+	assert.False(t, lr.Dangling)
+	assert.Equal(t, DependencyStats{}, forwarding.DependencyStats())
+
+	// Installing the forwarding entry first leaves a window with transient misses
+	assert.NoError(t, forwarding.ModifyTableEntry(singleFieldEntry(1, 2, 20), true))
+	for i := 0; i < 3; i++ {
+		lr, err = forwarding.Lookup(FieldValues{1: {2}})
+		assert.NoError(t, err)
+		assert.True(t, lr.Hit)
+		assert.True(t, lr.Dangling)
+	}
+	assert.Equal(t, DependencyStats{OrderingViolations: 1, TransientMisses: 3}, forwarding.DependencyStats())
+
+	// Once the next hop is installed, the window closes
+	assert.NoError(t, next.ModifyTableEntry(singleFieldEntry(2, 20, 0), true))
+	lr, err = forwarding.Lookup(FieldValues{1: {2}})
+	assert.NoError(t, err)
+	assert.False(t, lr.Dangling)
+
+	// Removing a next hop still referenced reopens the window; modifies to absent next hops are violations too
+	assert.NoError(t, next.RemoveTableEntry(singleFieldEntry(2, 10, 0)))
+	lr, _ = forwarding.Lookup(FieldValues{1: {1}})
+	assert.True(t, lr.Dangling)
+	assert.NoError(t, forwarding.ModifyTableEntry(singleFieldEntry(1, 2, 30), false))
+	lr, _ = forwarding.Lookup(FieldValues{1: {2}})
+	assert.True(t, lr.Dangling)
+	assert.Equal(t, DependencyStats{OrderingViolations: 2, TransientMisses: 5}, forwarding.DependencyStats())
+
+	// Tables without dependencies do not track ordering
+	assert.Equal(t, DependencyStats{}, next.DependencyStats())
+}
@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"sort"
+	"time"
+)
+
+// Digest represents a P4 digest extern, its configuration and the digest data awaiting delivery
+type Digest struct {
+	info  *p4info.Digest
+	entry *p4api.DigestEntry
+
+	pending      []*p4api.P4Data
+	pendingSince time.Time
+	outstanding  *p4api.DigestList
+	sentAt       time.Time
+}
+
+// Digests represents a set of P4 digests
+type Digests struct {
+	digests    map[uint32]*Digest
+	clock      Clock
+	lastListID uint64
+
+	batching
+}
+
+// NewDigests creates a new digests store
+func NewDigests(info []*p4info.Digest) *Digests {
+	ds := &Digests{
+		digests: make(map[uint32]*Digest, len(info)),
+		clock:   time.Now,
+	}
+	for _, di := range info {
+		ds.digests[di.Preamble.Id] = &Digest{info: di}
+	}
+	return ds
+}
+
+// SetClock sets the clock used to batch the digest data; defaults to time.Now
+func (ds *Digests) SetClock(clock Clock) {
+	ds.clock = clock
+}
+
+// ModifyDigestEntry configures the specified digest; a digest can be inserted only if not yet configured and
+// modified only if already configured
+func (ds *Digests) ModifyDigestEntry(entry *p4api.DigestEntry, insert bool) error {
+	digest, ok := ds.digests[entry.DigestId]
+	if !ok {
+		return errors.NewNotFound("digest %d not found", entry.DigestId)
+	}
+	config := entry.Config
+	if config == nil {
+		return errors.NewInvalid("digest %s requires a config", digest.Name())
+	}
+	if config.MaxTimeoutNs < 0 || config.MaxListSize < 0 || config.AckTimeoutNs < 0 {
+		return errors.NewInvalid("invalid config of digest %s: %v", digest.Name(), config)
+	}
+	if insert && digest.entry != nil {
+		return errors.NewAlreadyExists("digest %s already configured", digest.Name())
+	}
+	if !insert && digest.entry == nil {
+		return errors.NewNotFound("digest %s not configured", digest.Name())
+	}
+	digest.entry = entry
+	return nil
+}
+
+// DeleteDigestEntry removes the configuration of the specified digest, discarding any digest data awaiting delivery
+func (ds *Digests) DeleteDigestEntry(entry *p4api.DigestEntry) error {
+	digest, ok := ds.digests[entry.DigestId]
+	if !ok {
+		return errors.NewNotFound("digest %d not found", entry.DigestId)
+	}
+	digest.entry = nil
+	digest.pending = nil
+	digest.outstanding = nil
+	return nil
+}
+
+// ReadDigestEntries reads the specified digest entry; digest ID of 0 reads all configured digests
+func (ds *Digests) ReadDigestEntries(request *p4api.DigestEntry, sender BatchSender) error {
+	buffer := ds.newBuffer(sender)
+	if request.DigestId == 0 {
+		for _, digest := range ds.Digests() {
+			if digest.entry == nil {
+				continue
+			}
+			if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_DigestEntry{DigestEntry: digest.entry}}); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
+
+	digest, ok := ds.digests[request.DigestId]
+	if !ok {
+		return errors.NewNotFound("digest %d not found", request.DigestId)
+	}
+	if digest.entry == nil {
+		return errors.NewNotFound("digest %s not configured", digest.Name())
+	}
+	if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_DigestEntry{DigestEntry: digest.entry}}); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Digests returns the list of digests, ordered by ID
+func (ds *Digests) Digests() []*Digest {
+	digests := make([]*Digest, 0, len(ds.digests))
+	for _, digest := range ds.digests {
+		digests = append(digests, digest)
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i].ID() < digests[j].ID() })
+	return digests
+}
+
+// AddDigestData queues the given data, as generated by the data path, for delivery by the specified digest; returns
+// the digest list to send, if the data completes a list, or nil if the data remains pending
+func (ds *Digests) AddDigestData(digestID uint32, data *p4api.P4Data) (*p4api.DigestList, error) {
+	digest, ok := ds.digests[digestID]
+	if !ok {
+		return nil, errors.NewNotFound("digest %d not found", digestID)
+	}
+	if digest.entry == nil {
+		return nil, errors.NewInvalid("digest %s not configured", digest.Name())
+	}
+	now := ds.clock()
+	if len(digest.pending) == 0 {
+		digest.pendingSince = now
+	}
+	digest.pending = append(digest.pending, data)
+	return ds.collect(digest, now), nil
+}
+
+// FlushDigestLists returns the digest lists whose pending data has been held for the maximum timeout of their
+// digest, ordered by digest ID
+func (ds *Digests) FlushDigestLists() []*p4api.DigestList {
+	now := ds.clock()
+	lists := make([]*p4api.DigestList, 0)
+	for _, digest := range ds.Digests() {
+		if list := ds.collect(digest, now); list != nil {
+			lists = append(lists, list)
+		}
+	}
+	return lists
+}
+
+// AckDigestList acknowledges the specified digest list, allowing the digest to send its next list
+func (ds *Digests) AckDigestList(ack *p4api.DigestListAck) error {
+	digest, ok := ds.digests[ack.DigestId]
+	if !ok {
+		return errors.NewNotFound("digest %d not found", ack.DigestId)
+	}
+	if digest.outstanding == nil || digest.outstanding.ListId != ack.ListId {
+		return errors.NewNotFound("digest list %d of digest %s not awaiting ack", ack.ListId, digest.Name())
+	}
+	digest.outstanding = nil
+	return nil
+}
+
+// Collects the pending data of the digest into a digest list, if the data fills the maximum list size or has been
+// held for the maximum timeout, and if no previous list is awaiting an ack within the ack timeout; returns nil
+// if the data is to remain pending
+func (ds *Digests) collect(digest *Digest, now time.Time) *p4api.DigestList {
+	if digest.entry == nil || len(digest.pending) == 0 {
+		return nil
+	}
+	config := digest.entry.Config
+	if digest.outstanding != nil && now.Sub(digest.sentAt) < time.Duration(config.AckTimeoutNs) {
+		return nil
+	}
+	maxSize := int(config.MaxListSize)
+	full := maxSize > 0 && len(digest.pending) >= maxSize
+	if !full && now.Sub(digest.pendingSince) < time.Duration(config.MaxTimeoutNs) {
+		return nil
+	}
+
+	count := len(digest.pending)
+	if maxSize > 0 && count > maxSize {
+		count = maxSize
+	}
+	ds.lastListID++
+	list := &p4api.DigestList{
+		DigestId:  digest.ID(),
+		ListId:    ds.lastListID,
+		Data:      digest.pending[:count:count],
+		Timestamp: now.UnixNano(),
+	}
+	digest.pending = digest.pending[count:]
+	digest.pendingSince = now
+	digest.outstanding = nil
+	if config.AckTimeoutNs > 0 {
+		digest.outstanding = list
+		digest.sentAt = now
+	}
+	return list
+}
+
+// ID returns the digest ID
+func (d *Digest) ID() uint32 {
+	return d.info.Preamble.Id
+}
+
+// Name returns the digest name
+func (d *Digest) Name() string {
+	return d.info.Preamble.Name
+}
+
+// Pending returns the number of digest data awaiting delivery
+func (d *Digest) Pending() int {
+	return len(d.pending)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Creates digest data holding the given byte
+func digestData(b byte) *p4api.P4Data {
+	return &p4api.P4Data{Data: &p4api.P4Data_Bitstring{Bitstring: []byte{b}}}
+}
+
+func TestDigestEntries(t *testing.T) {
+	digests := NewDigests([]*p4info.Digest{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "mac_learn"}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "arp_learn"}},
+	})
+	config := &p4api.DigestEntry_Config{MaxListSize: 2, MaxTimeoutNs: int64(time.Second)}
+
+	assert.True(t, errors.IsNotFound(digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 3, Config: config}, true)))
+	assert.True(t, errors.IsInvalid(digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1}, true)))
+	assert.True(t, errors.IsInvalid(digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1,
+		Config: &p4api.DigestEntry_Config{MaxListSize: -1}}, true)))
+	assert.True(t, errors.IsNotFound(digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1, Config: config}, false)))
+	assert.NoError(t, digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1, Config: config}, true))
+	assert.True(t, errors.IsAlreadyExists(digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1, Config: config}, true)))
+	assert.NoError(t, digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1, Config: config}, false))
+
+	// Only configured digests are read back
+	read := func(digestID uint32) ([]*p4api.Entity, error) {
+		entities := make([]*p4api.Entity, 0)
+		err := digests.ReadDigestEntries(&p4api.DigestEntry{DigestId: digestID}, func(batch []*p4api.Entity) error {
+			entities = append(entities, batch...)
+			return nil
+		})
+		return entities, err
+	}
+	entities, err := read(0)
+	assert.NoError(t, err)
+	assert.Len(t, entities, 1)
+	assert.Equal(t, uint32(1), entities[0].GetDigestEntry().DigestId)
+	_, err = read(2)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Unconfigured digests do not accept data
+	_, err = digests.AddDigestData(2, digestData(1))
+	assert.True(t, errors.IsInvalid(err))
+	assert.NoError(t, digests.DeleteDigestEntry(&p4api.DigestEntry{DigestId: 1}))
+	_, err = digests.AddDigestData(1, digestData(1))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestDigestBatching(t *testing.T) {
+	digests := NewDigests([]*p4info.Digest{{Preamble: &p4info.Preamble{Id: 1, Name: "mac_learn"}}})
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	digests.SetClock(clock.Now)
+	assert.NoError(t, digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1, Config: &p4api.DigestEntry_Config{
+		MaxListSize: 3, MaxTimeoutNs: int64(time.Second), AckTimeoutNs: int64(5 * time.Second)}}, true))
+
+	// Data is coalesced up to the maximum list size
+	for i := byte(1); i <= 2; i++ {
+		list, err := digests.AddDigestData(1, digestData(i))
+		assert.NoError(t, err)
+		assert.Nil(t, list)
+	}
+	list, err := digests.AddDigestData(1, digestData(3))
+	assert.NoError(t, err)
+	assert.NotNil(t, list)
+	assert.Len(t, list.Data, 3)
+	assert.Equal(t, uint64(1), list.ListId)
+
+	// Pending data waits for the ack of the outstanding list, or for the ack timeout
+	for i := byte(4); i <= 7; i++ {
+		list, err = digests.AddDigestData(1, digestData(i))
+		assert.NoError(t, err)
+		assert.Nil(t, list)
+	}
+	clock.Advance(2 * time.Second)
+	assert.Len(t, digests.FlushDigestLists(), 0)
+	assert.True(t, errors.IsNotFound(digests.AckDigestList(&p4api.DigestListAck{DigestId: 1, ListId: 2})))
+	assert.NoError(t, digests.AckDigestList(&p4api.DigestListAck{DigestId: 1, ListId: 1}))
+	lists := digests.FlushDigestLists()
+	assert.Len(t, lists, 1)
+	assert.Equal(t, []*p4api.P4Data{digestData(4), digestData(5), digestData(6)}, lists[0].Data)
+	assert.Equal(t, 1, digests.Digests()[0].Pending())
+
+	// Unacknowledged lists no longer hold back the pending data after the ack timeout
+	clock.Advance(2 * time.Second)
+	assert.Len(t, digests.FlushDigestLists(), 0)
+	clock.Advance(3 * time.Second)
+	lists = digests.FlushDigestLists()
+	assert.Len(t, lists, 1)
+	assert.Equal(t, []*p4api.P4Data{digestData(7)}, lists[0].Data)
+	assert.Equal(t, uint64(3), lists[0].ListId)
+
+	// Without an ack timeout, partial lists are flushed at the maximum timeout
+	assert.NoError(t, digests.ModifyDigestEntry(&p4api.DigestEntry{DigestId: 1, Config: &p4api.DigestEntry_Config{
+		MaxListSize: 3, MaxTimeoutNs: int64(time.Second)}}, false))
+	list, err = digests.AddDigestData(1, digestData(8))
+	assert.NoError(t, err)
+	assert.Nil(t, list)
+	clock.Advance(500 * time.Millisecond)
+	assert.Len(t, digests.FlushDigestLists(), 0)
+	clock.Advance(500 * time.Millisecond)
+	lists = digests.FlushDigestLists()
+	assert.Len(t, lists, 1)
+	assert.Len(t, lists[0].Data, 1)
+	assert.Equal(t, clock.Now().UnixNano(), lists[0].Timestamp)
+}
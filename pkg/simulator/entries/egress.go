@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"sort"
+)
+
+// PortBatchSender is an abstract function for returning batches of read entities grouped by egress port
+type PortBatchSender func(port uint32, entities []*p4api.Entity) error
+
+// ReadGroupedByEgressPort reads the table entries grouped by the egress port given by the named parameter
+// of their action, emitting the groups in ascending port order; entries whose action has no such parameter
+// are omitted
+func (t *Table) ReadGroupedByEgressPort(egressActionParamName string, sender PortBatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	groups := make(map[uint32][]*Row)
+	for _, row := range t.orderedRows() {
+		if value, ok := t.actionParamValue(row.entry.Action, egressActionParamName); ok {
+			port := uint32(DecodeValue(value, BigEndian))
+			groups[port] = append(groups[port], row)
+		}
+	}
+
+	ports := make([]uint32, 0, len(groups))
+	for port := range groups {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+
+	for _, port := range ports {
+		p := port
+		buffer := t.tables.newBuffer(func(entities []*p4api.Entity) error { return sender(p, entities) })
+		for _, row := range groups[port] {
+			if err := buffer.sendEntity(t.getEntry(ReadTableEntry, row)); err != nil {
+				return err
+			}
+		}
+		if err := buffer.flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the value of the named parameter of the resolved table action
+func (t *Table) actionParamValue(action *p4api.TableAction, paramName string) ([]byte, bool) {
+	a := t.resolveAction(action)
+	if a == nil {
+		return nil, false
+	}
+	info, ok := t.tables.actions[a.ActionId]
+	if !ok {
+		return nil, false
+	}
+	for _, pi := range info.Params {
+		if pi.Name == paramName {
+			for _, param := range a.Params {
+				if param.ParamId == pi.Id {
+					return param.Value, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
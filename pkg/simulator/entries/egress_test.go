@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Test actions: output with a port parameter, and drop with no parameters
+var testActions = []*p4info.Action{
+	{Preamble: &p4info.Preamble{Id: 1, Name: "output"}, Params: []*p4info.Action_Param{{Id: 1, Name: "port_num", Bitwidth: 9}}},
+	{Preamble: &p4info.Preamble{Id: 2, Name: "drop"}},
+}
+
+func TestReadGroupedByEgressPort(t *testing.T) {
+	tables := newExactTables()
+	tables.SetActions(testActions)
+	table := tables.Table(1)
+
+	expected := map[uint32]int{1: 3, 2: 2, 5: 1}
+	i := byte(0)
+	for port, n := range expected {
+		for j := 0; j < n; j++ {
+			entry := exactEntry(i, 0)
+			entry.Action = directAction(1, byte(port))
+			assert.NoError(t, tables.ModifyTableEntry(entry, true))
+			i++
+		}
+	}
+	drop := exactEntry(100, 0)
+	drop.Action = &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{ActionId: 2}}}
+	assert.NoError(t, tables.ModifyTableEntry(drop, true))
+
+	ports := make([]uint32, 0)
+	counts := make(map[uint32]int)
+	err := table.ReadGroupedByEgressPort("port_num", func(port uint32, entities []*p4api.Entity) error {
+		ports = append(ports, port)
+		for _, entity := range entities {
+			value := entity.GetTableEntry().Action.GetAction().Params[0].Value
+			assert.Equal(t, port, uint32(DecodeValue(value, BigEndian)))
+		}
+		counts[port] += len(entities)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2, 5}, ports)
+	assert.Equal(t, expected, counts)
+
+	// Unknown parameter yields no groups
+	err = table.ReadGroupedByEgressPort("foo", func(port uint32, entities []*p4api.Entity) error {
+		assert.Fail(t, "unexpected group")
+		return nil
+	})
+	assert.NoError(t, err)
+}
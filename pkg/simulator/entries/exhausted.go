@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// ResourceExhaustedError is returned for inserts refused because of a capacity limit, e.g. the declared size of a
+// table; it maps to the RESOURCE_EXHAUSTED gRPC status, which onos-lib-go errors cannot represent
+type ResourceExhaustedError struct {
+	message string
+}
+
+// NewResourceExhausted returns a new ResourceExhaustedError with the given formatted message
+func NewResourceExhausted(format string, args ...interface{}) *ResourceExhaustedError {
+	return &ResourceExhaustedError{message: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the error message
+func (e *ResourceExhaustedError) Error() string {
+	return e.message
+}
+
+// GRPCStatus returns the RESOURCE_EXHAUSTED status of the error
+func (e *ResourceExhaustedError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.ResourceExhausted, e.message)
+}
+
+// IsResourceExhausted returns true if the given error is a ResourceExhaustedError
+func IsResourceExhausted(err error) bool {
+	_, ok := err.(*ResourceExhaustedError)
+	return ok
+}
+
+// ErrorStatus returns the gRPC status of the given error, including RESOURCE_EXHAUSTED for ResourceExhaustedError
+func ErrorStatus(err error) *grpcstatus.Status {
+	if exhausted, ok := err.(*ResourceExhaustedError); ok {
+		return exhausted.GRPCStatus()
+	}
+	return errors.Status(err)
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LatencyFault injects a fixed latency into a configurable fraction of operations
+type LatencyFault struct {
+	lock     sync.Mutex
+	fraction float64
+	latency  time.Duration
+	rand     *rand.Rand
+	sleep    func(time.Duration)
+}
+
+// NewLatencyFault creates a new latency fault which delays the given fraction (0.0 - 1.0) of operations by
+// the specified latency; the seed allows the fault pattern to be reproduced
+func NewLatencyFault(fraction float64, latency time.Duration, seed int64) *LatencyFault {
+	return &LatencyFault{
+		fraction: fraction,
+		latency:  latency,
+		rand:     rand.New(rand.NewSource(seed)),
+		sleep:    time.Sleep,
+	}
+}
+
+// Injects the latency if the operation falls within the faulty fraction; returns true if latency was injected
+func (f *LatencyFault) inject() bool {
+	if f == nil {
+		return false
+	}
+	f.lock.Lock()
+	hit := f.rand.Float64() < f.fraction
+	f.lock.Unlock()
+	if hit {
+		f.sleep(f.latency)
+	}
+	return hit
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Replaces the fault sleep function with one that records the injected latencies
+func recordLatencies(fault *LatencyFault) *[]time.Duration {
+	latencies := make([]time.Duration, 0)
+	fault.sleep = func(d time.Duration) { latencies = append(latencies, d) }
+	return &latencies
+}
+
+func TestCounterReadLatencyFault(t *testing.T) {
+	counters := NewCounters([]*p4info.Counter{{Preamble: &p4info.Preamble{Id: 1}, Size: 4}})
+	fault := NewLatencyFault(0.25, 50*time.Millisecond, 1)
+	latencies := recordLatencies(fault)
+	counters.SetReadFault(fault)
+
+	reads := 1000
+	for i := 0; i < reads; i++ {
+		count := 0
+		err := counters.ReadCounterEntries(&p4api.CounterEntry{CounterId: 1}, func(entities []*p4api.Entity) error {
+			count += len(entities)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, count)
+	}
+	assert.InDelta(t, 0.25, float64(len(*latencies))/float64(reads), 0.05)
+	for _, d := range *latencies {
+		assert.Equal(t, 50*time.Millisecond, d)
+	}
+}
+
+func TestDirectCounterReadLatencyFault(t *testing.T) {
+	tables := newExactTables()
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 1), true))
+
+	// With no fault, no latency is injected
+	fault := NewLatencyFault(0.5, time.Second, 42)
+	latencies := recordLatencies(fault)
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, func([]*p4api.Entity) error { return nil }))
+
+	// Entry reads are unaffected by the fault
+	tables.Table(1).SetCounterReadFault(fault)
+	reads := 1000
+	for i := 0; i < reads; i++ {
+		assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, func([]*p4api.Entity) error { return nil }))
+	}
+	assert.Len(t, *latencies, 0)
+
+	for i := 0; i < reads; i++ {
+		assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, func([]*p4api.Entity) error { return nil }))
+	}
+	assert.InDelta(t, 0.5, float64(len(*latencies))/float64(reads), 0.05)
+
+	// Disabling the fault stops the injection
+	tables.Table(1).SetCounterReadFault(nil)
+	injected := len(*latencies)
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, func([]*p4api.Entity) error { return nil }))
+	assert.Len(t, *latencies, injected)
+
+	// The latency is injected without holding the table lock, leaving writers unblocked
+	fault = NewLatencyFault(1, time.Second, 42)
+	fault.sleep = func(time.Duration) {
+		assert.True(t, tables.Table(1).lock.TryLock())
+		tables.Table(1).lock.Unlock()
+	}
+	tables.Table(1).SetCounterReadFault(fault)
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, func([]*p4api.Entity) error { return nil }))
+}
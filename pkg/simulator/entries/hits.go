@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"sort"
+)
+
+// EntryHits represents the number of lookups which resolved to a table entry; unlike direct counters, hits are
+// tracked for every entry, regardless of the P4 program, and are not visible to P4Runtime clients
+type EntryHits struct {
+	Entry *p4api.TableEntry
+	Hits  uint64
+}
+
+// TopHitEntries returns up to n entries of the table with the most lookup hits, in descending order of hits; entries
+// with the same number of hits are in the order of Entries. The default entry is not included; n of 0 or less
+// returns all entries.
+func (t *Table) TopHitEntries(n int) []*EntryHits {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	rows := t.orderedRows()
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].hits > rows[j].hits })
+	if n > 0 && n < len(rows) {
+		rows = rows[:n]
+	}
+	top := make([]*EntryHits, 0, len(rows))
+	for _, row := range rows {
+		top = append(top, &EntryHits{Entry: row.entry, Hits: row.hits})
+	}
+	return top
+}
+
+// LookupMisses returns the number of lookups which did not match any entry of the table
+func (t *Table) LookupMisses() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.misses
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTopHitEntries(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(1); i <= 3; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, 0), true))
+	}
+	assert.Len(t, table.TopHitEntries(0), 3)
+
+	// Each lookup resolving to an entry counts as a hit of that entry
+	lookups := map[byte]int{1: 2, 2: 5, 4: 1}
+	for value, count := range lookups {
+		for i := 0; i < count; i++ {
+			_, err := table.Lookup(FieldValues{1: {value}, 2: {0}})
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, table.RecordHit(exactEntry(3, 0)))
+	assert.Equal(t, uint64(1), table.LookupMisses())
+
+	top := table.TopHitEntries(2)
+	assert.Len(t, top, 2)
+	assert.Equal(t, exactEntry(2, 0).Match[0].GetExact().Value, top[0].Entry.Match[0].GetExact().Value)
+	assert.Equal(t, uint64(5), top[0].Hits)
+	assert.Equal(t, uint64(2), top[1].Hits)
+	all := table.TopHitEntries(10)
+	assert.Len(t, all, 3)
+	assert.Equal(t, uint64(1), all[2].Hits)
+
+	// Hits survive modifications of the entry
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(2, 0), false))
+	assert.Equal(t, uint64(5), table.TopHitEntries(1)[0].Hits)
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"time"
+)
+
+// Records a datapath hit of the row at the given time, refreshing its idle timer and counting the hit
+func (r *Row) hit(now time.Time) {
+	r.lastHit = now
+	r.hits++
+	r.idleNotified = false
+}
+
+// Returns true if the row has been idle for at least its idle timeout; entries without idle timeout never expire
+func (r *Row) isIdle(now time.Time) bool {
+	return r.entry.IdleTimeoutNs > 0 && now.Sub(r.lastHit) >= time.Duration(r.entry.IdleTimeoutNs)
+}
+
+// RecordHit records a datapath hit of the specified entry, as if by a lookup, refreshing its idle timer
+func (t *Table) RecordHit(entry *p4api.TableEntry) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	sortFieldMatches(entry.Match)
+	key, err := t.entryKey(entry)
+	if err != nil {
+		return err
+	}
+	row, ok := t.row(key, entry)
+	if !ok {
+		return errors.NewNotFound("entry doesn't exist: %v", entry)
+	}
+	row.hit(t.tables.clock())
+	return nil
+}
+
+// SweepIdleEntries returns the entries which have not been hit for at least their idle timeout, provided the
+// table supports idle timeout notifications. As per P4Runtime idle timeout semantics, the expired entries are not
+// removed; each is reported only once until it is hit again, giving the controller a chance to remove it.
+func (t *Table) SweepIdleEntries() []*p4api.TableEntry {
+	expired := make([]*p4api.TableEntry, 0)
+	if t.info.IdleTimeoutBehavior != p4info.Table_NOTIFY_CONTROL {
+		return expired
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := t.tables.clock()
+	for _, row := range t.rows {
+		if !row.idleNotified && row.isIdle(now) {
+			row.idleNotified = true
+			expired = append(expired, row.entry)
+		}
+	}
+	return expired
+}
+
+// ReadByIdleTimeout reads the table entries which have an idle timeout configured, if hasTimeout is true, or which
+// have no idle timeout, otherwise
+func (t *Table) ReadByIdleTimeout(hasTimeout bool, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	buffer := t.tables.newBuffer(sender)
+	if err := t.visitRows(&p4api.TableEntry{}, func(row *Row) error {
+		if (row.entry.IdleTimeoutNs != 0) != hasTimeout {
+			return nil
+		}
+		return buffer.sendEntity(t.getEntry(ReadTableEntry, row))
+	}); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
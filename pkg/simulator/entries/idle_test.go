@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Creates an exact entry with the given idle timeout
+func idleEntry(v1 byte, timeout time.Duration) *p4api.TableEntry {
+	entry := exactEntry(v1, 1)
+	entry.IdleTimeoutNs = int64(timeout)
+	return entry
+}
+
+func TestSweepIdleEntries(t *testing.T) {
+	tables := newExactTables()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+	table.info.IdleTimeoutBehavior = p4info.Table_NOTIFY_CONTROL
+
+	assert.NoError(t, table.ModifyTableEntry(idleEntry(1, 10*time.Second), true))
+	assert.NoError(t, table.ModifyTableEntry(idleEntry(2, 10*time.Second), true))
+	assert.NoError(t, table.ModifyTableEntry(idleEntry(3, 0), true))
+
+	// Entry 1 is hit periodically and never ages out; entry 2 is idle and does
+	for i := 0; i < 5; i++ {
+		clock.Advance(4 * time.Second)
+		lr, err := table.Lookup(FieldValues{1: {1}, 2: {1}})
+		assert.NoError(t, err)
+		assert.True(t, lr.Hit)
+
+		expired := table.SweepIdleEntries()
+		if i < 2 {
+			assert.Len(t, expired, 0)
+		} else if i == 2 {
+			assert.Len(t, expired, 1)
+			assert.Equal(t, []byte{2}, expired[0].Match[0].GetExact().Value)
+		} else {
+			// Already reported entries are not reported again
+			assert.Len(t, expired, 0)
+		}
+	}
+
+	// Expired entries are not removed; a hit re-arms the notification
+	assert.Equal(t, 3, table.Size())
+	lr, err := table.Lookup(FieldValues{1: {2}, 2: {1}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+	assert.Len(t, table.SweepIdleEntries(), 0)
+	clock.Advance(10 * time.Second)
+	expired := table.SweepIdleEntries()
+	assert.Len(t, expired, 2)
+
+	// Tables without idle timeout notification never expire entries
+	table.info.IdleTimeoutBehavior = p4info.Table_NO_TIMEOUT
+	clock.Advance(time.Minute)
+	assert.NoError(t, table.ModifyTableEntry(idleEntry(4, time.Second), true))
+	clock.Advance(time.Minute)
+	assert.Len(t, table.SweepIdleEntries(), 0)
+}
+
+func TestReadByIdleTimeout(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(1); i <= 10; i++ {
+		timeout := time.Duration(0)
+		if i%3 == 0 {
+			timeout = time.Duration(i) * time.Second
+		}
+		assert.NoError(t, table.ModifyTableEntry(idleEntry(i, timeout), true))
+	}
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+
+	read := func(hasTimeout bool) map[byte]int64 {
+		timeouts := make(map[byte]int64)
+		assert.NoError(t, table.ReadByIdleTimeout(hasTimeout, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				entry := entity.GetTableEntry()
+				v := byte(0)
+				if !entry.IsDefaultAction {
+					v = entry.Match[0].GetExact().Value[0]
+				}
+				timeouts[v] = entry.IdleTimeoutNs
+			}
+			return nil
+		}))
+		return timeouts
+	}
+
+	timed := read(true)
+	assert.Equal(t, map[byte]int64{3: int64(3 * time.Second), 6: int64(6 * time.Second), 9: int64(9 * time.Second)}, timed)
+
+	untimed := read(false)
+	assert.Len(t, untimed, 8)
+	for v, timeout := range untimed {
+		assert.True(t, v == 0 || v%3 != 0, "entry %d", v)
+		assert.Equal(t, int64(0), timeout)
+	}
+	// The default entry never has an idle timeout
+	assert.Contains(t, untimed, byte(0))
+}
+
+func TestRecordHitAndTimeSinceLastHit(t *testing.T) {
+	tables := newExactTables()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+	table.info.IdleTimeoutBehavior = p4info.Table_NOTIFY_CONTROL
+	assert.NoError(t, table.ModifyTableEntry(idleEntry(1, 10*time.Second), true))
+	assert.NoError(t, table.ModifyTableEntry(idleEntry(2, 10*time.Second), true))
+
+	// Injected hits refresh the idle timer, as lookups do
+	clock.Advance(8 * time.Second)
+	assert.NoError(t, table.RecordHit(idleEntry(1, 0)))
+	assert.True(t, errors.IsNotFound(table.RecordHit(idleEntry(3, 0))))
+	clock.Advance(3 * time.Second)
+
+	elapsed := func(request *p4api.TableEntry) map[byte]int64 {
+		result := make(map[byte]int64)
+		assert.NoError(t, table.ReadTableEntries(request, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				entry := entity.GetTableEntry()
+				result[entry.Match[0].GetExact().Value[0]] = entry.GetTimeSinceLastHit().GetElapsedNs()
+			}
+			return nil
+		}))
+		return result
+	}
+
+	// The time since last hit is reported only when requested
+	assert.Equal(t, map[byte]int64{1: 0, 2: 0}, elapsed(&p4api.TableEntry{TableId: 1}))
+	request := &p4api.TableEntry{TableId: 1, TimeSinceLastHit: &p4api.TableEntry_IdleTimeout{}}
+	assert.Equal(t, map[byte]int64{1: int64(3 * time.Second), 2: int64(11 * time.Second)}, elapsed(request))
+	exact := idleEntry(1, 0)
+	exact.TimeSinceLastHit = &p4api.TableEntry_IdleTimeout{}
+	assert.Equal(t, map[byte]int64{1: int64(3 * time.Second)}, elapsed(exact))
+
+	// Only the entry which was not hit is reported as idle
+	expired := table.SweepIdleEntries()
+	assert.Len(t, expired, 1)
+	assert.Equal(t, []byte{2}, expired[0].Match[0].GetExact().Value)
+}
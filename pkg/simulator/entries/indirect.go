@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Validates that any action profile member or group referenced by the action exists in the action profile
+// implementing the table, that any one-shot action set is valid for that profile, and that tables implemented by
+// an action profile are not given direct actions
+func (t *Table) validateProfileAction(action *p4api.TableAction) error {
+	if action == nil {
+		return nil
+	}
+	if action.GetAction() != nil {
+		if t.info.ImplementationId != 0 {
+			return errors.NewInvalid("table %s is implemented by an action profile and cannot have direct actions", t.Name())
+		}
+		return nil
+	}
+	if set := action.GetActionProfileActionSet(); set != nil {
+		return t.validateActionSet(set)
+	}
+	memberID, groupID := action.GetActionProfileMemberId(), action.GetActionProfileGroupId()
+	if memberID == 0 && groupID == 0 {
+		return nil
+	}
+	if t.info.ImplementationId == 0 {
+		return errors.NewInvalid("table %s is not implemented by an action profile", t.Name())
+	}
+	if t.tables.profiles == nil {
+		return errors.NewInvalid("action profiles not available for table %s", t.Name())
+	}
+	if memberID != 0 {
+		if _, ok := t.tables.profiles.member(t.info.ImplementationId, memberID); !ok {
+			return errors.NewInvalid("action profile member %d not found", memberID)
+		}
+	}
+	if groupID != 0 {
+		if _, ok := t.tables.profiles.group(t.info.ImplementationId, groupID); !ok {
+			return errors.NewInvalid("action profile group %d not found", groupID)
+		}
+	}
+	return nil
+}
+
+// Validates the one-shot action set of an entry, i.e. that the table is implemented by an action selector, that
+// the set is not empty and does not exceed the maximum group size, and that each action is a valid action of the
+// table with a positive weight
+func (t *Table) validateActionSet(set *p4api.ActionProfileActionSet) error {
+	if t.info.ImplementationId == 0 {
+		return errors.NewInvalid("table %s is not implemented by an action profile", t.Name())
+	}
+	if t.tables.profiles == nil {
+		return errors.NewInvalid("action profiles not available for table %s", t.Name())
+	}
+	profile, ok := t.tables.profiles.profiles[t.info.ImplementationId]
+	if !ok {
+		return errors.NewInvalid("action profile %d not found", t.info.ImplementationId)
+	}
+	if !profile.info.WithSelector {
+		return errors.NewInvalid("action profile %s of table %s does not support one-shot action sets",
+			profile.info.Preamble.Name, t.Name())
+	}
+	if len(set.ActionProfileActions) == 0 {
+		return errors.NewInvalid("one-shot action set of table %s is empty", t.Name())
+	}
+	if profile.info.MaxGroupSize > 0 && len(set.ActionProfileActions) > int(profile.info.MaxGroupSize) {
+		return errors.NewInvalid("one-shot action set of table %s exceeds maximum group size %d",
+			t.Name(), profile.info.MaxGroupSize)
+	}
+	for _, a := range set.ActionProfileActions {
+		if a.Action == nil {
+			return errors.NewInvalid("one-shot action set of table %s has a member without action", t.Name())
+		}
+		if a.Weight < 1 {
+			return errors.NewInvalid("action %d of the one-shot action set of table %s has non-positive weight %d",
+				a.Action.ActionId, t.Name(), a.Weight)
+		}
+		if err := t.validateEntryAction(a.Action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolves the given table action into a direct action, following any action profile member or group reference;
+// one-shot action sets resolve to their first action. Returns nil if the action cannot be resolved.
+func (t *Table) resolveAction(action *p4api.TableAction) *p4api.Action {
+	if action == nil {
+		return nil
+	}
+	if a := action.GetAction(); a != nil {
+		return a
+	}
+	if set := action.GetActionProfileActionSet(); set != nil {
+		if len(set.ActionProfileActions) == 0 {
+			return nil
+		}
+		return set.ActionProfileActions[0].Action
+	}
+	if t.tables.profiles == nil {
+		return nil
+	}
+	profileID := t.info.ImplementationId
+	memberID := action.GetActionProfileMemberId()
+	if groupID := action.GetActionProfileGroupId(); groupID != 0 {
+		group, ok := t.tables.profiles.group(profileID, groupID)
+		if !ok || len(group.entry.Members) == 0 {
+			return nil
+		}
+		memberID = group.entry.Members[0].MemberId
+	}
+	if member, ok := t.tables.profiles.member(profileID, memberID); ok {
+		return member.entry.Action
+	}
+	return nil
+}
+
+// EntryCountByGroup returns the number of entries, including the default entry, referencing each action profile group
+func (t *Table) EntryCountByGroup() map[uint32]int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	counts := make(map[uint32]int)
+	count := func(row *Row) {
+		if groupID := row.entry.Action.GetActionProfileGroupId(); groupID != 0 {
+			counts[groupID]++
+		}
+	}
+	for _, row := range t.rows {
+		count(row)
+	}
+	if t.defaultRow != nil {
+		count(t.defaultRow)
+	}
+	return counts
+}
+
+// ReadTableEntriesResolved reads the table entries matching the specified request, with any action profile group
+// references replaced by the action set of the group members, along with their weights and watch ports; this
+// spares the reader a follow-up read of the action profile
+func (t *Table) ReadTableEntriesResolved(request *p4api.TableEntry, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	buffer := t.tables.newBuffer(sender)
+	if err := t.visitRows(request, func(row *Row) error {
+		return buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: t.resolveGroupEntry(t.formatEntry(row.entry))}})
+	}); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Returns a copy of the given entry with its action profile group reference replaced by the action set of the
+// group members; entries without a resolvable group reference are returned as they are
+func (t *Table) resolveGroupEntry(entry *p4api.TableEntry) *p4api.TableEntry {
+	groupID := entry.Action.GetActionProfileGroupId()
+	if groupID == 0 || t.tables.profiles == nil {
+		return entry
+	}
+	profileID := t.info.ImplementationId
+	group, ok := t.tables.profiles.group(profileID, groupID)
+	if !ok {
+		return entry
+	}
+	set := &p4api.ActionProfileActionSet{}
+	for _, m := range group.entry.Members {
+		member, ok := t.tables.profiles.member(profileID, m.MemberId)
+		if !ok {
+			continue
+		}
+		action := &p4api.ActionProfileAction{Action: member.entry.Action, Weight: m.Weight}
+		if watchPort := m.GetWatchPort(); watchPort != nil {
+			action.WatchKind = &p4api.ActionProfileAction_WatchPort{WatchPort: watchPort}
+		}
+		set.ActionProfileActions = append(set.ActionProfileActions, action)
+	}
+	resolved := proto.Clone(entry).(*p4api.TableEntry)
+	resolved.Action = &p4api.TableAction{Type: &p4api.TableAction_ActionProfileActionSet{ActionProfileActionSet: set}}
+	return resolved
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"math/rand"
+	"sync"
+)
+
+// DefaultInstallRateWindow is the number of most recent installs over which the install success rate is computed
+const DefaultInstallRateWindow = 100
+
+// Outcomes of inserts and modifies of table entries
+type installStats struct {
+	successes uint64
+	failures  map[codes.Code]uint64
+	recent    []bool
+	next      int
+	count     int
+}
+
+// InstallFault fails a configurable fraction of inserts and modifies of table entries
+type InstallFault struct {
+	lock     sync.Mutex
+	fraction float64
+	rand     *rand.Rand
+}
+
+// NewInstallFault creates a new install fault which fails the given fraction (0.0 - 1.0) of installs with an
+// UNAVAILABLE error; the seed allows the fault pattern to be reproduced
+func NewInstallFault(fraction float64, seed int64) *InstallFault {
+	return &InstallFault{fraction: fraction, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Returns true if the install falls within the faulty fraction
+func (f *InstallFault) hit() bool {
+	if f == nil {
+		return false
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.rand.Float64() < f.fraction
+}
+
+// SetInstallFault sets the fault to be injected into inserts and modifies of entries; nil disables injection
+func (t *Table) SetInstallFault(fault *InstallFault) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.installFault = fault
+}
+
+// SetInstallRateWindow sets the number of most recent installs over which the install success rate is computed
+func (t *Table) SetInstallRateWindow(window int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if window <= 0 {
+		return errors.NewInvalid("install rate window must be positive: %d", window)
+	}
+	t.installs.recent = make([]bool, window)
+	t.installs.next, t.installs.count = 0, 0
+	return nil
+}
+
+// Performs the given install, unless failed by the install fault, and records its outcome
+func (t *Table) install(op func() error) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var err error
+	if t.installFault.hit() {
+		err = errors.NewUnavailable("injected install failure in table %s", t.Name())
+	} else {
+		err = op()
+	}
+	t.installs.record(err)
+	return err
+}
+
+// Records the outcome of an install
+func (s *installStats) record(err error) {
+	if s.recent == nil {
+		s.recent = make([]bool, DefaultInstallRateWindow)
+	}
+	if err == nil {
+		s.successes++
+	} else {
+		if s.failures == nil {
+			s.failures = make(map[codes.Code]uint64)
+		}
+		s.failures[ErrorStatus(err).Code()]++
+	}
+	s.recent[s.next] = err == nil
+	s.next = (s.next + 1) % len(s.recent)
+	if s.count < len(s.recent) {
+		s.count++
+	}
+}
+
+// Reports the install outcomes into the given table stats
+func (s *installStats) report(stats *TableStats) {
+	stats.InstallSuccesses = s.successes
+	if len(s.failures) > 0 {
+		stats.InstallFailures = make(map[codes.Code]uint64, len(s.failures))
+		for code, n := range s.failures {
+			stats.InstallFailures[code] = n
+		}
+	}
+	if s.count > 0 {
+		successes := 0
+		for i := 0; i < s.count; i++ {
+			if s.recent[i] {
+				successes++
+			}
+		}
+		stats.InstallSuccessRate = float64(successes) / float64(s.count)
+	}
+}
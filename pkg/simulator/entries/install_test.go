@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"testing"
+)
+
+func TestInstallStats(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.Equal(t, float64(0), table.Stats().InstallSuccessRate)
+
+	// Mix of successful installs and failures of various kinds
+	for i := byte(0); i < 6; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(i, 0), true))
+	}
+	assert.True(t, errors.IsAlreadyExists(tables.ModifyTableEntry(exactEntry(1, 0), true)))
+	assert.True(t, errors.IsNotFound(tables.ModifyTableEntry(exactEntry(9, 0), false)))
+	assert.True(t, errors.IsNotFound(tables.ModifyTableEntry(exactEntry(9, 1), false)))
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 0), false))
+
+	stats := table.Stats()
+	assert.Equal(t, uint64(7), stats.InstallSuccesses)
+	assert.Equal(t, map[codes.Code]uint64{codes.AlreadyExists: 1, codes.NotFound: 2}, stats.InstallFailures)
+	assert.Equal(t, 0.7, stats.InstallSuccessRate)
+
+	// The success rate covers only the most recent installs
+	assert.NoError(t, table.SetInstallRateWindow(4))
+	assert.True(t, errors.IsInvalid(table.SetInstallRateWindow(0)))
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(6, 0), true))
+	assert.True(t, errors.IsAlreadyExists(tables.ModifyTableEntry(exactEntry(6, 0), true)))
+	assert.Equal(t, 0.5, table.Stats().InstallSuccessRate)
+	for i := byte(7); i < 11; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(i, 0), true))
+	}
+	assert.Equal(t, float64(1), table.Stats().InstallSuccessRate)
+
+	// Injected faults fail installs with UNAVAILABLE
+	table.SetInstallFault(NewInstallFault(0.5, 1))
+	failed := 0
+	for i := byte(20); i < 120; i++ {
+		if err := tables.ModifyTableEntry(exactEntry(i, 0), true); err != nil {
+			assert.True(t, errors.IsUnavailable(err))
+			failed++
+		}
+	}
+	assert.Greater(t, failed, 25)
+	assert.Less(t, failed, 75)
+	assert.Equal(t, 11+100-failed, table.Size())
+	stats = table.Stats()
+	assert.Equal(t, uint64(failed), stats.InstallFailures[codes.Unavailable])
+	assert.Equal(t, uint64(12+100-failed), stats.InstallSuccesses)
+
+	// Without the fault, all installs succeed again
+	table.SetInstallFault(nil)
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(200, 0), true))
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"hash"
+	"hash/fnv"
+)
+
+// KeyHash is an abstract constructor of the hash used to produce entry keys
+type KeyHash func() hash.Hash
+
+// KeyHashSHA1 produces entry keys using SHA-1; this is the default
+func KeyHashSHA1() hash.Hash {
+	return sha1.New()
+}
+
+// KeyHashFNV64 produces shorter entry keys using the 64-bit FNV-1a hash, at a higher risk of collisions
+func KeyHashFNV64() hash.Hash {
+	return fnv.New64a()
+}
+
+// SetKeyHash sets the hash used to produce the entry keys of all tables; the keys of any existing entries are
+// recomputed, unless they would collide, in which case the previous hash remains in place
+func (ts *Tables) SetKeyHash(keyHash KeyHash) error {
+	oldHash := ts.keyHash
+	ts.keyHash = keyHash
+	return ts.rekey(func() { ts.keyHash = oldHash })
+}
+
+// Number of keys sent by ReadKeys in each batch
+const keyBatchSize = 256
+
+// EntryKey returns the canonical key of the specified entry as a hex string, in the same form as streamed by ReadKeys
+func (t *Table) EntryKey(entry *p4api.TableEntry) (string, error) {
+	canonical := &p4api.TableEntry{Priority: entry.Priority, Match: append([]*p4api.FieldMatch{}, entry.Match...)}
+	sortFieldMatches(canonical.Match)
+	key, err := t.entryKey(canonical)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString([]byte(key)), nil
+}
+
+// ReadKeys streams the canonical keys of all table entries, excluding the default entry, to the given sender in
+// batches of hex strings; this allows reconciling table contents without reading the full entries
+func (t *Table) ReadKeys(sender func([]string) error) error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	batch := make([]string, 0, keyBatchSize)
+	for _, kr := range t.orderedKeyedRows() {
+		batch = append(batch, hex.EncodeToString([]byte(kr.key)))
+		if len(batch) == keyBatchSize {
+			if err := sender(batch); err != nil {
+				return err
+			}
+			batch = make([]string, 0, keyBatchSize)
+		}
+	}
+	if len(batch) > 0 {
+		return sender(batch)
+	}
+	return nil
+}
+
+// ReadShard reads the table entries, excluding the default entry, whose key hash falls in the specified shard
+// out of the given number of shards; reading all shards yields every entry exactly once, allowing several readers
+// to process disjoint subsets of a large table in parallel
+func (t *Table) ReadShard(shardIndex int, shardCount int, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if shardCount < 1 || shardIndex < 0 || shardIndex >= shardCount {
+		return errors.NewInvalid("invalid shard %d of %d", shardIndex, shardCount)
+	}
+	t.pollCounters()
+	buffer := t.tables.newBuffer(sender)
+	for _, kr := range t.orderedKeyedRows() {
+		if keyShard(kr.key, shardCount) != shardIndex {
+			continue
+		}
+		if err := buffer.sendEntity(t.getEntry(ReadTableEntry, kr.row)); err != nil {
+			return err
+		}
+	}
+	return buffer.flush()
+}
+
+// Returns the shard of the given entry key out of the given number of shards
+func keyShard(key string, shardCount int) int {
+	hf := fnv.New64a()
+	_, _ = hf.Write([]byte(key))
+	return int(hf.Sum64() % uint64(shardCount))
+}
+
+// Returns the row stored under the given key, provided its entry has the same field matches as the given entry;
+// it is assumed that the field matches of both are in canonical order
+func (t *Table) row(key string, entry *p4api.TableEntry) (*Row, bool) {
+	row, ok := t.rows[key]
+	if !ok {
+		return nil, false
+	}
+	if !sameMatches(row.entry.Match, entry.Match) {
+		t.collision(entry)
+		return nil, false
+	}
+	return row, true
+}
+
+// Records a collision of the key of the given entry with the key of another entry
+func (t *Table) collision(entry *p4api.TableEntry) {
+	t.keyCollisions++
+	log.Warnf("Table %s: key of entry %v collides with another entry; %d collisions so far", t.Name(), entry, t.keyCollisions)
+}
+
+// Returns true if the two canonically ordered sets of field matches are numerically equal
+func sameMatches(a []*p4api.FieldMatch, b []*p4api.FieldMatch) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].FieldId != b[i].FieldId || !fieldMatchesEqual(a[i], b[i], 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"crypto/sha1"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"hash"
+	"hash/fnv"
+	"testing"
+)
+
+// Hash which ignores all input, forcing all entry keys to collide
+type constantHash struct {
+	hash.Hash
+}
+
+func (h constantHash) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func newConstantHash() hash.Hash {
+	return constantHash{Hash: fnv.New32()}
+}
+
+func TestKeyHashFNV64(t *testing.T) {
+	tables := newExactTables()
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 2), true))
+	assert.NoError(t, tables.SetKeyHash(KeyHashFNV64))
+	table := tables.Table(1)
+	assert.Len(t, mustKey(t, table, exactEntry(1, 2)), 8)
+
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(3, 4), true))
+	lr, err := table.Lookup(FieldValues{1: {1}, 2: {2}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+	assert.Equal(t, TableStats{Entries: 2, InstallSuccesses: 2, InstallSuccessRate: 1}, table.Stats())
+}
+
+func TestKeyCollisions(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 2), true))
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(3, 4), true))
+
+	// Switching to a hash under which existing entries collide is refused
+	err := tables.SetKeyHash(newConstantHash)
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, uint64(1), table.Stats().KeyCollisions)
+	lr, err := table.Lookup(FieldValues{1: {3}, 2: {4}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(3, 4)))
+	assert.NoError(t, tables.SetKeyHash(newConstantHash))
+
+	// The strict compare detects true collisions on all paths
+	err = tables.ModifyTableEntry(exactEntry(5, 6), true)
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, uint64(2), table.Stats().KeyCollisions)
+
+	err = tables.ModifyTableEntry(exactEntry(5, 6), false)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, uint64(3), table.Stats().KeyCollisions)
+
+	lr, err = table.Lookup(FieldValues{1: {5}, 2: {6}})
+	assert.NoError(t, err)
+	assert.False(t, lr.Hit)
+	assert.Equal(t, uint64(4), table.Stats().KeyCollisions)
+
+	err = table.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(5, 6), Data: &p4api.CounterData{}})
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, uint64(5), table.Stats().KeyCollisions)
+
+	// Removing a colliding entry leaves the stored entry in place
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(5, 6)))
+	assert.Equal(t, TableStats{Entries: 1, KeyCollisions: 6, InstallSuccesses: 2,
+		InstallFailures: map[codes.Code]uint64{codes.NotFound: 1, codes.FailedPrecondition: 1}, InstallSuccessRate: 0.5}, table.Stats())
+
+	// The stored entry itself remains accessible
+	lr, err = table.Lookup(FieldValues{1: {1}, 2: {2}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+	assert.Equal(t, uint64(6), table.Stats().KeyCollisions)
+}
+
+func TestReadKeys(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+
+	expected := make(map[string]bool)
+	for i := 0; i < 600; i++ {
+		entry := exactEntry(byte(i), byte(i/256))
+		assert.NoError(t, table.ModifyTableEntry(entry, true))
+		key, err := table.EntryKey(entry)
+		assert.NoError(t, err)
+		expected[key] = true
+	}
+
+	read := func() (map[string]bool, int) {
+		keys := make(map[string]bool)
+		batches := 0
+		assert.NoError(t, table.ReadKeys(func(batch []string) error {
+			assert.LessOrEqual(t, len(batch), keyBatchSize)
+			for _, key := range batch {
+				keys[key] = true
+			}
+			batches++
+			return nil
+		}))
+		return keys, batches
+	}
+
+	// Keys of all entries but the default one are streamed in batches
+	keys, batches := read()
+	assert.Equal(t, expected, keys)
+	assert.Equal(t, 3, batches)
+
+	// Repeated reads stream the keys in the same order
+	ordered := func() []string {
+		keys := make([]string, 0)
+		assert.NoError(t, table.ReadKeys(func(batch []string) error {
+			keys = append(keys, batch...)
+			return nil
+		}))
+		return keys
+	}
+	assert.Equal(t, ordered(), ordered())
+
+	// Keys are canonical regardless of the field match order
+	reversed := exactEntry(5, 0)
+	reversed.Match[0], reversed.Match[1] = reversed.Match[1], reversed.Match[0]
+	key, err := table.EntryKey(reversed)
+	assert.NoError(t, err)
+	assert.True(t, keys[key])
+
+	// Removed entries are no longer reported
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(5, 0)))
+	keys, _ = read()
+	assert.Len(t, keys, 599)
+	assert.False(t, keys[key])
+
+	// Sender errors abort the stream
+	assert.Error(t, table.ReadKeys(func(batch []string) error { return errors.NewUnavailable("closed") }))
+}
+
+func TestKeyPriorityAndPrefixLength(t *testing.T) {
+	h := sha1.New()
+	writeHash(h, 0x12345678)
+	expected := sha1.Sum([]byte{0x12, 0x34, 0x56, 0x78})
+	assert.Equal(t, expected[:], h.Sum(nil))
+
+	// Entries differing only in prefix length have distinct keys and coexist
+	lpmTables := newLPMTables()
+	lpmTable := lpmTables.Table(2)
+	short := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	long := lpmEntry(1, []byte{10, 0, 0, 0}, 16)
+	assert.NotEqual(t, mustKey(t, lpmTable, short), mustKey(t, lpmTable, long))
+	assert.NoError(t, lpmTable.ModifyTableEntry(short, true))
+	assert.NoError(t, lpmTable.ModifyTableEntry(long, true))
+	assert.Equal(t, 2, lpmTable.Size())
+
+	// Entries differing only in priority have distinct keys and coexist
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 3, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+		},
+	}})
+	table := tables.Table(3)
+	acl := func(priority int32) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 3, Priority: priority, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: []byte{0x08, 0x00}, Mask: []byte{0xff, 0xff}}}},
+		}}
+	}
+	assert.NotEqual(t, mustKey(t, table, acl(10)), mustKey(t, table, acl(0x01000000+10)))
+	assert.NoError(t, table.ModifyTableEntry(acl(10), true))
+	assert.NoError(t, table.ModifyTableEntry(acl(0x01000000+10), true))
+	assert.NoError(t, table.ModifyTableEntry(acl(20), true))
+	assert.Equal(t, 3, table.Size())
+	assert.NoError(t, table.RemoveTableEntry(acl(10)))
+	assert.Equal(t, 2, table.Size())
+	assert.Equal(t, TableStats{Entries: 2, InstallSuccesses: 3, InstallSuccessRate: 1}, table.Stats())
+}
+
+func TestKeyFieldBoundaries(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "multi"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "a", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "b", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		},
+	}})
+	table := tables.Table(1)
+	entry := func(a []byte, b []byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: a}}},
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: b}}},
+		}}
+	}
+
+	// Entries whose values concatenate to the same bytes have distinct keys and coexist
+	first := entry([]byte{0x01, 0x02}, []byte{0x03})
+	second := entry([]byte{0x01}, []byte{0x02, 0x03})
+	assert.NotEqual(t, mustKey(t, table, first), mustKey(t, table, second))
+	assert.NoError(t, table.ModifyTableEntry(first, true))
+	assert.NoError(t, table.ModifyTableEntry(second, true))
+	assert.Equal(t, 2, table.Size())
+	assert.Equal(t, uint64(0), table.Stats().KeyCollisions)
+}
+
+func TestReadShard(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+	expected := make(map[string]bool)
+	for i := 0; i < 600; i++ {
+		entry := exactEntry(byte(i), byte(i/256))
+		assert.NoError(t, table.ModifyTableEntry(entry, true))
+		key, err := table.EntryKey(entry)
+		assert.NoError(t, err)
+		expected[key] = true
+	}
+
+	// The shards are disjoint and their union yields all entries but the default one
+	for _, shardCount := range []int{1, 3, 8} {
+		keys := make(map[string]bool)
+		for shard := 0; shard < shardCount; shard++ {
+			count := 0
+			assert.NoError(t, table.ReadShard(shard, shardCount, func(entities []*p4api.Entity) error {
+				for _, entity := range entities {
+					key, err := table.EntryKey(entity.GetTableEntry())
+					assert.NoError(t, err)
+					assert.False(t, keys[key], "entry read in more than one shard")
+					keys[key] = true
+					count++
+				}
+				return nil
+			}))
+			if shardCount > 1 {
+				assert.Less(t, count, 600)
+			}
+		}
+		assert.Equal(t, expected, keys)
+	}
+
+	// Repeated reads of a shard yield the entries in the same order
+	shardKeys := func() []string {
+		keys := make([]string, 0)
+		assert.NoError(t, table.ReadShard(1, 3, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				key, err := table.EntryKey(entity.GetTableEntry())
+				assert.NoError(t, err)
+				keys = append(keys, key)
+			}
+			return nil
+		}))
+		return keys
+	}
+	assert.Equal(t, shardKeys(), shardKeys())
+
+	sender := func([]*p4api.Entity) error { return nil }
+	assert.True(t, errors.IsInvalid(table.ReadShard(0, 0, sender)))
+	assert.True(t, errors.IsInvalid(table.ReadShard(3, 3, sender)))
+	assert.True(t, errors.IsInvalid(table.ReadShard(-1, 3, sender)))
+}
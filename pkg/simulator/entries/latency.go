@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import "time"
+
+// LookupLatency models the simulated cost of a table lookup, e.g. a constant cost for hash tables or
+// a prefix-length dependent cost for LPM tables
+type LookupLatency struct {
+	// Base is the latency incurred by every lookup, hit or miss
+	Base time.Duration
+	// PerPrefixBit is the additional latency incurred for each bit of the matched LPM prefix
+	PerPrefixBit time.Duration
+}
+
+// SetLookupLatency sets the lookup latency model of the table; nil means lookups incur no latency
+func (t *Table) SetLookupLatency(latency *LookupLatency) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.lookupLatency = latency
+}
+
+// Returns the simulated latency of a lookup which matched a prefix of the given length
+func (t *Table) simulatedLatency(prefixLen int32) time.Duration {
+	if t.lookupLatency == nil {
+		return 0
+	}
+	return t.lookupLatency.Base + time.Duration(prefixLen)*t.lookupLatency.PerPrefixBit
+}
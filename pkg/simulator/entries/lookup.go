@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"time"
+)
+
+// FieldValues maps match field IDs to the packet header values used for table lookups
+type FieldValues map[uint32][]byte
+
+// LookupResult represents the outcome of a table lookup
+type LookupResult struct {
+	// Entry is the matched entry or the default entry on a miss; nil if there is no default entry
+	Entry *p4api.TableEntry
+	// Action is the direct action resolved from the entry, including resolution of action profile references
+	Action *p4api.Action
+	// Hit indicates whether the lookup matched a (non-default) entry
+	Hit bool
+	// Latency is the simulated lookup latency according to the table lookup latency model
+	Latency time.Duration
+	// Dangling indicates that the matched entry references an entry absent from a dependent table, e.g. a next hop
+	// not yet installed, in which case a real target would drop the packet
+	Dangling bool
+}
+
+// Lookup looks up the entry matching the specified field values using the lookup appropriate for the
+// table match fields; tables requiring priorities are looked up by priority
+func (t *Table) Lookup(values FieldValues) (*LookupResult, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.RequiresPriority() {
+		return t.lookupTernary(values)
+	}
+	for _, field := range t.info.MatchFields {
+		if field.GetMatchType() == p4info.MatchField_LPM {
+			return t.lookupLPM(values)
+		}
+	}
+	return t.lookupExact(values)
+}
+
+// LookupExact looks up the entry matching the specified field values in a table with only exact match fields,
+// falling back to the default action on a miss
+func (t *Table) LookupExact(values FieldValues) (*LookupResult, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.lookupExact(values)
+}
+
+func (t *Table) lookupExact(values FieldValues) (*LookupResult, error) {
+	for _, field := range t.info.MatchFields {
+		if field.GetMatchType() != p4info.MatchField_EXACT {
+			return nil, errors.NewInvalid("field %s of table %s is not an exact match field", field.Name, t.Name())
+		}
+	}
+
+	matches := make([]*p4api.FieldMatch, 0, len(t.info.MatchFields))
+	for _, field := range t.info.MatchFields {
+		value, ok := values[field.Id]
+		if !ok {
+			result := t.lookupDefault()
+			result.Latency = t.simulatedLatency(0)
+			return result, nil
+		}
+		matches = append(matches, &p4api.FieldMatch{
+			FieldId:        field.Id,
+			FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: value}},
+		})
+	}
+
+	key, err := t.entryKey(&p4api.TableEntry{TableId: t.ID(), Match: matches})
+	if err != nil {
+		return nil, err
+	}
+	// Entries are keyed by canonical values, so header values padded to the field width find them as well
+	var result *LookupResult
+	if row, ok := t.row(key, &p4api.TableEntry{Match: matches}); ok {
+		result = t.lookupHit(row)
+	} else {
+		result = t.lookupDefault()
+	}
+	result.Latency = t.simulatedLatency(0)
+	return result, nil
+}
+
+// LookupLPM looks up the entry in the specified table with the longest prefix covering the value of its LPM field,
+// with any other fields matching the specified field values, falling back to the default action on a miss
+func (ts *Tables) LookupLPM(tableID uint32, values FieldValues) (*LookupResult, error) {
+	table, ok := ts.tables[tableID]
+	if !ok {
+		return nil, errors.NewNotFound("table %d not found", tableID)
+	}
+	return table.LookupLPM(values)
+}
+
+// LookupLPM looks up the entry with the longest prefix covering the value of the LPM field, with any other
+// fields matching the specified field values, falling back to the default action on a miss; of the entries with
+// equally long prefixes, the one with the lowest key wins
+func (t *Table) LookupLPM(values FieldValues) (*LookupResult, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.lookupLPM(values)
+}
+
+func (t *Table) lookupLPM(values FieldValues) (*LookupResult, error) {
+	var lpmField *p4info.MatchField
+	for _, field := range t.info.MatchFields {
+		if field.GetMatchType() != p4info.MatchField_LPM {
+			continue
+		}
+		if lpmField != nil {
+			return nil, errors.NewInvalid("table %s has more than one LPM match field", t.Name())
+		}
+		lpmField = field
+	}
+	if lpmField == nil {
+		return nil, errors.NewInvalid("table %s has no LPM match field", t.Name())
+	}
+
+	var best *Row
+	bestKey := ""
+	bestLen := int32(-1)
+	for key, row := range t.rows {
+		if !t.rowMatchesValues(row, values) {
+			continue
+		}
+		prefixLen := int32(0)
+		for _, m := range row.entry.Match {
+			if m.FieldId == lpmField.Id && m.GetLpm() != nil {
+				prefixLen = m.GetLpm().PrefixLen
+			}
+		}
+		if prefixLen > bestLen || (prefixLen == bestLen && key < bestKey) {
+			best, bestKey, bestLen = row, key, prefixLen
+		}
+	}
+	if best == nil {
+		result := t.lookupDefault()
+		result.Latency = t.simulatedLatency(0)
+		return result, nil
+	}
+	result := t.lookupHit(best)
+	result.Latency = t.simulatedLatency(bestLen)
+	return result, nil
+}
+
+// Returns the result of a lookup which hit the given row
+func (t *Table) lookupHit(row *Row) *LookupResult {
+	row.hit(t.tables.clock())
+	result := &LookupResult{Entry: row.entry, Action: t.resolveAction(row.entry.Action), Hit: true}
+	if len(t.dependencies) > 0 && !t.referencesResolved(row.entry) {
+		t.dependencyStats.TransientMisses++
+		result.Dangling = true
+	}
+	return result
+}
+
+// Returns the result of a lookup miss, using the programmed default action or the constant default action
+func (t *Table) lookupDefault() *LookupResult {
+	t.misses++
+	if t.defaultRow != nil {
+		return &LookupResult{Entry: t.defaultRow.entry, Action: t.resolveAction(t.defaultRow.entry.Action)}
+	}
+	if t.info.ConstDefaultActionId != 0 {
+		return &LookupResult{Action: &p4api.Action{ActionId: t.info.ConstDefaultActionId}}
+	}
+	return &LookupResult{}
+}
+
+// Returns true if all field matches of the specified row match the given field values; fields absent from
+// the row are wildcards
+func (t *Table) rowMatchesValues(row *Row, values FieldValues) bool {
+	for _, m := range row.entry.Match {
+		value, ok := values[m.FieldId]
+		if !ok || !fieldMatchesValue(m, value, t.fieldWidth(m.FieldId)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the width in bytes of the specified match field; 0 if the width is not known
+func (t *Table) fieldWidth(fieldID uint32) int {
+	for _, field := range t.info.MatchFields {
+		if field.Id == fieldID {
+			return int(field.Bitwidth+7) / 8
+		}
+	}
+	return 0
+}
+
+// Returns true if the given field match matches the specified value
+func fieldMatchesValue(m *p4api.FieldMatch, value []byte, width int) bool {
+	switch {
+	case m.GetExact() != nil:
+		return bytesEqual(m.GetExact().Value, value, width)
+	case m.GetOptional() != nil:
+		return bytesEqual(m.GetOptional().Value, value, width)
+	case m.GetLpm() != nil:
+		return prefixMatches(m.GetLpm().Value, m.GetLpm().PrefixLen, value, width)
+	case m.GetTernary() != nil:
+		return ternaryMatches(m.GetTernary().Value, m.GetTernary().Mask, value, width)
+	case m.GetRange() != nil:
+		n := maxLen(width, m.GetRange().Low, m.GetRange().High, value)
+		v := padTo(value, n)
+		return bytes.Compare(padTo(m.GetRange().Low, n), v) <= 0 && bytes.Compare(v, padTo(m.GetRange().High, n)) <= 0
+	}
+	return false
+}
+
+// Returns true if the two values are numerically equal
+func bytesEqual(a []byte, b []byte, width int) bool {
+	n := maxLen(width, a, b)
+	return bytes.Equal(padTo(a, n), padTo(b, n))
+}
+
+// Returns true if the first prefixLen bits of the prefix and the value are equal
+func prefixMatches(prefix []byte, prefixLen int32, value []byte, width int) bool {
+	n := maxLen(width, prefix, value)
+	p, v := padTo(prefix, n), padTo(value, n)
+	for i := 0; i < n && prefixLen > 0; i++ {
+		mask := byte(0xff)
+		if prefixLen < 8 {
+			mask = byte(0xff << (8 - prefixLen))
+		}
+		if p[i]&mask != v[i]&mask {
+			return false
+		}
+		prefixLen -= 8
+	}
+	return true
+}
+
+// Returns true if the value and the ternary value are equal in all bits covered by the mask
+func ternaryMatches(tv []byte, mask []byte, value []byte, width int) bool {
+	n := maxLen(width, tv, mask, value)
+	t, m, v := padTo(tv, n), padTo(mask, n), padTo(value, n)
+	for i := 0; i < n; i++ {
+		if t[i]&m[i] != v[i]&m[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the larger of the given width and the lengths of all the given values
+func maxLen(width int, values ...[]byte) int {
+	n := width
+	for _, v := range values {
+		if len(v) > n {
+			n = len(v)
+		}
+	}
+	return n
+}
+
+// Returns the value left-padded with zeros to the specified length
+func padTo(value []byte, n int) []byte {
+	if len(value) >= n {
+		return value
+	}
+	padded := make([]byte, n)
+	copy(padded[n-len(value):], value)
+	return padded
+}
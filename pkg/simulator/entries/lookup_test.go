@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Creates a table with an exact selector field and an LPM field, implemented by action profile 100
+func newLPMTables() *Tables {
+	return NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 2, Name: "routing"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "vrf", Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+		},
+		ImplementationId: 100,
+	}})
+}
+
+// Creates an LPM table entry for the given VRF and prefix
+func lpmEntry(vrf byte, prefix []byte, prefixLen int32) *p4api.TableEntry {
+	return &p4api.TableEntry{
+		TableId: 2,
+		Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{vrf}}}},
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Lpm{Lpm: &p4api.FieldMatch_LPM{Value: prefix, PrefixLen: prefixLen}}},
+		},
+	}
+}
+
+func TestIndirectDefaultAction(t *testing.T) {
+	tables := newLPMTables()
+	aps := newTestProfiles()
+	tables.SetActionProfiles(aps)
+	table := tables.Table(2)
+
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, 1, 7), true))
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, 2, 8), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 10, 1), true))
+
+	// References to non-existent group or member are rejected
+	groupRef := func(id uint32) *p4api.TableAction {
+		return &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: id}}
+	}
+	memberRef := func(id uint32) *p4api.TableAction {
+		return &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: id}}
+	}
+	assert.Error(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: groupRef(11)}, false))
+	assert.Error(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: memberRef(3)}, false))
+
+	// A group reference default is used on a miss
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: groupRef(10)}, false))
+	assert.NoError(t, table.ModifyTableEntry(lpmEntry(1, []byte{10, 0, 0, 0}, 8), true))
+
+	result, err := table.LookupLPM(FieldValues{1: {1}, 2: {10, 1, 2, 3}})
+	assert.NoError(t, err)
+	assert.True(t, result.Hit)
+
+	result, err = table.LookupLPM(FieldValues{1: {1}, 2: {11, 1, 2, 3}})
+	assert.NoError(t, err)
+	assert.False(t, result.Hit)
+	assert.True(t, result.Entry.IsDefaultAction)
+	assert.Equal(t, uint32(7), result.Action.ActionId)
+
+	// As is a member reference default
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: memberRef(2)}, false))
+	result, err = table.LookupLPM(FieldValues{1: {2}, 2: {10, 1, 2, 3}})
+	assert.NoError(t, err)
+	assert.False(t, result.Hit)
+	assert.Equal(t, uint32(8), result.Action.ActionId)
+
+	// Indirect defaults cannot be set on tables without action profile
+	exact := newExactTables()
+	exact.SetActionProfiles(aps)
+	assert.Error(t, exact.Table(1).ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: groupRef(10)}, false))
+}
+
+func TestLookupExact(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	entry := exactEntry(1, 2)
+	entry.Action = directAction(5, 0)
+	assert.NoError(t, tables.ModifyTableEntry(entry, true))
+
+	result, err := table.LookupExact(FieldValues{1: {1}, 2: {2}})
+	assert.NoError(t, err)
+	assert.True(t, result.Hit)
+	assert.Equal(t, uint32(5), result.Action.ActionId)
+
+	// Header values padded to the field width match the canonical entry values
+	result, err = table.LookupExact(FieldValues{1: {0x00, 0x01}, 2: {0x00, 0x02}})
+	assert.NoError(t, err)
+	assert.True(t, result.Hit)
+	result, err = table.Lookup(FieldValues{1: {0x00, 0x01}, 2: {2}})
+	assert.NoError(t, err)
+	assert.True(t, result.Hit)
+
+	result, err = table.LookupExact(FieldValues{1: {1}, 2: {3}})
+	assert.NoError(t, err)
+	assert.False(t, result.Hit)
+	assert.Nil(t, result.Entry)
+
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(6, 0)}, false))
+	result, err = table.LookupExact(FieldValues{1: {1}})
+	assert.NoError(t, err)
+	assert.False(t, result.Hit)
+	assert.Equal(t, uint32(6), result.Action.ActionId)
+
+	_, err = newLPMTables().Table(2).LookupExact(FieldValues{})
+	assert.Error(t, err)
+}
+
+func TestLookupTernary(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 5, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+			{Id: 2, Name: "l4_dport", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_RANGE}},
+		},
+	}})
+	table := tables.Table(5)
+	entry := func(priority int32, value []byte, mask []byte, action byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 5, Priority: priority, Action: directAction(1, action), Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: value, Mask: mask}}},
+		}}
+	}
+
+	// Overlapping entries: a catch-all, a /8 and a /16 match, inserted out of priority order
+	assert.NoError(t, table.ModifyTableEntry(entry(20, []byte{10, 1, 0, 0}, []byte{0xff, 0xff, 0, 0}, 2), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(1, []byte{0, 0, 0, 0}, []byte{0, 0, 0, 0}, 0), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(10, []byte{10, 0, 0, 0}, []byte{0xff, 0, 0, 0}, 1), true))
+	ranged := entry(30, []byte{10, 1, 2, 0}, []byte{0xff, 0xff, 0xff, 0}, 3)
+	ranged.Match = append(ranged.Match, &p4api.FieldMatch{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Range_{Range: &p4api.FieldMatch_Range{Low: []byte{0, 80}, High: []byte{0, 90}}}})
+	assert.NoError(t, table.ModifyTableEntry(ranged, true))
+
+	lookup := func(values FieldValues) byte {
+		lr, err := table.Lookup(values)
+		assert.NoError(t, err)
+		assert.True(t, lr.Hit)
+		return lr.Action.Params[0].Value[0]
+	}
+	assert.Equal(t, byte(2), lookup(FieldValues{1: {10, 1, 2, 3}, 2: {0, 22}}))
+	assert.Equal(t, byte(3), lookup(FieldValues{1: {10, 1, 2, 3}, 2: {0, 85}}))
+	assert.Equal(t, byte(1), lookup(FieldValues{1: {10, 2, 2, 3}}))
+	assert.Equal(t, byte(0), lookup(FieldValues{1: {11, 2, 2, 3}}))
+
+	// The index follows removals and insertions once built
+	assert.NoError(t, table.RemoveTableEntry(entry(20, []byte{10, 1, 0, 0}, []byte{0xff, 0xff, 0, 0}, 2)))
+	assert.Equal(t, byte(1), lookup(FieldValues{1: {10, 1, 2, 3}, 2: {0, 22}}))
+	assert.NoError(t, table.ModifyTableEntry(entry(40, []byte{10, 1, 0, 0}, []byte{0xff, 0xff, 0, 0}, 4), true))
+	assert.Equal(t, byte(4), lookup(FieldValues{1: {10, 1, 2, 3}, 2: {0, 85}}))
+	assert.NoError(t, table.RemoveTableEntry(entry(1, []byte{0, 0, 0, 0}, []byte{0, 0, 0, 0}, 0)))
+	lr, err := table.Lookup(FieldValues{1: {11, 2, 2, 3}})
+	assert.NoError(t, err)
+	assert.False(t, lr.Hit)
+	assert.Len(t, table.priorityIndex(), len(table.rows))
+
+	_, err = newExactTables().Table(1).LookupTernary(FieldValues{})
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestLookupLongestPrefix(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 2, Name: "routing"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "vrf", Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+		},
+	}, {
+		Preamble: &p4info.Preamble{Id: 3, Name: "fallback"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 2, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+		},
+	}, {
+		Preamble: &p4info.Preamble{Id: 4, Name: "dual"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "src", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+			{Id: 2, Name: "dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+		},
+	}})
+	tables.SetActions(testActions)
+	route := func(vrf byte, prefix []byte, prefixLen int32, port byte) *p4api.TableEntry {
+		entry := lpmEntry(vrf, prefix, prefixLen)
+		entry.Action = directAction(1, port)
+		return entry
+	}
+	port := func(result *LookupResult) byte {
+		return result.Action.Params[0].Value[0]
+	}
+
+	assert.NoError(t, tables.ModifyTableEntry(route(1, []byte{10, 0, 0, 0}, 8, 1), true))
+	assert.NoError(t, tables.ModifyTableEntry(route(1, []byte{10, 1, 0, 0}, 16, 2), true))
+	assert.NoError(t, tables.ModifyTableEntry(route(1, []byte{10, 1, 2, 0}, 24, 3), true))
+	assert.NoError(t, tables.ModifyTableEntry(route(2, []byte{10, 1, 2, 0}, 24, 4), true))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: directAction(1, 9)}, false))
+
+	// The longest covering prefix wins, scoped by the exact selector
+	for _, c := range []struct {
+		vrf  byte
+		dst  []byte
+		port byte
+		hit  bool
+	}{
+		{1, []byte{10, 1, 2, 3}, 3, true},
+		{1, []byte{10, 1, 3, 3}, 2, true},
+		{1, []byte{10, 2, 3, 4}, 1, true},
+		{2, []byte{10, 1, 2, 3}, 4, true},
+		{2, []byte{10, 1, 3, 3}, 9, false},
+		{1, []byte{11, 0, 0, 1}, 9, false},
+	} {
+		result, err := tables.LookupLPM(2, FieldValues{1: {c.vrf}, 2: c.dst})
+		assert.NoError(t, err)
+		assert.Equal(t, c.hit, result.Hit)
+		assert.Equal(t, c.port, port(result))
+	}
+
+	// Entries of other tables are not considered; a zero-length prefix covers everything
+	fallback := &p4api.TableEntry{TableId: 3, Action: directAction(1, 5), Match: []*p4api.FieldMatch{
+		{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Lpm{Lpm: &p4api.FieldMatch_LPM{Value: []byte{0, 0, 0, 0}, PrefixLen: 0}}},
+	}}
+	assert.NoError(t, tables.ModifyTableEntry(fallback, true))
+	result, err := tables.LookupLPM(3, FieldValues{2: {10, 1, 2, 3}})
+	assert.NoError(t, err)
+	assert.True(t, result.Hit)
+	assert.Equal(t, byte(5), port(result))
+	result, err = tables.LookupLPM(2, FieldValues{1: {2}, 2: {10, 1, 3, 3}})
+	assert.NoError(t, err)
+	assert.False(t, result.Hit)
+
+	_, err = tables.LookupLPM(5, FieldValues{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = tables.LookupLPM(4, FieldValues{})
+	assert.True(t, errors.IsInvalid(err))
+}
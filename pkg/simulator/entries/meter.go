@@ -8,23 +8,30 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
 	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"time"
 )
 
 // Meter represents all cells of a specific meter
 type Meter struct {
-	info  *p4info.Meter
-	cells []*p4api.MeterEntry
+	info       *p4info.Meter
+	cells      []*p4api.MeterEntry
+	buckets    []*tokenBuckets
+	outOfRange uint64
 }
 
 // Meters represents a set of P4 meters
 type Meters struct {
 	meters map[uint32]*Meter
+	clock  Clock
+
+	batching
 }
 
 // NewMeters creates a new meters store
 func NewMeters(info []*p4info.Meter) *Meters {
 	ms := &Meters{
 		meters: make(map[uint32]*Meter, len(info)),
+		clock:  time.Now,
 	}
 	for _, mi := range info {
 		ms.meters[mi.Preamble.Id] = ms.NewMeter(mi)
@@ -40,8 +47,9 @@ func (ms *Meters) NewMeter(info *p4info.Meter) *Meter {
 		cells[i] = &p4api.MeterEntry{MeterId: info.Preamble.Id, Index: &p4api.Index{Index: int64(i)}}
 	}
 	return &Meter{
-		info:  info,
-		cells: cells,
+		info:    info,
+		cells:   cells,
+		buckets: make([]*tokenBuckets, info.Size),
 	}
 }
 
@@ -69,6 +77,46 @@ func (ms *Meters) ModifyMeterEntry(entry *p4api.MeterEntry, insert bool) error {
 	}
 
 	meter.cells[entry.Index.Index] = entry
+	meter.resetBuckets(entry.Index.Index)
+	return nil
+}
+
+// ReadMeterEntries reads the meter cells matching the specified meter entry; meter ID of 0 reads all meters and
+// a nil index reads all cells of the meter
+func (ms *Meters) ReadMeterEntries(request *p4api.MeterEntry, sender BatchSender) error {
+	buffer := ms.newBuffer(sender)
+	if request.MeterId == 0 {
+		for _, meter := range ms.meters {
+			if err := meter.readCells(nil, buffer); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
+
+	meter, ok := ms.meters[request.MeterId]
+	if !ok {
+		return errors.NewNotFound("meter not found")
+	}
+	if err := meter.readCells(request.Index, buffer); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Sends either the cell at the specified index or all cells if the index is nil
+func (m *Meter) readCells(index *p4api.Index, buffer *entityBuffer) error {
+	if index != nil {
+		if index.Index < 0 || int(index.Index) >= len(m.cells) {
+			return errors.NewNotFound("meter index out of bounds")
+		}
+		return buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_MeterEntry{MeterEntry: m.cells[index.Index]}})
+	}
+	for _, cell := range m.cells {
+		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_MeterEntry{MeterEntry: cell}}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -87,7 +135,16 @@ func (m *Meter) Name() string {
 	return m.info.Preamble.Name
 }
 
-// Cell returns the specified cell of the meter
+// Cell returns the specified cell of the meter; nil if the index is outside of the meter size
 func (m *Meter) Cell(index int64) *p4api.MeterEntry {
+	if index < 0 || int(index) >= len(m.cells) {
+		return nil
+	}
 	return m.cells[index]
 }
+
+// OutOfRangeClassifications returns the number of packets which could not be metered due to an index outside of
+// the meter size
+func (m *Meter) OutOfRangeClassifications() uint64 {
+	return m.outOfRange
+}
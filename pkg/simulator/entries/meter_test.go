@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Reads the meter cells matching the request, returning their configs keyed by meter ID and index
+func readMeterConfigs(t *testing.T, meters *Meters, request *p4api.MeterEntry) map[uint32]map[int64]*p4api.MeterConfig {
+	configs := make(map[uint32]map[int64]*p4api.MeterConfig)
+	assert.NoError(t, meters.ReadMeterEntries(request, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			cell := entity.GetMeterEntry()
+			if configs[cell.MeterId] == nil {
+				configs[cell.MeterId] = make(map[int64]*p4api.MeterConfig)
+			}
+			configs[cell.MeterId][cell.Index.Index] = cell.Config
+		}
+		return nil
+	}))
+	return configs
+}
+
+func TestIndirectMeters(t *testing.T) {
+	meters := NewMeters([]*p4info.Meter{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "qos"}, Spec: &p4info.MeterSpec{Unit: p4info.MeterSpec_BYTES}, Size: 4},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "policer"}, Spec: &p4info.MeterSpec{Unit: p4info.MeterSpec_PACKETS}, Size: 200},
+	})
+	config := &p4api.MeterConfig{Cir: 1000, Cburst: 100, Pir: 2000, Pburst: 200}
+
+	// Meters can only be modified, within their declared size
+	assert.True(t, errors.IsInvalid(meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 1, Index: &p4api.Index{Index: 0}, Config: config}, true)))
+	assert.True(t, errors.IsNotFound(meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 3, Index: &p4api.Index{Index: 0}, Config: config}, false)))
+	assert.True(t, errors.IsNotFound(meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 1, Index: &p4api.Index{Index: 4}, Config: config}, false)))
+	assert.True(t, errors.IsNotFound(meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 1, Config: config}, false)))
+	assert.NoError(t, meters.ModifyMeterEntry(&p4api.MeterEntry{MeterId: 1, Index: &p4api.Index{Index: 2}, Config: config}, false))
+
+	// Reads of a single cell, of all cells of a meter and of all meters
+	configs := readMeterConfigs(t, meters, &p4api.MeterEntry{MeterId: 1, Index: &p4api.Index{Index: 2}})
+	assert.Len(t, configs[1], 1)
+	assert.Equal(t, int64(1000), configs[1][2].GetCir())
+	configs = readMeterConfigs(t, meters, &p4api.MeterEntry{MeterId: 1})
+	assert.Len(t, configs[1], 4)
+	assert.Nil(t, configs[1][0])
+	configs = readMeterConfigs(t, meters, &p4api.MeterEntry{})
+	assert.Len(t, configs, 2)
+	assert.Len(t, configs[2], 200)
+
+	err := meters.ReadMeterEntries(&p4api.MeterEntry{MeterId: 1, Index: &p4api.Index{Index: 4}}, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(err))
+	err = meters.ReadMeterEntries(&p4api.MeterEntry{MeterId: 3}, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestMeterClassifyOutOfRange(t *testing.T) {
+	meters := NewMeters([]*p4info.Meter{{Preamble: &p4info.Preamble{Id: 1, Name: "m1"}, Size: 2}})
+	meter := meters.Meters()[0]
+
+	color, err := meters.Classify(1, 1, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, Green, color)
+	for _, index := range []int64{-1, 2, 1 << 40} {
+		color, err = meters.Classify(1, index, 100)
+		assert.True(t, errors.IsNotFound(err))
+		assert.Equal(t, Red, color)
+		assert.Nil(t, meter.Cell(index))
+	}
+	assert.Equal(t, uint64(3), meter.OutOfRangeClassifications())
+	assert.NotNil(t, meter.Cell(1))
+}
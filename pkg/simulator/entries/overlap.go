@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// SetOverlapCheck enables or disables the rejection of inserts of entries which overlap an existing entry of the
+// same priority, i.e. which match some of the same packets, leaving the lookup result ambiguous; only tables
+// requiring priorities are checked. The check compares the entry with all entries of the table and is therefore
+// disabled by default.
+func (t *Table) SetOverlapCheck(enabled bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.overlapCheck = enabled
+}
+
+// Validates that the entry about to be inserted does not overlap any existing entry of the same priority, if the
+// overlap check is enabled
+func (t *Table) validateNoOverlap(entry *p4api.TableEntry) error {
+	if !t.overlapCheck || !t.RequiresPriority() {
+		return nil
+	}
+	for _, row := range t.orderedRows() {
+		if row.entry.Priority == entry.Priority && t.entriesOverlap(row.entry, entry) {
+			return errors.NewInvalid("entry overlaps entry %v of table %s with the same priority: %v",
+				row.entry, t.Name(), entry)
+		}
+	}
+	return nil
+}
+
+// Returns true if the two entries match at least one common set of field values; fields absent from an entry are
+// wildcards, which overlap any match of the field
+func (t *Table) entriesOverlap(a *p4api.TableEntry, b *p4api.TableEntry) bool {
+	for _, ma := range a.Match {
+		for _, mb := range b.Match {
+			if ma.FieldId == mb.FieldId && !fieldMatchesOverlap(ma, mb, t.fieldWidth(ma.FieldId)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns true if the two matches of the same field match at least one common value
+func fieldMatchesOverlap(a *p4api.FieldMatch, b *p4api.FieldMatch, width int) bool {
+	switch {
+	case a.GetExact() != nil && b.GetExact() != nil:
+		return bytesEqual(a.GetExact().Value, b.GetExact().Value, width)
+	case a.GetOptional() != nil && b.GetOptional() != nil:
+		return bytesEqual(a.GetOptional().Value, b.GetOptional().Value, width)
+	case a.GetLpm() != nil && b.GetLpm() != nil:
+		// One prefix covers the other if they agree on the bits of the shorter prefix
+		prefixLen := a.GetLpm().PrefixLen
+		if b.GetLpm().PrefixLen < prefixLen {
+			prefixLen = b.GetLpm().PrefixLen
+		}
+		return prefixMatches(a.GetLpm().Value, prefixLen, b.GetLpm().Value, width)
+	case a.GetTernary() != nil && b.GetTernary() != nil:
+		// Two ternaries overlap if their values agree on the bits covered by both masks
+		n := maxLen(width, a.GetTernary().Value, a.GetTernary().Mask, b.GetTernary().Value, b.GetTernary().Mask)
+		av, am := padTo(a.GetTernary().Value, n), padTo(a.GetTernary().Mask, n)
+		bv, bm := padTo(b.GetTernary().Value, n), padTo(b.GetTernary().Mask, n)
+		for i := 0; i < n; i++ {
+			if (av[i]^bv[i])&am[i]&bm[i] != 0 {
+				return false
+			}
+		}
+		return true
+	case a.GetRange() != nil && b.GetRange() != nil:
+		n := maxLen(width, a.GetRange().Low, a.GetRange().High, b.GetRange().Low, b.GetRange().High)
+		return bytes.Compare(padTo(a.GetRange().Low, n), padTo(b.GetRange().High, n)) <= 0 &&
+			bytes.Compare(padTo(b.GetRange().Low, n), padTo(a.GetRange().High, n)) <= 0
+	}
+	// Matches of differing types do not comply with the same schema; assume they may overlap
+	return true
+}
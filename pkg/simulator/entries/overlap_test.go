@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOverlapCheck(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+			{Id: 2, Name: "l4_port", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_RANGE}},
+		},
+	}})
+	table := tables.Table(1)
+	entry := func(priority int32, value []byte, mask []byte, ports ...byte) *p4api.TableEntry {
+		entry := &p4api.TableEntry{TableId: 1, Priority: priority, Match: []*p4api.FieldMatch{{FieldId: 1,
+			FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: value, Mask: mask}}}}}
+		if len(ports) == 2 {
+			entry.Match = append(entry.Match, &p4api.FieldMatch{FieldId: 2,
+				FieldMatchType: &p4api.FieldMatch_Range_{Range: &p4api.FieldMatch_Range{Low: []byte{ports[0]}, High: []byte{ports[1]}}}})
+		}
+		return entry
+	}
+
+	// By default, overlapping entries of the same priority are accepted
+	assert.NoError(t, table.ModifyTableEntry(entry(10, []byte{0x08, 0x00}, []byte{0xff, 0xff}), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(10, []byte{0x08, 0x00}, []byte{0xff, 0x00}), true))
+	assert.NoError(t, table.RemoveTableEntry(entry(10, []byte{0x08, 0x00}, []byte{0xff, 0x00})))
+
+	// When checking, the second of two overlapping entries of the same priority is refused
+	table.SetOverlapCheck(true)
+	err := table.ModifyTableEntry(entry(10, []byte{0x08, 0x00}, []byte{0xff, 0x00}), true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "overlaps")
+	assert.Equal(t, 1, table.Size())
+
+	// Overlapping entries of distinct priorities and disjoint entries of the same priority are accepted
+	assert.NoError(t, table.ModifyTableEntry(entry(20, []byte{0x08, 0x00}, []byte{0xff, 0x00}), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(10, []byte{0x86, 0xdd}, []byte{0xff, 0xff}), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(30, []byte{0x00, 0x00}, []byte{0x00, 0x00}, 10, 20), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(30, []byte{0x00, 0x00}, []byte{0x00, 0x00}, 21, 30), true))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(30, []byte{0x08, 0x06}, []byte{0xff, 0xff}, 20, 21), true)))
+
+	// Omitted fields are wildcards, overlapping any match of the field
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(30, []byte{0x08, 0x06}, []byte{0xff, 0xff}), true)))
+	assert.Equal(t, 5, table.Size())
+
+	// Modifies of existing entries are not checked
+	assert.NoError(t, table.ModifyTableEntry(entry(10, []byte{0x08, 0x00}, []byte{0xff, 0xff}), false))
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// ReadPage reads a page of at most pageSize table entries, excluding the default entry, following the position
+// given by the cursor; an empty cursor starts the scan from the beginning. Returns the cursor of the next page, or
+// an empty cursor once the scan is complete.
+//
+// Entries are paged in the order of their keys and the cursor records the key of the last entry returned, so the
+// scan remains consistent under concurrent modification of the table: entries present for the whole scan are
+// returned exactly once, while entries inserted or removed during the scan may or may not be returned. Modifies
+// do not change the entry keys, so modified entries are returned exactly once, either before or after the modify.
+// Recomputing the entry keys, e.g. by changing the key salt, invalidates any outstanding cursors.
+func (t *Table) ReadPage(cursor string, pageSize int, sender BatchSender) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if pageSize < 1 {
+		return "", errors.NewInvalid("invalid page size %d", pageSize)
+	}
+	after, err := t.decodeCursor(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	t.pollCounters()
+	keys := make([]string, 0)
+	for key := range t.rows {
+		if cursor == "" || key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	buffer := t.tables.newBuffer(sender)
+	for i := 0; i < len(keys) && i < pageSize; i++ {
+		if err := buffer.sendEntity(t.getEntry(ReadTableEntry, t.rows[keys[i]])); err != nil {
+			return "", err
+		}
+	}
+	if err := buffer.flush(); err != nil {
+		return "", err
+	}
+	if len(keys) <= pageSize {
+		return "", nil
+	}
+	return fmt.Sprintf("%d:%s", t.keyGeneration, hex.EncodeToString([]byte(keys[pageSize-1]))), nil
+}
+
+// Decodes the cursor into the key of the last entry of the previous page
+func (t *Table) decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	parts := strings.SplitN(cursor, ":", 2)
+	if len(parts) != 2 {
+		return "", errors.NewInvalid("invalid cursor %s", cursor)
+	}
+	if parts[0] != fmt.Sprintf("%d", t.keyGeneration) {
+		return "", errors.NewInvalid("cursor %s has been invalidated by recomputing the entry keys", cursor)
+	}
+	key, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.NewInvalid("invalid cursor %s", cursor)
+	}
+	return string(key), nil
+}
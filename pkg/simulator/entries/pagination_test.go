@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadPage(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(0); i < 10; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, i), true))
+	}
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, Action: directAction(1, 1), IsDefaultAction: true}, false))
+
+	seen := make(map[byte]int)
+	readPage := func(cursor string) string {
+		next, err := table.ReadPage(cursor, 3, func(entities []*p4api.Entity) error {
+			assert.LessOrEqual(t, len(entities), 3)
+			for _, entity := range entities {
+				assert.False(t, entity.GetTableEntry().IsDefaultAction)
+				seen[entity.GetTableEntry().Match[0].GetExact().Value[0]]++
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		return next
+	}
+
+	// Mutate the table between pages; entries present for the whole scan must be returned exactly once
+	cursor := readPage("")
+	assert.NotEmpty(t, cursor)
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(9, 9)))
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(20, 20), true))
+	pages := 1
+	for cursor != "" {
+		cursor = readPage(cursor)
+		pages++
+	}
+	assert.GreaterOrEqual(t, pages, 4)
+	for i := byte(0); i < 9; i++ {
+		assert.Equal(t, 1, seen[i])
+	}
+	for _, count := range seen {
+		assert.Equal(t, 1, count)
+	}
+
+	_, err := table.ReadPage("", 0, func(entities []*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsInvalid(err))
+	_, err = table.ReadPage("bogus", 3, func(entities []*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsInvalid(err))
+
+	// Re-keying the entries invalidates outstanding cursors
+	cursor = readPage("")
+	assert.NoError(t, tables.SetKeySalt([]byte("salt")))
+	_, err = table.ReadPage(cursor, 3, func(entities []*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsInvalid(err))
+}
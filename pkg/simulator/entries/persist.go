@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Marshal serializes the entries of all tables, including the default entries, along with their direct counter data,
+// meter configs and meter counter data, as a binary read response; tables are ordered by ID and entries as by Entries
+func (ts *Tables) Marshal() ([]byte, error) {
+	return proto.Marshal(&p4api.ReadResponse{Entities: ts.ExportEntities()})
+}
+
+// ExportEntities returns the entries of all tables as entities carrying the state of their direct resources, in the
+// order in which they are marshalled
+func (ts *Tables) ExportEntities() []*p4api.Entity {
+	entities := make([]*p4api.Entity, 0)
+	for _, table := range ts.orderedTables() {
+		entities = append(entities, table.exportEntities()...)
+	}
+	return entities
+}
+
+// Returns the table entries as entities carrying the state of their direct resources
+func (t *Table) exportEntities() []*p4api.Entity {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pollCounters()
+	rows := t.orderedRows()
+	if t.defaultRow != nil {
+		rows = append(rows, t.defaultRow)
+	}
+	entities := make([]*p4api.Entity, 0, len(rows))
+	for _, row := range rows {
+		entry := t.exportEntry(row)
+		if t.directMeter != nil {
+			entry.MeterCounterData = row.meterData
+		}
+		entities = append(entities, &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: entry}})
+	}
+	return entities
+}
+
+// Unmarshal replaces the entries of all tables with the entries serialized by Marshal; the tables must have the
+// schema of the marshalled tables. If any entry cannot be installed, the tables are left unchanged.
+func (ts *Tables) Unmarshal(data []byte) error {
+	response := &p4api.ReadResponse{}
+	if err := proto.Unmarshal(data, response); err != nil {
+		return errors.NewInvalid("invalid table entries: %v", err)
+	}
+	return ts.ImportEntities(response.Entities)
+}
+
+// ImportEntities replaces the entries of all tables with the given table entries, as returned by ExportEntities; if
+// any entry cannot be installed, the tables are left unchanged
+func (ts *Tables) ImportEntities(entities []*p4api.Entity) error {
+	for _, entity := range entities {
+		entry := entity.GetTableEntry()
+		if entry == nil {
+			return errors.NewInvalid("entity is not a table entry: %v", entity)
+		}
+		if _, ok := ts.tables[entry.TableId]; !ok {
+			return errors.NewNotFound("table %d not found", entry.TableId)
+		}
+	}
+
+	snapshot := ts.Snapshot()
+	for _, table := range ts.tables {
+		table.restore(&tableSnapshot{rows: make(map[string]*Row)})
+	}
+	for _, entity := range entities {
+		entry := entity.GetTableEntry()
+		table := ts.tables[entry.TableId]
+		table.lock.Lock()
+		err := table.modifyTableEntry(entry, !entry.IsDefaultAction)
+		table.lock.Unlock()
+		if err != nil {
+			_ = ts.Restore(snapshot)
+			return err
+		}
+	}
+	return nil
+}
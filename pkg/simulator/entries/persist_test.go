@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestMarshalTables(t *testing.T) {
+	tables := newExportedTables(t)
+	assert.NoError(t, tables.ModifyDirectMeterEntry(&p4api.DirectMeterEntry{
+		TableEntry: exactEntry(1, 2), Config: &p4api.MeterConfig{Cir: 100, Cburst: 10, Pir: 200, Pburst: 20}}, false))
+	data, err := tables.Marshal()
+	assert.NoError(t, err)
+
+	// A fresh store with the same schema reproduces the entries and their direct resources
+	restored := newExactTables()
+	assert.NoError(t, restored.ImportEntities([]*p4api.Entity{{Entity: &p4api.Entity_TableEntry{TableEntry: exactEntry(7, 7)}}}))
+	assert.NoError(t, restored.Unmarshal(data))
+	assert.Equal(t, 4, restored.Table(1).Size())
+	original, copied := tables.ExportEntities(), restored.ExportEntities()
+	assert.Len(t, copied, len(original))
+	for i := range original {
+		assert.True(t, proto.Equal(original[i], copied[i]), "entity %d differs: %v", i, copied[i])
+	}
+	again, err := restored.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	// Entries of unknown tables or not complying with the schema leave the tables unchanged
+	assert.Error(t, restored.Unmarshal([]byte{0xff}))
+	assert.True(t, errors.IsNotFound(restored.ImportEntities([]*p4api.Entity{{Entity: &p4api.Entity_TableEntry{TableEntry: &p4api.TableEntry{TableId: 9}}}})))
+	duplicate := &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: exactEntry(7, 7)}}
+	assert.Error(t, restored.ImportEntities([]*p4api.Entity{duplicate, duplicate}))
+	assert.Equal(t, 4, restored.Table(1).Size())
+	assert.True(t, proto.Equal(original[0], restored.ExportEntities()[0]))
+}
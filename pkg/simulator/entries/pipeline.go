@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"strings"
+	"time"
+)
+
+// Metadata represents named packet header and metadata values carried between pipeline stages
+type Metadata map[string][]byte
+
+// PipelineStage describes a single stage of a pipeline: the table, the metadata supplying its lookup key
+// and the metadata written by the parameters of its actions
+type PipelineStage struct {
+	TableID uint32
+	// Keys maps the table match field IDs to the names of metadata supplying their lookup values
+	Keys map[uint32]string
+	// Writes maps the action parameter names to the names of metadata they are written to
+	Writes map[string]string
+}
+
+// Pipeline represents a declarative order of table stages through which packets are processed
+type Pipeline struct {
+	tables *Tables
+	stages []*PipelineStage
+}
+
+// StageResult represents the outcome of a single pipeline stage
+type StageResult struct {
+	TableID uint32
+	Result  *LookupResult
+	// Metered indicates whether the packet was metered by the direct meter of the matched entry
+	Metered bool
+	// Color is the color with which the packet was marked if it was metered
+	Color Color
+}
+
+// PipelineResult represents the outcome of a pipeline walk
+type PipelineResult struct {
+	// Metadata contains the metadata values after all stages have been applied
+	Metadata Metadata
+	// Stages contains the lookup result of each stage, in pipeline order
+	Stages []*StageResult
+	// TransitTime is the simulated transit time of the packet, accumulated from the stage lookup latencies
+	TransitTime time.Duration
+	// DropReason describes why the packet was dropped, i.e. by a drop action or by an entry referencing an entry
+	// absent from a dependent table; empty if the packet was not dropped
+	DropReason string
+}
+
+// NewPipeline creates a new pipeline for the specified tables using the given stages, in order
+func NewPipeline(tables *Tables, stages []*PipelineStage) (*Pipeline, error) {
+	for _, stage := range stages {
+		if tables.Table(stage.TableID) == nil {
+			return nil, errors.NewNotFound("table %d not found", stage.TableID)
+		}
+	}
+	return &Pipeline{tables: tables, stages: stages}, nil
+}
+
+// Walk processes the specified metadata through all the pipeline stages, looking up each table using the
+// current metadata and applying the metadata writes of the matched actions before proceeding to the next stage
+func (p *Pipeline) Walk(metadata Metadata) (*PipelineResult, error) {
+	return p.WalkFlow(metadata, nil)
+}
+
+// WalkFlow processes the specified metadata through all the pipeline stages, like Walk, except that entries
+// referencing action profile groups, or having one-shot action sets, resolve to the group member or set action
+// selected for the given flow key, rather than to the first one; a nil flow key resolves to the first one
+func (p *Pipeline) WalkFlow(metadata Metadata, flow []byte) (*PipelineResult, error) {
+	result := &PipelineResult{Metadata: make(Metadata, len(metadata)), Stages: make([]*StageResult, 0, len(p.stages))}
+	for k, v := range metadata {
+		result.Metadata[k] = v
+	}
+	return p.walkStages(p.stages, result, flow)
+}
+
+// Processes the result metadata through the given stages, accumulating the stage results
+func (p *Pipeline) walkStages(stages []*PipelineStage, result *PipelineResult, flow []byte) (*PipelineResult, error) {
+	for _, stage := range stages {
+		table := p.tables.Table(stage.TableID)
+		values := make(FieldValues, len(stage.Keys))
+		for fieldID, name := range stage.Keys {
+			if value, ok := result.Metadata[name]; ok {
+				values[fieldID] = value
+			}
+		}
+
+		lr, err := table.Lookup(values)
+		if err != nil {
+			return nil, err
+		}
+		if flow != nil {
+			table.selectFlowAction(lr, flow)
+		}
+		result.Stages = append(result.Stages, &StageResult{TableID: stage.TableID, Result: lr})
+		result.TransitTime += lr.Latency
+		if result.DropReason == "" {
+			result.DropReason = p.dropReason(table, lr)
+		}
+		p.applyWrites(stage, lr, result.Metadata)
+	}
+	return result, nil
+}
+
+// CountPackets increments the direct counters of the entries hit during the given walk by the given number of
+// packets and bytes, as if by the datapath; stages which missed or whose tables have no direct counter are skipped
+func (p *Pipeline) CountPackets(result *PipelineResult, packets int64, bytes int64) error {
+	for _, stage := range result.Stages {
+		table := p.tables.Table(stage.TableID)
+		if !stage.Result.Hit || table == nil || table.directCounter == nil {
+			continue
+		}
+		if err := table.IncrementDirectCounter(stage.Result.Entry, packets, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MeterPackets meters a packet of the given size, in bytes, against the direct meters of the entries hit during
+// the given walk, recording the packet colors in the stage results; stages which missed or whose tables have no
+// direct meter are skipped
+func (p *Pipeline) MeterPackets(result *PipelineResult, bytes int64) error {
+	for _, stage := range result.Stages {
+		table := p.tables.Table(stage.TableID)
+		if !stage.Result.Hit || table == nil || table.directMeter == nil {
+			continue
+		}
+		color, err := table.MeterDirect(stage.Result.Entry, bytes)
+		if err != nil {
+			return err
+		}
+		stage.Metered, stage.Color = true, color
+	}
+	return nil
+}
+
+// EgressResolution represents the chain of stages through which an entry resolves to its egress
+type EgressResolution struct {
+	// Chain contains the result of each stage, starting with the stage of the resolved entry, in pipeline order
+	Chain []*StageResult
+	// Egress is the value of the egress metadata at the end of the chain
+	Egress []byte
+}
+
+// ResolveEgress follows the chain of the specified entry through the subsequent pipeline stages, e.g. from a routing
+// entry via its next-hop ID to the next-hop group, its member and finally the egress port, and returns the value
+// of the named egress metadata at the end of the chain
+func (p *Pipeline) ResolveEgress(entry *p4api.TableEntry, egress string) (*EgressResolution, error) {
+	for i, stage := range p.stages {
+		if stage.TableID != entry.TableId {
+			continue
+		}
+		lr := &LookupResult{Entry: entry, Action: p.tables.Table(stage.TableID).resolveAction(entry.Action), Hit: true}
+		result := &PipelineResult{Metadata: make(Metadata), Stages: []*StageResult{{TableID: stage.TableID, Result: lr}}}
+		p.applyWrites(stage, lr, result.Metadata)
+		if _, err := p.walkStages(p.stages[i+1:], result, nil); err != nil {
+			return nil, err
+		}
+		value, ok := result.Metadata[egress]
+		if !ok {
+			return nil, errors.NewNotFound("entry does not resolve to %s", egress)
+		}
+		return &EgressResolution{Chain: result.Stages, Egress: value}, nil
+	}
+	return nil, errors.NewNotFound("table %d is not a pipeline stage", entry.TableId)
+}
+
+// Resolves the action of the lookup result whose entry references an action profile group, or has a one-shot
+// action set, to the action of the group member, or set action, selected for the given flow key
+func (t *Table) selectFlowAction(lr *LookupResult, flow []byte) {
+	if set := lr.Entry.GetAction().GetActionProfileActionSet(); set != nil {
+		weights := make([]int32, 0, len(set.ActionProfileActions))
+		for _, a := range set.ActionProfileActions {
+			weights = append(weights, a.Weight)
+		}
+		var selector *memberSelector
+		if t.tables.profiles != nil {
+			selector = t.tables.profiles.selector
+		}
+		if i, ok := selectWeighted(selector, weights, flow); ok {
+			lr.Action = set.ActionProfileActions[i].Action
+		}
+		return
+	}
+	groupID := lr.Entry.GetAction().GetActionProfileGroupId()
+	if groupID == 0 || t.tables.profiles == nil {
+		return
+	}
+	profileID := t.info.ImplementationId
+	group, ok := t.tables.profiles.group(profileID, groupID)
+	if !ok {
+		return
+	}
+	if memberID, ok := group.SelectMember(flow); ok {
+		if member, ok := t.tables.profiles.member(profileID, memberID); ok {
+			lr.Action = member.entry.Action
+		}
+	}
+}
+
+// Returns the reason for which the packet is dropped by the specified lookup result of the given table, if any;
+// actions whose names end with "drop", e.g. drop or mark_to_drop, are considered to drop the packet
+func (p *Pipeline) dropReason(table *Table, lr *LookupResult) string {
+	if lr.Dangling {
+		return fmt.Sprintf("entry of table %s references a missing entry", table.Name())
+	}
+	if lr.Action == nil {
+		return ""
+	}
+	if info, ok := p.tables.actions[lr.Action.ActionId]; ok && strings.HasSuffix(info.Preamble.Name, "drop") {
+		return fmt.Sprintf("dropped by action %s of table %s", info.Preamble.Name, table.Name())
+	}
+	return ""
+}
+
+// Applies the metadata writes of the action resolved by the specified lookup result
+func (p *Pipeline) applyWrites(stage *PipelineStage, lr *LookupResult, metadata Metadata) {
+	if lr.Action == nil {
+		return
+	}
+	info, ok := p.tables.actions[lr.Action.ActionId]
+	if !ok {
+		return
+	}
+	for _, pi := range info.Params {
+		name, ok := stage.Writes[pi.Name]
+		if !ok {
+			continue
+		}
+		for _, param := range lr.Action.Params {
+			if param.ParamId == pi.Id {
+				metadata[name] = param.Value
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Creates a two-stage pipeline: a routing table (LPM on ipv4_dst) setting next_id and a next table
+// (exact on next_id) setting the egress port
+func newTwoStagePipeline(t *testing.T) (*Tables, *Pipeline) {
+	tables := NewTables([]*p4info.Table{
+		{
+			Preamble:    &p4info.Preamble{Id: 10, Name: "routing"},
+			MatchFields: []*p4info.MatchField{{Id: 1, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}}},
+			ActionRefs:  []*p4info.ActionRef{{Id: 3}},
+		},
+		{
+			Preamble:    &p4info.Preamble{Id: 20, Name: "next"},
+			MatchFields: []*p4info.MatchField{{Id: 1, Name: "next_id", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+			ActionRefs:  []*p4info.ActionRef{{Id: 1}, {Id: 2}},
+		},
+	})
+	tables.SetActions(append(testActions,
+		&p4info.Action{Preamble: &p4info.Preamble{Id: 3, Name: "set_next_id"}, Params: []*p4info.Action_Param{{Id: 1, Name: "next_id", Bitwidth: 32}}}))
+
+	pipeline, err := NewPipeline(tables, []*PipelineStage{
+		{TableID: 10, Keys: map[uint32]string{1: "ipv4_dst"}, Writes: map[string]string{"next_id": "next_id"}},
+		{TableID: 20, Keys: map[uint32]string{1: "next_id"}, Writes: map[string]string{"port_num": "egress_port"}},
+	})
+	assert.NoError(t, err)
+	return tables, pipeline
+}
+
+// Creates a routing entry for the given prefix, pointing to the given next ID
+func routeEntry(prefix []byte, prefixLen int32, nextID byte) *p4api.TableEntry {
+	return &p4api.TableEntry{
+		TableId: 10,
+		Match:   []*p4api.FieldMatch{{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Lpm{Lpm: &p4api.FieldMatch_LPM{Value: prefix, PrefixLen: prefixLen}}}},
+		Action: &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{
+			ActionId: 3, Params: []*p4api.Action_Param{{ParamId: 1, Value: []byte{nextID}}}}}},
+	}
+}
+
+// Creates a next entry for the given next ID with the specified action
+func nextEntry(nextID byte, action *p4api.TableAction) *p4api.TableEntry {
+	return &p4api.TableEntry{
+		TableId: 20,
+		Match:   []*p4api.FieldMatch{{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{nextID}}}}},
+		Action:  action,
+	}
+}
+
+func TestPipelineWalk(t *testing.T) {
+	tables, pipeline := newTwoStagePipeline(t)
+	assert.NoError(t, tables.ModifyTableEntry(routeEntry([]byte{10, 0, 0, 0}, 8, 1), true))
+	assert.NoError(t, tables.ModifyTableEntry(routeEntry([]byte{10, 1, 0, 0}, 16, 2), true))
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(1, directAction(1, 11)), true))
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(2, directAction(1, 12)), true))
+
+	result, err := pipeline.Walk(Metadata{"ipv4_dst": {10, 2, 3, 4}})
+	assert.NoError(t, err)
+	assert.Len(t, result.Stages, 2)
+	assert.True(t, result.Stages[0].Result.Hit)
+	assert.True(t, result.Stages[1].Result.Hit)
+	assert.Equal(t, []byte{1}, result.Metadata["next_id"])
+	assert.Equal(t, []byte{11}, result.Metadata["egress_port"])
+
+	// Longer prefix wins and leads to a different next
+	result, err = pipeline.Walk(Metadata{"ipv4_dst": {10, 1, 3, 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{12}, result.Metadata["egress_port"])
+
+	// Routing miss leaves next stage without a key and so it misses too
+	result, err = pipeline.Walk(Metadata{"ipv4_dst": {11, 1, 3, 4}})
+	assert.NoError(t, err)
+	assert.False(t, result.Stages[0].Result.Hit)
+	assert.False(t, result.Stages[1].Result.Hit)
+	_, ok := result.Metadata["egress_port"]
+	assert.False(t, ok)
+	assert.Empty(t, result.DropReason)
+
+	// Drop actions are reported as the drop reason
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(2, &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{ActionId: 2}}}), false))
+	result, err = pipeline.Walk(Metadata{"ipv4_dst": {10, 1, 3, 4}})
+	assert.NoError(t, err)
+	assert.Contains(t, result.DropReason, "drop")
+
+	_, err = NewPipeline(tables, []*PipelineStage{{TableID: 30}})
+	assert.Error(t, err)
+}
+
+func TestPipelineTransitTime(t *testing.T) {
+	tables, pipeline := newTwoStagePipeline(t)
+	tables.Table(10).SetLookupLatency(&LookupLatency{Base: 100 * time.Nanosecond, PerPrefixBit: 10 * time.Nanosecond})
+	tables.Table(20).SetLookupLatency(&LookupLatency{Base: 50 * time.Nanosecond})
+	assert.NoError(t, tables.ModifyTableEntry(routeEntry([]byte{10, 0, 0, 0}, 8, 1), true))
+	assert.NoError(t, tables.ModifyTableEntry(routeEntry([]byte{10, 1, 0, 0}, 16, 2), true))
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(1, directAction(1, 11)), true))
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(2, directAction(1, 12)), true))
+
+	result, err := pipeline.Walk(Metadata{"ipv4_dst": {10, 2, 3, 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, 180*time.Nanosecond, result.Stages[0].Result.Latency)
+	assert.Equal(t, 50*time.Nanosecond, result.Stages[1].Result.Latency)
+	assert.Equal(t, 230*time.Nanosecond, result.TransitTime)
+
+	// Longer prefix costs more
+	result, err = pipeline.Walk(Metadata{"ipv4_dst": {10, 1, 3, 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, 310*time.Nanosecond, result.TransitTime)
+
+	// Misses incur only the base latency
+	result, err = pipeline.Walk(Metadata{"ipv4_dst": {11, 1, 3, 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, 150*time.Nanosecond, result.TransitTime)
+
+	// Tables without a latency model are free
+	tables.Table(20).SetLookupLatency(nil)
+	result, err = pipeline.Walk(Metadata{"ipv4_dst": {10, 2, 3, 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, 180*time.Nanosecond, result.TransitTime)
+}
+
+func TestResolveEgress(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{
+			Preamble:    &p4info.Preamble{Id: 10, Name: "routing"},
+			MatchFields: []*p4info.MatchField{{Id: 1, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}}},
+		},
+		{
+			Preamble:         &p4info.Preamble{Id: 20, Name: "hashed"},
+			MatchFields:      []*p4info.MatchField{{Id: 1, Name: "next_id", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+			ImplementationId: 100,
+		},
+	})
+	tables.SetActions(append(testActions,
+		&p4info.Action{Preamble: &p4info.Preamble{Id: 3, Name: "set_next_id"}, Params: []*p4info.Action_Param{{Id: 1, Name: "next_id", Bitwidth: 32}}}))
+	profiles := newTestProfiles()
+	tables.SetActionProfiles(profiles)
+	pipeline, err := NewPipeline(tables, []*PipelineStage{
+		{TableID: 10, Keys: map[uint32]string{1: "ipv4_dst"}, Writes: map[string]string{"next_id": "next_id"}},
+		{TableID: 20, Keys: map[uint32]string{1: "next_id"}, Writes: map[string]string{"port_num": "egress_port"}},
+	})
+	assert.NoError(t, err)
+
+	output := func(port byte) *p4api.Action {
+		return &p4api.Action{ActionId: 1, Params: []*p4api.Action_Param{{ParamId: 1, Value: []byte{port}}}}
+	}
+	assert.NoError(t, profiles.ModifyActionProfileMember(&p4api.ActionProfileMember{ActionProfileId: 100, MemberId: 1, Action: output(21)}, true))
+	assert.NoError(t, profiles.ModifyActionProfileMember(&p4api.ActionProfileMember{ActionProfileId: 100, MemberId: 2, Action: output(22)}, true))
+	assert.NoError(t, profiles.ModifyActionProfileGroup(testGroup(100, 50, 1, 2), true))
+	assert.NoError(t, profiles.ModifyActionProfileMember(&p4api.ActionProfileMember{ActionProfileId: 100, MemberId: 3, Action: &p4api.Action{ActionId: 2}}, true))
+
+	// Next 1 goes via a group; next 2 via a member; next 3 drops; next 4 is not programmed
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(1, &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: 50}}), true))
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(2, &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 2}}), true))
+	assert.NoError(t, tables.ModifyTableEntry(nextEntry(3, &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 3}}), true))
+
+	viaGroup := routeEntry([]byte{10, 0, 0, 0}, 8, 1)
+	resolution, err := pipeline.ResolveEgress(viaGroup, "egress_port")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{21}, resolution.Egress)
+	assert.Len(t, resolution.Chain, 2)
+	assert.Equal(t, viaGroup, resolution.Chain[0].Result.Entry)
+	assert.Equal(t, uint32(50), resolution.Chain[1].Result.Entry.Action.GetActionProfileGroupId())
+
+	resolution, err = pipeline.ResolveEgress(routeEntry([]byte{10, 1, 0, 0}, 16, 2), "egress_port")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{22}, resolution.Egress)
+
+	// Entries already at the end of the chain resolve directly
+	resolution, err = pipeline.ResolveEgress(nextEntry(2, &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 1}}), "egress_port")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{21}, resolution.Egress)
+	assert.Len(t, resolution.Chain, 1)
+
+	_, err = pipeline.ResolveEgress(routeEntry([]byte{10, 2, 0, 0}, 16, 3), "egress_port")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = pipeline.ResolveEgress(routeEntry([]byte{10, 3, 0, 0}, 16, 4), "egress_port")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = pipeline.ResolveEgress(&p4api.TableEntry{TableId: 30}, "egress_port")
+	assert.True(t, errors.IsNotFound(err))
+}
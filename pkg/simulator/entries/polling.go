@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"time"
+)
+
+// Direct counter increments staged until the end of the poll interval in which they occurred
+type stagedCounter struct {
+	period  int64
+	packets int64
+	bytes   int64
+}
+
+// SetCounterPollInterval sets the interval at which the hardware direct counters are polled; datapath increments
+// only become visible to reads once the poll interval in which they occurred has elapsed. Interval of 0 disables
+// the polling model, making increments immediately visible.
+func (t *Table) SetCounterPollInterval(interval time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, row := range t.rows {
+		t.foldStaged(row)
+	}
+	t.pollInterval = interval
+	t.pollEpoch = t.tables.clock()
+}
+
+// IncrementDirectCounter increments the direct counter of the specified entry by the given number of packets and
+// bytes, as if by the datapath
+func (t *Table) IncrementDirectCounter(entry *p4api.TableEntry, packets int64, bytes int64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.directCounter == nil {
+		return errors.NewInvalid("table %s has no direct counter", t.Name())
+	}
+	sortFieldMatches(entry.Match)
+	key, err := t.entryKey(entry)
+	if err != nil {
+		return err
+	}
+	row, ok := t.row(key, entry)
+	if !ok {
+		return errors.NewNotFound("entry doesn't exist: %v", entry)
+	}
+
+	if t.pollInterval == 0 {
+		row.addCounts(packets, bytes)
+		t.mutated()
+		return nil
+	}
+
+	period := t.pollPeriod()
+	if row.staged != nil && row.staged.period < period {
+		t.foldStaged(row)
+	}
+	if row.staged == nil {
+		row.staged = &stagedCounter{period: period}
+	}
+	row.staged.packets += packets
+	row.staged.bytes += bytes
+	return nil
+}
+
+// Returns the index of the current poll period
+func (t *Table) pollPeriod() int64 {
+	return int64(t.tables.clock().Sub(t.pollEpoch) / t.pollInterval)
+}
+
+// Makes visible all staged increments which occurred during the poll periods which have already elapsed
+func (t *Table) pollCounters() {
+	if t.pollInterval == 0 {
+		return
+	}
+	period := t.pollPeriod()
+	for _, row := range t.rows {
+		if row.staged != nil && row.staged.period < period {
+			t.foldStaged(row)
+		}
+	}
+}
+
+// Folds the staged increments of the row into its visible counter data
+func (t *Table) foldStaged(row *Row) {
+	if row.staged == nil {
+		return
+	}
+	row.addCounts(row.staged.packets, row.staged.bytes)
+	row.staged = nil
+	t.mutated()
+}
+
+// Adds the given counts to the row counter data; the counter data is replaced rather than updated in place, as
+// it may have been handed out by previous reads
+func (r *Row) addCounts(packets int64, bytes int64) {
+	r.counterData = &p4api.CounterData{
+		PacketCount: r.counterData.GetPacketCount() + packets,
+		ByteCount:   r.counterData.GetByteCount() + bytes,
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Reads the packet count of the direct counter of the specified entry
+func readPackets(t *testing.T, table *Table, entry *p4api.TableEntry) int64 {
+	packets := int64(-1)
+	assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			dce := entity.GetDirectCounterEntry()
+			if sameMatches(dce.TableEntry.Match, entry.Match) {
+				packets = dce.Data.PacketCount
+			}
+		}
+		return nil
+	}))
+	return packets
+}
+
+func TestCounterPollInterval(t *testing.T) {
+	tables := newExactTables()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+	entry := exactEntry(1, 2)
+	assert.NoError(t, table.ModifyTableEntry(entry, true))
+
+	// Without polling, increments are immediately visible
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 1, 100))
+	assert.Equal(t, int64(1), readPackets(t, table, entry))
+
+	table.SetCounterPollInterval(time.Second)
+	clock.Advance(200 * time.Millisecond)
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 2, 200))
+	clock.Advance(500 * time.Millisecond)
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 3, 300))
+	assert.Equal(t, int64(1), readPackets(t, table, entry))
+
+	// Increments become visible at the poll boundary
+	clock.Advance(300 * time.Millisecond)
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 4, 400))
+	assert.Equal(t, int64(6), readPackets(t, table, entry))
+	clock.Advance(999 * time.Millisecond)
+	assert.Equal(t, int64(6), readPackets(t, table, entry))
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, int64(10), readPackets(t, table, entry))
+
+	// Disabling the polling makes all staged increments visible
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 5, 500))
+	assert.Equal(t, int64(10), readPackets(t, table, entry))
+	table.SetCounterPollInterval(0)
+	assert.Equal(t, int64(15), readPackets(t, table, entry))
+
+	assert.True(t, errors.IsNotFound(table.IncrementDirectCounter(exactEntry(3, 4), 1, 1)))
+}
+
+func TestResetDirectCounter(t *testing.T) {
+	tables := newExactTables()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+	entry := exactEntry(1, 2)
+	assert.NoError(t, table.ModifyTableEntry(entry, true))
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 5, 500))
+	assert.Equal(t, int64(5), readPackets(t, table, entry))
+
+	// Reset zeroes the counter, via either the table or the tables
+	assert.NoError(t, tables.ResetDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(1, 2),
+		Data: &p4api.CounterData{PacketCount: 9}}))
+	assert.Equal(t, int64(0), readPackets(t, table, entry))
+
+	// Modify with empty data zeroes the counter as well
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 3, 300))
+	assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(1, 2)}, false))
+	assert.Equal(t, int64(0), readPackets(t, table, entry))
+
+	// Staged increments not yet polled are discarded by the reset
+	table.SetCounterPollInterval(time.Second)
+	assert.NoError(t, table.IncrementDirectCounter(exactEntry(1, 2), 4, 400))
+	assert.NoError(t, table.ResetDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(1, 2)}))
+	clock.Advance(time.Second)
+	assert.Equal(t, int64(0), readPackets(t, table, entry))
+
+	// Entries which do not exist cannot be reset
+	err := table.ResetDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(3, 4)})
+	assert.True(t, errors.IsNotFound(err))
+	err = tables.ResetDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: &p4api.TableEntry{TableId: 2}})
+	assert.True(t, errors.IsNotFound(err))
+	assert.True(t, errors.IsInvalid(table.ResetDirectCounterEntry(&p4api.DirectCounterEntry{})))
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"sort"
+)
+
+// LookupTernary looks up the highest priority entry matching the specified field values in a table requiring
+// priorities, i.e. one with ternary, range or optional match fields, falling back to the default action on a miss;
+// fields absent from an entry are wildcards. Entries of the same priority are tried in the order of their keys.
+func (t *Table) LookupTernary(values FieldValues) (*LookupResult, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.lookupTernary(values)
+}
+
+func (t *Table) lookupTernary(values FieldValues) (*LookupResult, error) {
+	if !t.RequiresPriority() {
+		return nil, errors.NewInvalid("table %s has no ternary, range or optional match fields", t.Name())
+	}
+	for _, key := range t.priorityIndex() {
+		if row := t.rows[key]; t.rowMatchesValues(row, values) {
+			result := t.lookupHit(row)
+			result.Latency = t.simulatedLatency(0)
+			return result, nil
+		}
+	}
+	result := t.lookupDefault()
+	result.Latency = t.simulatedLatency(0)
+	return result, nil
+}
+
+// Returns the keys of the table rows ordered by descending priority and then by key, building the index if needed;
+// the index is only maintained once built, so that tables which are never looked up by priority do not pay for it
+func (t *Table) priorityIndex() []string {
+	if t.byPriority == nil {
+		t.byPriority = make([]string, 0, len(t.rows))
+		for key := range t.rows {
+			t.byPriority = append(t.byPriority, key)
+		}
+		sort.Slice(t.byPriority, func(i, j int) bool { return t.priorityBefore(t.byPriority[i], t.byPriority[j]) })
+	}
+	return t.byPriority
+}
+
+// Returns true if the row with key a precedes the row with key b in the priority index
+func (t *Table) priorityBefore(a string, b string) bool {
+	pa, pb := t.rows[a].entry.Priority, t.rows[b].entry.Priority
+	if pa != pb {
+		return pa > pb
+	}
+	return a < b
+}
+
+// Adds the key of the newly added row to the priority index, if the index has been built
+func (t *Table) indexPriority(key string) {
+	if t.byPriority == nil {
+		return
+	}
+	i := sort.Search(len(t.byPriority), func(i int) bool { return !t.priorityBefore(t.byPriority[i], key) })
+	t.byPriority = append(t.byPriority, "")
+	copy(t.byPriority[i+1:], t.byPriority[i:])
+	t.byPriority[i] = key
+}
+
+// Removes the key of the row about to be removed from the priority index, if the index has been built
+func (t *Table) unindexPriority(key string) {
+	if t.byPriority == nil {
+		return
+	}
+	i := sort.Search(len(t.byPriority), func(i int) bool { return !t.priorityBefore(t.byPriority[i], key) })
+	if i < len(t.byPriority) && t.byPriority[i] == key {
+		t.byPriority = append(t.byPriority[:i], t.byPriority[i+1:]...)
+	}
+}
@@ -17,26 +17,33 @@ type ActionProfileMember struct {
 
 // ActionProfileGroup represents a P4 action profile group
 type ActionProfileGroup struct {
-	entry *p4api.ActionProfileGroup
-	name  string
+	entry    *p4api.ActionProfileGroup
+	name     string
+	selector *memberSelector
 }
 
 // ActionProfile represents a P4 action profile instance
 type ActionProfile struct {
-	info    *p4info.ActionProfile
-	members map[uint32]*ActionProfileMember
-	groups  map[uint32]*ActionProfileGroup
+	info     *p4info.ActionProfile
+	members  map[uint32]*ActionProfileMember
+	groups   map[uint32]*ActionProfileGroup
+	selector *memberSelector
 }
 
 // ActionProfiles represents a set of P4 action profiles
 type ActionProfiles struct {
 	profiles map[uint32]*ActionProfile
+	tables   *Tables
+	selector *memberSelector
+
+	batching
 }
 
 // NewActionProfiles creates a new action profiles
 func NewActionProfiles(actionProfilesInfo []*p4info.ActionProfile) *ActionProfiles {
 	gs := &ActionProfiles{
 		profiles: make(map[uint32]*ActionProfile, len(actionProfilesInfo)),
+		selector: &memberSelector{},
 	}
 	for _, pi := range actionProfilesInfo {
 		gs.profiles[pi.Preamble.Id] = gs.NewActionProfile(pi)
@@ -47,9 +54,10 @@ func NewActionProfiles(actionProfilesInfo []*p4info.ActionProfile) *ActionProfil
 // NewActionProfile creates a new action profile
 func (aps *ActionProfiles) NewActionProfile(info *p4info.ActionProfile) *ActionProfile {
 	return &ActionProfile{
-		info:    info,
-		members: make(map[uint32]*ActionProfileMember),
-		groups:  make(map[uint32]*ActionProfileGroup),
+		info:     info,
+		members:  make(map[uint32]*ActionProfileMember),
+		groups:   make(map[uint32]*ActionProfileGroup),
+		selector: aps.selector,
 	}
 }
 
@@ -83,7 +91,7 @@ func (aps *ActionProfiles) ReadActionProfileMembers(entry *p4api.ActionProfileMe
 	if !ok {
 		return errors.NewNotFound("action profile not found")
 	}
-	return profile.ReadActionProfileMembers(sender)
+	return profile.readMembers(aps.newBuffer(sender))
 }
 
 // DeleteActionProfileMember deletes the specified member entry from its action profile
@@ -110,7 +118,7 @@ func (aps *ActionProfiles) ReadActionProfileGroups(entry *p4api.ActionProfileGro
 	if !ok {
 		return errors.NewNotFound("action profile not found")
 	}
-	return profile.ReadActionProfileGroups(sender)
+	return profile.readGroups(aps.newBuffer(sender))
 }
 
 // DeleteActionProfileGroup deletes the specified action profile group
@@ -122,6 +130,73 @@ func (aps *ActionProfiles) DeleteActionProfileGroup(entry *p4api.ActionProfileGr
 	return profile.DeleteActionProfileGroup(entry)
 }
 
+// ReadAll sends all members and groups of the specified action profile to the given sender; profile ID of 0
+// reads members and groups of all action profiles
+func (aps *ActionProfiles) ReadAll(profileID uint32, sender BatchSender) error {
+	buffer := aps.newBuffer(sender)
+	if profileID == 0 {
+		for _, profile := range aps.profiles {
+			if err := profile.readAll(buffer); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
+
+	profile, ok := aps.profiles[profileID]
+	if !ok {
+		return errors.NewNotFound("action profile not found")
+	}
+	if err := profile.readAll(buffer); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Returns the specified member of the given action profile
+func (aps *ActionProfiles) member(profileID uint32, memberID uint32) (*ActionProfileMember, bool) {
+	profile, ok := aps.profiles[profileID]
+	if !ok {
+		return nil, false
+	}
+	member, ok := profile.members[memberID]
+	return member, ok
+}
+
+// Returns the specified group of the given action profile
+func (aps *ActionProfiles) group(profileID uint32, groupID uint32) (*ActionProfileGroup, bool) {
+	profile, ok := aps.profiles[profileID]
+	if !ok {
+		return nil, false
+	}
+	group, ok := profile.groups[groupID]
+	return group, ok
+}
+
+// EntriesReferencingGroup returns the table entries, across all tables implemented by the specified action
+// profile, whose action references the given group
+func (aps *ActionProfiles) EntriesReferencingGroup(profileID uint32, groupID uint32) ([]*p4api.TableEntry, error) {
+	if _, ok := aps.profiles[profileID]; !ok {
+		return nil, errors.NewNotFound("action profile not found")
+	}
+	refs := make([]*p4api.TableEntry, 0)
+	if aps.tables == nil {
+		return refs, nil
+	}
+	for _, table := range aps.tables.tables {
+		if table.info.ImplementationId != profileID {
+			continue
+		}
+		_ = table.visitRows(&p4api.TableEntry{}, func(row *Row) error {
+			if row.entry.Action.GetActionProfileGroupId() == groupID {
+				refs = append(refs, row.entry)
+			}
+			return nil
+		})
+	}
+	return refs, nil
+}
+
 // Groups returns a list of all action profiles' groups.
 func (aps *ActionProfiles) Groups() []*ActionProfileGroup {
 	groups := make([]*ActionProfileGroup, 0)
@@ -133,6 +208,15 @@ func (aps *ActionProfiles) Groups() []*ActionProfileGroup {
 	return groups
 }
 
+// EntryCount returns the total number of members and groups of all action profiles
+func (aps *ActionProfiles) EntryCount() int {
+	count := 0
+	for _, profile := range aps.profiles {
+		count += len(profile.members) + len(profile.groups)
+	}
+	return count
+}
+
 // ModifyActionProfileMember modifies the specified member entry
 func (ap ActionProfile) ModifyActionProfileMember(entry *p4api.ActionProfileMember, insert bool) error {
 	member, ok := ap.members[entry.MemberId]
@@ -149,8 +233,8 @@ func (ap ActionProfile) ModifyActionProfileMember(entry *p4api.ActionProfileMemb
 
 	// If the entry doesn't exist and we're supposed to do insert, well... do it
 	if !ok && insert {
-		if int64(len(ap.members)) > ap.info.Size {
-			return errors.NewUnavailable("resource exhausted: %v", entry)
+		if ap.info.Size > 0 && int64(len(ap.members)) >= ap.info.Size {
+			return NewResourceExhausted("resource exhausted: %v", entry)
 		}
 		member = &ActionProfileMember{}
 		ap.members[entry.MemberId] = member
@@ -169,7 +253,11 @@ func (ap ActionProfile) DeleteActionProfileMember(entry *p4api.ActionProfileMemb
 
 // ReadActionProfileMembers sends all members of the profile to the specified sender
 func (ap ActionProfile) ReadActionProfileMembers(sender BatchSender) error {
-	buffer := newBuffer(sender)
+	return ap.readMembers(newBuffer(sender, DefaultBatchSize))
+}
+
+// Sends all members of the profile via the given buffer
+func (ap ActionProfile) readMembers(buffer *entityBuffer) error {
 	for _, member := range ap.members {
 		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_ActionProfileMember{ActionProfileMember: member.entry}}); err != nil {
 			return err
@@ -178,10 +266,30 @@ func (ap ActionProfile) ReadActionProfileMembers(sender BatchSender) error {
 	return buffer.flush()
 }
 
+// Sends all members followed by all groups of the profile via the given buffer
+func (ap ActionProfile) readAll(buffer *entityBuffer) error {
+	for _, member := range ap.members {
+		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_ActionProfileMember{ActionProfileMember: member.entry}}); err != nil {
+			return err
+		}
+	}
+	for _, group := range ap.groups {
+		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_ActionProfileGroup{ActionProfileGroup: group.entry}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ModifyActionProfileGroup modifies the specified group in this action profile
 func (ap ActionProfile) ModifyActionProfileGroup(entry *p4api.ActionProfileGroup, insert bool) error {
 	group, ok := ap.groups[entry.GroupId]
 
+	// Validate the group members against the existing members and the maximum group size
+	if err := ap.validateGroupMembers(entry); err != nil {
+		return err
+	}
+
 	// If the entry exists, and we're supposed to do a new insert, raise error
 	if ok && insert {
 		return errors.NewAlreadyExists("entry already exists: %v", entry)
@@ -194,10 +302,10 @@ func (ap ActionProfile) ModifyActionProfileGroup(entry *p4api.ActionProfileGroup
 
 	// If the entry doesn't exist and we're supposed to do insert, well... do it
 	if !ok && insert {
-		if int64(len(ap.groups)) > ap.info.Size {
-			return errors.NewUnavailable("resource exhausted: %v", entry)
+		if ap.info.Size > 0 && int64(len(ap.groups)) >= ap.info.Size {
+			return NewResourceExhausted("resource exhausted: %v", entry)
 		}
-		group = &ActionProfileGroup{name: ap.info.Preamble.Name}
+		group = &ActionProfileGroup{name: ap.info.Preamble.Name, selector: ap.selector}
 		ap.groups[entry.GroupId] = group
 	}
 
@@ -206,9 +314,32 @@ func (ap ActionProfile) ModifyActionProfileGroup(entry *p4api.ActionProfileGroup
 	return nil
 }
 
+// Validates that the group references only existing members, each at most once, and that it does not exceed the
+// maximum group size of the profile
+func (ap ActionProfile) validateGroupMembers(entry *p4api.ActionProfileGroup) error {
+	if ap.info.MaxGroupSize > 0 && len(entry.Members) > int(ap.info.MaxGroupSize) {
+		return errors.NewInvalid("group %d exceeds maximum group size %d", entry.GroupId, ap.info.MaxGroupSize)
+	}
+	seen := make(map[uint32]bool, len(entry.Members))
+	for _, m := range entry.Members {
+		if _, ok := ap.members[m.MemberId]; !ok {
+			return errors.NewInvalid("action profile member %d not found", m.MemberId)
+		}
+		if seen[m.MemberId] {
+			return errors.NewInvalid("duplicate action profile member %d in group %d", m.MemberId, entry.GroupId)
+		}
+		seen[m.MemberId] = true
+	}
+	return nil
+}
+
 // ReadActionProfileGroups sends all groups of the profile to the specified sender
 func (ap ActionProfile) ReadActionProfileGroups(sender BatchSender) error {
-	buffer := newBuffer(sender)
+	return ap.readGroups(newBuffer(sender, DefaultBatchSize))
+}
+
+// Sends all groups of the profile via the given buffer
+func (ap ActionProfile) readGroups(buffer *entityBuffer) error {
 	for _, group := range ap.groups {
 		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_ActionProfileGroup{ActionProfileGroup: group.entry}}); err != nil {
 			return err
@@ -0,0 +1,389 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+// Creates two action profiles for testing
+func newTestProfiles() *ActionProfiles {
+	return NewActionProfiles([]*p4info.ActionProfile{
+		{Preamble: &p4info.Preamble{Id: 100, Name: "p100"}, Size: 256, MaxGroupSize: 16, WithSelector: true},
+		{Preamble: &p4info.Preamble{Id: 200, Name: "p200"}, Size: 256, MaxGroupSize: 16, WithSelector: true},
+	})
+}
+
+// Creates a member in the given profile with the specified action ID
+func testMember(profileID uint32, memberID uint32, actionID uint32) *p4api.ActionProfileMember {
+	return &p4api.ActionProfileMember{ActionProfileId: profileID, MemberId: memberID, Action: &p4api.Action{ActionId: actionID}}
+}
+
+// Creates a group in the given profile with the specified members; weights match member IDs
+func testGroup(profileID uint32, groupID uint32, memberIDs ...uint32) *p4api.ActionProfileGroup {
+	members := make([]*p4api.ActionProfileGroup_Member, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		members = append(members, &p4api.ActionProfileGroup_Member{MemberId: id, Weight: int32(id)})
+	}
+	return &p4api.ActionProfileGroup{ActionProfileId: profileID, GroupId: groupID, Members: members}
+}
+
+func TestReadAllProfiles(t *testing.T) {
+	aps := newTestProfiles()
+	for i := uint32(1); i <= 3; i++ {
+		assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, i, 1), true))
+	}
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(200, 1, 1), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 10, 1, 2, 3), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(200, 20, 1), true))
+
+	members := make(map[uint32][]*p4api.ActionProfileMember)
+	groups := make(map[uint32][]*p4api.ActionProfileGroup)
+	read := func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			if m := entity.GetActionProfileMember(); m != nil {
+				members[m.ActionProfileId] = append(members[m.ActionProfileId], m)
+			}
+			if g := entity.GetActionProfileGroup(); g != nil {
+				groups[g.ActionProfileId] = append(groups[g.ActionProfileId], g)
+			}
+		}
+		return nil
+	}
+
+	// Read everything across all profiles
+	assert.NoError(t, aps.ReadAll(0, read))
+	assert.Len(t, members[100], 3)
+	assert.Len(t, members[200], 1)
+	assert.Len(t, groups[100], 1)
+	assert.Len(t, groups[200], 1)
+	assert.Len(t, groups[100][0].Members, 3)
+	for _, m := range groups[100][0].Members {
+		assert.Equal(t, int32(m.MemberId), m.Weight)
+	}
+
+	// Read only a single profile
+	members = make(map[uint32][]*p4api.ActionProfileMember)
+	groups = make(map[uint32][]*p4api.ActionProfileGroup)
+	assert.NoError(t, aps.ReadAll(200, read))
+	assert.Len(t, members[100], 0)
+	assert.Len(t, members[200], 1)
+	assert.Len(t, groups[100], 0)
+	assert.Len(t, groups[200], 1)
+
+	assert.Error(t, aps.ReadAll(300, read))
+}
+
+func TestEntriesReferencingGroup(t *testing.T) {
+	tables := newLPMTables()
+	aps := newTestProfiles()
+	tables.SetActionProfiles(aps)
+
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, 1, 7), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 10, 1), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 11, 1), true))
+
+	groupRef := func(id uint32) *p4api.TableAction {
+		return &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: id}}
+	}
+	for i := byte(1); i <= 3; i++ {
+		entry := lpmEntry(i, []byte{10, 0, 0, 0}, 8)
+		entry.Action = groupRef(10)
+		assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	}
+	entry := lpmEntry(4, []byte{10, 0, 0, 0}, 8)
+	entry.Action = groupRef(11)
+	assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: groupRef(10)}, false))
+
+	refs, err := aps.EntriesReferencingGroup(100, 10)
+	assert.NoError(t, err)
+	assert.Len(t, refs, 4)
+	for _, ref := range refs {
+		assert.Equal(t, uint32(10), ref.Action.GetActionProfileGroupId())
+	}
+
+	refs, err = aps.EntriesReferencingGroup(100, 11)
+	assert.NoError(t, err)
+	assert.Len(t, refs, 1)
+
+	refs, err = aps.EntriesReferencingGroup(200, 10)
+	assert.NoError(t, err)
+	assert.Len(t, refs, 0)
+
+	_, err = aps.EntriesReferencingGroup(300, 10)
+	assert.Error(t, err)
+}
+
+func TestEntryCountByGroup(t *testing.T) {
+	tables := newLPMTables()
+	aps := newTestProfiles()
+	tables.SetActionProfiles(aps)
+	table := tables.Table(2)
+	assert.Len(t, table.EntryCountByGroup(), 0)
+
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, 1, 7), true))
+	for _, groupID := range []uint32{10, 11, 12} {
+		assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, groupID, 1), true))
+	}
+
+	groupRef := func(id uint32) *p4api.TableAction {
+		return &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: id}}
+	}
+	for i, groupID := range []uint32{10, 10, 10, 11, 12, 12} {
+		entry := lpmEntry(byte(i), []byte{10, 0, 0, 0}, 8)
+		entry.Action = groupRef(groupID)
+		assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	}
+	// Entries with member references do not count towards any group
+	entry := lpmEntry(20, []byte{10, 0, 0, 0}, 8)
+	entry.Action = &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 1}}
+	assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: groupRef(11)}, false))
+
+	assert.Equal(t, map[uint32]int{10: 3, 11: 2, 12: 2}, table.EntryCountByGroup())
+
+	assert.NoError(t, table.RemoveTableEntry(lpmEntry(0, []byte{10, 0, 0, 0}, 8)))
+	assert.Equal(t, map[uint32]int{10: 2, 11: 2, 12: 2}, table.EntryCountByGroup())
+}
+
+func TestReadTableEntriesResolved(t *testing.T) {
+	tables := newLPMTables()
+	aps := newTestProfiles()
+	tables.SetActionProfiles(aps)
+	table := tables.Table(2)
+
+	for i := uint32(1); i <= 3; i++ {
+		assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, i, 10+i), true))
+	}
+	group := testGroup(100, 10, 1, 3)
+	group.Members[1].WatchKind = &p4api.ActionProfileGroup_Member_WatchPort{WatchPort: []byte{7}}
+	assert.NoError(t, aps.ModifyActionProfileGroup(group, true))
+
+	grouped := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	grouped.Action = &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: 10}}
+	assert.NoError(t, table.ModifyTableEntry(grouped, true))
+	member := lpmEntry(2, []byte{10, 0, 0, 0}, 8)
+	member.Action = &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 2}}
+	assert.NoError(t, table.ModifyTableEntry(member, true))
+
+	entries := make(map[byte]*p4api.TableEntry)
+	assert.NoError(t, table.ReadTableEntriesResolved(&p4api.TableEntry{}, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			entries[entity.GetTableEntry().Match[0].GetExact().Value[0]] = entity.GetTableEntry()
+		}
+		return nil
+	}))
+	assert.Len(t, entries, 2)
+
+	// Group references are replaced by the member actions, weights and watch ports
+	set := entries[1].Action.GetActionProfileActionSet()
+	assert.NotNil(t, set)
+	assert.Len(t, set.ActionProfileActions, 2)
+	assert.Equal(t, uint32(11), set.ActionProfileActions[0].Action.ActionId)
+	assert.Equal(t, int32(1), set.ActionProfileActions[0].Weight)
+	assert.Nil(t, set.ActionProfileActions[0].GetWatchPort())
+	assert.Equal(t, uint32(13), set.ActionProfileActions[1].Action.ActionId)
+	assert.Equal(t, int32(3), set.ActionProfileActions[1].Weight)
+	assert.Equal(t, []byte{7}, set.ActionProfileActions[1].GetWatchPort())
+
+	// Member references are left as they are, as are the stored entries
+	assert.Equal(t, uint32(2), entries[2].Action.GetActionProfileMemberId())
+	assert.Equal(t, uint32(10), grouped.Action.GetActionProfileGroupId())
+}
+
+// Selects a member of the given group for each of the specified number of flows
+func selectMembers(t *testing.T, group *ActionProfileGroup, flows int) []uint32 {
+	selected := make([]uint32, 0, flows)
+	for i := 0; i < flows; i++ {
+		memberID, ok := group.SelectMember([]byte{byte(i >> 8), byte(i)})
+		assert.True(t, ok)
+		selected = append(selected, memberID)
+	}
+	return selected
+}
+
+func TestSelectMember(t *testing.T) {
+	aps := newTestProfiles()
+	for i := uint32(1); i <= 3; i++ {
+		assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, i, 1), true))
+	}
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 1, 1, 2, 3), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 2), true))
+	group, _ := aps.group(100, 1)
+	empty, _ := aps.group(100, 2)
+	_, ok := empty.SelectMember([]byte{1})
+	assert.False(t, ok)
+
+	// Deterministic mode is reproducible, regardless of the seed
+	deterministic := selectMembers(t, group, 600)
+	assert.Equal(t, deterministic, selectMembers(t, group, 600))
+	aps.SetSelectionMode(DeterministicSelection, 42)
+	assert.Equal(t, deterministic, selectMembers(t, group, 600))
+
+	// Nondeterministic mode is reproducible for a given seed, but varies across seeds
+	aps.SetSelectionMode(NondeterministicSelection, 1)
+	seed1 := selectMembers(t, group, 600)
+	assert.Equal(t, seed1, selectMembers(t, group, 600))
+	aps.SetSelectionMode(NondeterministicSelection, 2)
+	seed2 := selectMembers(t, group, 600)
+	assert.NotEqual(t, seed1, seed2)
+	assert.NotEqual(t, deterministic, seed1)
+
+	// All modes respect the member weights of 1, 2 and 3
+	for _, selected := range [][]uint32{deterministic, seed1, seed2} {
+		counts := make(map[uint32]int)
+		for _, memberID := range selected {
+			counts[memberID]++
+		}
+		assert.InDelta(t, 100, counts[1], 40)
+		assert.InDelta(t, 200, counts[2], 50)
+		assert.InDelta(t, 300, counts[3], 50)
+	}
+}
+
+func TestActionProfileValidation(t *testing.T) {
+	aps := NewActionProfiles([]*p4info.ActionProfile{
+		{Preamble: &p4info.Preamble{Id: 100, Name: "p100"}, Size: 4, MaxGroupSize: 2, WithSelector: true},
+	})
+
+	// Members are limited by the profile size
+	for i := uint32(1); i <= 4; i++ {
+		assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, i, 1), true))
+	}
+	assert.True(t, IsResourceExhausted(aps.ModifyActionProfileMember(testMember(100, 5, 1), true)))
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, 4, 2), false))
+
+	// Groups must reference existing members, each at most once, within the maximum group size
+	assert.True(t, errors.IsInvalid(aps.ModifyActionProfileGroup(testGroup(100, 1, 1, 5), true)))
+	assert.True(t, errors.IsInvalid(aps.ModifyActionProfileGroup(testGroup(100, 1, 1, 1), true)))
+	assert.True(t, errors.IsInvalid(aps.ModifyActionProfileGroup(testGroup(100, 1, 1, 2, 3), true)))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 1, 1, 2), true))
+	assert.True(t, errors.IsInvalid(aps.ModifyActionProfileGroup(testGroup(100, 1, 1, 2, 3), false)))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 1, 3, 4), false))
+	group, _ := aps.group(100, 1)
+	assert.Equal(t, 2, group.Size())
+	assert.Equal(t, uint32(3), group.entry.Members[0].MemberId)
+
+	// Groups are limited by the profile size
+	for i := uint32(2); i <= 4; i++ {
+		assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, i, 1), true))
+	}
+	assert.True(t, IsResourceExhausted(aps.ModifyActionProfileGroup(testGroup(100, 5, 1), true)))
+
+	// Reads stream back the members and groups
+	members, groups := 0, 0
+	assert.NoError(t, aps.ReadAll(100, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			if entity.GetActionProfileMember() != nil {
+				members++
+			} else if entity.GetActionProfileGroup() != nil {
+				groups++
+			}
+		}
+		return nil
+	}))
+	assert.Equal(t, 4, members)
+	assert.Equal(t, 4, groups)
+}
+
+func TestIndirectTableActions(t *testing.T) {
+	tables := newLPMTables()
+	aps := newTestProfiles()
+	tables.SetActionProfiles(aps)
+	table := tables.Table(2)
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(100, 1, 7), true))
+	assert.NoError(t, aps.ModifyActionProfileGroup(testGroup(100, 10, 1), true))
+	assert.NoError(t, aps.ModifyActionProfileMember(testMember(200, 2, 7), true))
+
+	entry := func(vrf byte, action *p4api.TableAction) *p4api.TableEntry {
+		entry := lpmEntry(vrf, []byte{10, 0, 0, 0}, 8)
+		entry.Action = action
+		return entry
+	}
+	memberRef := &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 1}}
+	groupRef := &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: 10}}
+
+	// Entries may reference existing members and groups of the profile implementing the table
+	assert.NoError(t, table.ModifyTableEntry(entry(1, memberRef), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(2, groupRef), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(1, groupRef), false))
+	assert.Equal(t, uint32(7), table.resolveAction(memberRef).ActionId)
+
+	// References to unknown members and groups, or to members of other profiles, are refused
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(3, &p4api.TableAction{
+		Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 2}}), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(3, &p4api.TableAction{
+		Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: 11}}), true)))
+
+	// Indirect tables refuse direct actions and direct tables refuse references
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(3, directAction(7, 1)), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(2, directAction(7, 1)), false)))
+	direct := newExactTables()
+	direct.SetActionProfiles(aps)
+	exact := exactEntry(1, 2)
+	exact.Action = memberRef
+	assert.True(t, errors.IsInvalid(direct.ModifyTableEntry(exact, true)))
+	assert.Equal(t, 2, table.Size())
+}
+
+func TestOneShotActionSets(t *testing.T) {
+	tables := newLPMTables()
+	aps := newTestProfiles()
+	tables.SetActionProfiles(aps)
+	table := tables.Table(2)
+	oneShot := func(vrf byte, weights ...int32) *p4api.TableEntry {
+		set := &p4api.ActionProfileActionSet{}
+		for i, weight := range weights {
+			set.ActionProfileActions = append(set.ActionProfileActions, &p4api.ActionProfileAction{
+				Action: &p4api.Action{ActionId: uint32(i + 1)}, Weight: weight})
+		}
+		entry := lpmEntry(vrf, []byte{10, 0, 0, 0}, 8)
+		entry.Action = &p4api.TableAction{Type: &p4api.TableAction_ActionProfileActionSet{ActionProfileActionSet: set}}
+		return entry
+	}
+
+	// Sets must be non-empty, within the maximum group size, and have positive weights
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(oneShot(1), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(oneShot(1, make([]int32, 17)...), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(oneShot(1, 1, 0), true)))
+	missing := oneShot(1, 1)
+	missing.Action.GetActionProfileActionSet().ActionProfileActions[0].Action = nil
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(missing, true)))
+	direct := newExactTables()
+	direct.SetActionProfiles(aps)
+	exact := exactEntry(1, 2)
+	exact.Action = oneShot(1, 1).Action
+	assert.True(t, errors.IsInvalid(direct.ModifyTableEntry(exact, true)))
+
+	// Valid sets are stored and read back as they were written
+	assert.NoError(t, table.ModifyTableEntry(oneShot(1, 1, 3), true))
+	read := make([]*p4api.TableEntry, 0)
+	assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			read = append(read, entity.GetTableEntry())
+		}
+		return nil
+	}))
+	assert.Len(t, read, 1)
+	assert.True(t, proto.Equal(oneShot(1, 1, 3).Action, read[0].Action))
+
+	// Lookups resolve to the first action, or to the action selected for a flow according to the weights
+	lr, err := table.Lookup(FieldValues{1: {1}, 2: {10, 1, 2, 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), lr.Action.ActionId)
+	counts := make(map[uint32]int)
+	for i := 0; i < 400; i++ {
+		table.selectFlowAction(lr, []byte{byte(i), byte(i >> 8)})
+		counts[lr.Action.ActionId]++
+	}
+	assert.InDelta(t, 100, counts[1], 40)
+	assert.InDelta(t, 300, counts[2], 40)
+}
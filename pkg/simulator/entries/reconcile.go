@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+	"sort"
+)
+
+// InvalidatedEntry represents an existing table entry which does not comply with the new schema of its table
+type InvalidatedEntry struct {
+	Entry  *p4api.TableEntry
+	Reason error
+}
+
+// TablesDiff reports the existing table entries which would not survive a reconfiguration of the tables
+type TablesDiff struct {
+	// Orphaned lists the entries, including default entries, of the tables which are no longer declared
+	Orphaned []*p4api.TableEntry
+	// Invalidated lists the entries whose match fields or priority do not comply with the new table schema
+	Invalidated []*InvalidatedEntry
+}
+
+// IsEmpty returns true if all existing entries remain valid under the new table definitions
+func (d *TablesDiff) IsEmpty() bool {
+	return len(d.Orphaned) == 0 && len(d.Invalidated) == 0
+}
+
+// Diff reports which existing entries would be orphaned or invalidated if the tables were reconfigured using the
+// specified table definitions; entries are reported in table ID order and neither the tables nor the given
+// definitions are modified
+func (ts *Tables) Diff(tablesInfo []*p4info.Table) *TablesDiff {
+	infos := make(map[uint32]*p4info.Table, len(tablesInfo))
+	for _, ti := range tablesInfo {
+		infos[ti.Preamble.Id] = ti
+	}
+
+	diff := &TablesDiff{Orphaned: make([]*p4api.TableEntry, 0), Invalidated: make([]*InvalidatedEntry, 0)}
+	ids := make([]uint32, 0, len(ts.tables))
+	for id := range ts.tables {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		entries := ts.tables[id].Entries()
+		info, ok := infos[id]
+		if !ok {
+			diff.Orphaned = append(diff.Orphaned, entries...)
+			continue
+		}
+
+		// Validate the entries against a detached table bearing the new schema
+		schema := ts.NewTable(proto.Clone(info).(*p4info.Table))
+		for _, entry := range entries {
+			if entry.IsDefaultAction {
+				continue
+			}
+			if err := schema.validateSchema(proto.Clone(entry).(*p4api.TableEntry)); err != nil {
+				diff.Invalidated = append(diff.Invalidated, &InvalidatedEntry{Entry: entry, Reason: err})
+			}
+		}
+	}
+	return diff
+}
+
+// Validates that the match fields and priority of the entry comply with the table schema
+func (t *Table) validateSchema(entry *p4api.TableEntry) error {
+	if err := t.canonicalizeMatches(entry); err != nil {
+		return err
+	}
+	if err := t.validateExactMatches(entry); err != nil {
+		return err
+	}
+	if err := t.validatePriority(entry); err != nil {
+		return err
+	}
+	_, err := t.entryKey(entry)
+	return err
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTablesDiff(t *testing.T) {
+	exactField := func(id uint32, bitwidth int32) *p4info.MatchField {
+		return &p4info.MatchField{Id: id, Name: "f", Bitwidth: bitwidth, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}
+	}
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "exact"}, MatchFields: []*p4info.MatchField{exactField(1, 16), exactField(2, 16)}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "gone"}, MatchFields: []*p4info.MatchField{exactField(1, 8)}},
+	})
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 2), true))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, Match: []*p4api.FieldMatch{
+		{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1}}}},
+		{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1, 2}}}},
+	}}, true))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 2, Match: []*p4api.FieldMatch{
+		{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1}}}},
+	}}, true))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 2, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+
+	// Identical definitions keep all entries
+	assert.True(t, tables.Diff([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "exact"}, MatchFields: []*p4info.MatchField{exactField(2, 16), exactField(1, 16)}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "gone"}, MatchFields: []*p4info.MatchField{exactField(1, 8)}},
+	}).IsEmpty())
+
+	// Entries of dropped tables are orphaned and entries no longer fitting the match spec are invalidated
+	narrowed := []*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "exact"}, MatchFields: []*p4info.MatchField{exactField(2, 8), exactField(1, 16)}},
+	}
+	diff := tables.Diff(narrowed)
+	assert.False(t, diff.IsEmpty())
+	assert.Len(t, diff.Orphaned, 2)
+	assert.Len(t, diff.Invalidated, 1)
+	assert.Equal(t, []byte{1, 2}, diff.Invalidated[0].Entry.Match[1].GetExact().Value)
+	assert.Error(t, diff.Invalidated[0].Reason)
+
+	// A changed match type invalidates all entries; the tables themselves are left untouched
+	diff = tables.Diff([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "exact"}, MatchFields: []*p4info.MatchField{exactField(1, 16),
+			{Id: 2, Name: "f2", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}}}},
+	})
+	assert.Len(t, diff.Invalidated, 2)
+	assert.Equal(t, 2, tables.Table(1).Size())
+	assert.Equal(t, uint32(2), narrowed[0].MatchFields[0].Id)
+}
@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// Register represents all cells of a specific register
+type Register struct {
+	info  *p4info.Register
+	cells []*p4api.RegisterEntry
+}
+
+// Registers represents a set of P4 registers
+type Registers struct {
+	registers map[uint32]*Register
+
+	batching
+}
+
+// NewRegisters creates a new registers store
+func NewRegisters(info []*p4info.Register) *Registers {
+	rs := &Registers{
+		registers: make(map[uint32]*Register, len(info)),
+	}
+	for _, ri := range info {
+		rs.registers[ri.Preamble.Id] = rs.NewRegister(ri)
+	}
+	return rs
+}
+
+// NewRegister creates a new register and all its cell entries, with bitstring cells initialized to zero
+func (rs *Registers) NewRegister(info *p4info.Register) *Register {
+	register := &Register{info: info, cells: make([]*p4api.RegisterEntry, info.Size)}
+	for i := 0; i < int(info.Size); i++ {
+		register.cells[i] = &p4api.RegisterEntry{RegisterId: info.Preamble.Id, Index: &p4api.Index{Index: int64(i)}, Data: register.zeroData()}
+	}
+	return register
+}
+
+// Registers returns the list of registers
+func (rs *Registers) Registers() []*Register {
+	registers := make([]*Register, 0, len(rs.registers))
+	for _, register := range rs.registers {
+		registers = append(registers, register)
+	}
+	return registers
+}
+
+// ModifyRegisterEntry modifies the specified register entry cell; data of nil resets the cell
+func (rs *Registers) ModifyRegisterEntry(entry *p4api.RegisterEntry, insert bool) error {
+	if insert {
+		return errors.NewInvalid("register cannot be inserted")
+	}
+
+	register, ok := rs.registers[entry.RegisterId]
+	if !ok {
+		return errors.NewNotFound("register not found")
+	}
+	if entry.Index == nil || entry.Index.Index < 0 || int(entry.Index.Index) >= len(register.cells) {
+		return errors.NewInvalid("register index out of bounds")
+	}
+	data := entry.Data
+	if data == nil {
+		data = register.zeroData()
+	}
+	if err := register.validateData(data); err != nil {
+		return err
+	}
+
+	register.cells[entry.Index.Index] = &p4api.RegisterEntry{RegisterId: entry.RegisterId, Index: &p4api.Index{Index: entry.Index.Index}, Data: data}
+	return nil
+}
+
+// ReadRegisterEntries reads the register cells matching the specified register entry; register ID of 0 reads all
+// registers and a nil index reads all cells of the register
+func (rs *Registers) ReadRegisterEntries(request *p4api.RegisterEntry, sender BatchSender) error {
+	buffer := rs.newBuffer(sender)
+	if request.RegisterId == 0 {
+		for _, register := range rs.registers {
+			if err := register.readCells(nil, buffer); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
+
+	register, ok := rs.registers[request.RegisterId]
+	if !ok {
+		return errors.NewNotFound("register not found")
+	}
+	if err := register.readCells(request.Index, buffer); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Sends either the cell at the specified index or all cells if the index is nil
+func (r *Register) readCells(index *p4api.Index, buffer *entityBuffer) error {
+	if index != nil {
+		if index.Index < 0 || int(index.Index) >= len(r.cells) {
+			return errors.NewNotFound("register index out of bounds")
+		}
+		return buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_RegisterEntry{RegisterEntry: r.cells[index.Index]}})
+	}
+	for _, cell := range r.cells {
+		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_RegisterEntry{RegisterEntry: cell}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the bitwidth of the register elements, if they are fixed-width bitstrings, or 0 otherwise
+func (r *Register) bitwidth() int32 {
+	bitstring := r.info.GetTypeSpec().GetBitstring()
+	switch {
+	case bitstring.GetBit() != nil:
+		return bitstring.GetBit().Bitwidth
+	case bitstring.GetInt() != nil:
+		return bitstring.GetInt().Bitwidth
+	case bitstring.GetVarbit() != nil:
+		return bitstring.GetVarbit().MaxBitwidth
+	}
+	return 0
+}
+
+// Returns the initial data of the register cells; zero for bitstring elements and nil for other element types
+func (r *Register) zeroData() *p4api.P4Data {
+	if r.info.GetTypeSpec().GetBitstring() == nil {
+		return nil
+	}
+	return &p4api.P4Data{Data: &p4api.P4Data_Bitstring{Bitstring: []byte{0}}}
+}
+
+// Validates the data against the element type of the register; only bitstring element types are validated
+func (r *Register) validateData(data *p4api.P4Data) error {
+	if r.info.GetTypeSpec().GetBitstring() == nil {
+		return nil
+	}
+	value, ok := data.Data.(*p4api.P4Data_Bitstring)
+	if !ok {
+		return errors.NewInvalid("register %s requires bitstring data", r.Name())
+	}
+	if width := r.bitwidth(); width > 0 && len(value.Bitstring) > int(width+7)/8 {
+		return errors.NewInvalid("value of %d bytes exceeds %d bit width of register %s", len(value.Bitstring), width, r.Name())
+	}
+	return nil
+}
+
+// ID returns the register ID
+func (r *Register) ID() uint32 {
+	return r.info.Preamble.Id
+}
+
+// Size returns the number of cells for the register
+func (r *Register) Size() int {
+	return len(r.cells)
+}
+
+// Name returns the register name
+func (r *Register) Name() string {
+	return r.info.Preamble.Name
+}
+
+// Cell returns the specified cell of the register; nil if the index is outside of the register size
+func (r *Register) Cell(index int64) *p4api.RegisterEntry {
+	if index < 0 || int(index) >= len(r.cells) {
+		return nil
+	}
+	return r.cells[index]
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Creates a register entry with the given index and bitstring value
+func registerEntry(registerID uint32, index int64, value []byte) *p4api.RegisterEntry {
+	return &p4api.RegisterEntry{RegisterId: registerID, Index: &p4api.Index{Index: index},
+		Data: &p4api.P4Data{Data: &p4api.P4Data_Bitstring{Bitstring: value}}}
+}
+
+// Reads the register cells matching the request, returning their bitstring values keyed by register ID and index
+func readRegisterValues(t *testing.T, registers *Registers, request *p4api.RegisterEntry) map[uint32]map[int64][]byte {
+	values := make(map[uint32]map[int64][]byte)
+	assert.NoError(t, registers.ReadRegisterEntries(request, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			cell := entity.GetRegisterEntry()
+			if values[cell.RegisterId] == nil {
+				values[cell.RegisterId] = make(map[int64][]byte)
+			}
+			values[cell.RegisterId][cell.Index.Index] = cell.Data.GetBitstring()
+		}
+		return nil
+	}))
+	return values
+}
+
+func TestRegisters(t *testing.T) {
+	bits := func(width int32) *p4info.P4DataTypeSpec {
+		return &p4info.P4DataTypeSpec{TypeSpec: &p4info.P4DataTypeSpec_Bitstring{Bitstring: &p4info.P4BitstringLikeTypeSpec{
+			TypeSpec: &p4info.P4BitstringLikeTypeSpec_Bit{Bit: &p4info.P4BitTypeSpec{Bitwidth: width}}}}}
+	}
+	registers := NewRegisters([]*p4info.Register{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "flows"}, TypeSpec: bits(32), Size: 4},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "seen"}, TypeSpec: bits(1), Size: 300},
+	})
+
+	// Cells exist from the start, with zero values
+	values := readRegisterValues(t, registers, &p4api.RegisterEntry{RegisterId: 2})
+	assert.Len(t, values[2], 300)
+	assert.Equal(t, []byte{0}, values[2][299])
+
+	// Writes must be modifies within the declared size, with values fitting the element width
+	assert.True(t, errors.IsInvalid(registers.ModifyRegisterEntry(registerEntry(1, 0, []byte{1}), true)))
+	assert.True(t, errors.IsInvalid(registers.ModifyRegisterEntry(registerEntry(1, 4, []byte{1}), false)))
+	assert.True(t, errors.IsInvalid(registers.ModifyRegisterEntry(registerEntry(1, -1, []byte{1}), false)))
+	assert.True(t, errors.IsInvalid(registers.ModifyRegisterEntry(registerEntry(1, 0, []byte{1, 2, 3, 4, 5}), false)))
+	assert.True(t, errors.IsInvalid(registers.ModifyRegisterEntry(&p4api.RegisterEntry{RegisterId: 1, Index: &p4api.Index{Index: 0},
+		Data: &p4api.P4Data{Data: &p4api.P4Data_Bool{Bool: true}}}, false)))
+	assert.True(t, errors.IsNotFound(registers.ModifyRegisterEntry(registerEntry(3, 0, []byte{1}), false)))
+	assert.NoError(t, registers.ModifyRegisterEntry(registerEntry(1, 2, []byte{1, 2, 3, 4}), false))
+	assert.NoError(t, registers.ModifyRegisterEntry(registerEntry(2, 7, []byte{1}), false))
+
+	// Reads of a single cell and of all cells of all registers
+	values = readRegisterValues(t, registers, &p4api.RegisterEntry{RegisterId: 1, Index: &p4api.Index{Index: 2}})
+	assert.Equal(t, map[uint32]map[int64][]byte{1: {2: {1, 2, 3, 4}}}, values)
+	values = readRegisterValues(t, registers, &p4api.RegisterEntry{})
+	assert.Len(t, values[1], 4)
+	assert.Len(t, values[2], 300)
+	assert.Equal(t, []byte{1}, values[2][7])
+
+	// Modifying a cell without data resets it
+	assert.NoError(t, registers.ModifyRegisterEntry(&p4api.RegisterEntry{RegisterId: 1, Index: &p4api.Index{Index: 2}}, false))
+	assert.Equal(t, []byte{0}, registers.Registers()[0].Cell(2).Data.GetBitstring())
+	err := registers.ReadRegisterEntries(&p4api.RegisterEntry{RegisterId: 1, Index: &p4api.Index{Index: 4}}, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -11,16 +11,76 @@ import (
 
 // PacketReplication represents packet replication engine constructs
 type PacketReplication struct {
-	multicasts    map[uint32]*p4api.MulticastGroupEntry
-	cloneSessions map[uint32]*p4api.CloneSessionEntry
+	multicasts     map[uint32]*p4api.MulticastGroupEntry
+	cloneSessions  map[uint32]*p4api.CloneSessionEntry
+	tables         *Tables
+	groupParamName string
+	egressQueues   func(egressPort uint32) uint32
+
+	batching
 }
 
+// DefaultGroupParamName is the name of the action parameter carrying the multicast group ID, as used by the
+// set_mcast_group_id action of the fabric pipeline
+const DefaultGroupParamName = "group_id"
+
+// DefaultEgressQueues is the number of egress queues of ports for which no queue configuration is available
+const DefaultEgressQueues = 8
+
 // NewPacketReplication creates store for P4 PRE constructs
 func NewPacketReplication() *PacketReplication {
 	return &PacketReplication{
-		multicasts:    make(map[uint32]*p4api.MulticastGroupEntry),
-		cloneSessions: make(map[uint32]*p4api.CloneSessionEntry),
+		multicasts:     make(map[uint32]*p4api.MulticastGroupEntry),
+		cloneSessions:  make(map[uint32]*p4api.CloneSessionEntry),
+		groupParamName: DefaultGroupParamName,
+	}
+}
+
+// SetGroupParamName sets the name of the action parameter through which table entries target multicast groups
+func (pr *PacketReplication) SetGroupParamName(name string) {
+	pr.groupParamName = name
+}
+
+// SetEgressQueues sets the function giving the number of egress queues of a port, identified by its SDN port
+// number, against which clone session class of service values are validated
+func (pr *PacketReplication) SetEgressQueues(egressQueues func(egressPort uint32) uint32) {
+	pr.egressQueues = egressQueues
+}
+
+// Returns an error if the class of service of the clone session does not map to an egress queue of all its replicas
+func (pr *PacketReplication) validateClassOfService(entry *p4api.CloneSessionEntry) error {
+	for _, replica := range entry.Replicas {
+		queues := uint32(DefaultEgressQueues)
+		if pr.egressQueues != nil {
+			queues = pr.egressQueues(replica.EgressPort)
+		}
+		if entry.ClassOfService >= queues {
+			return errors.NewInvalid("class of service %d exceeds the %d egress queues of port %d",
+				entry.ClassOfService, queues, replica.EgressPort)
+		}
+	}
+	return nil
+}
+
+// GroupUsage returns the table entries, across all tables, whose actions target the specified multicast group
+// via the multicast group action parameter
+func (pr *PacketReplication) GroupUsage(groupID uint32) ([]*p4api.TableEntry, error) {
+	if _, ok := pr.multicasts[groupID]; !ok {
+		return nil, errors.NewNotFound("multicast group %d not found", groupID)
+	}
+	usage := make([]*p4api.TableEntry, 0)
+	if pr.tables == nil {
+		return usage, nil
+	}
+	for _, table := range pr.tables.tables {
+		_ = table.visitRows(&p4api.TableEntry{}, func(row *Row) error {
+			if value, ok := table.actionParamValue(row.entry.Action, pr.groupParamName); ok && DecodeValue(value, BigEndian) == uint64(groupID) {
+				usage = append(usage, row.entry)
+			}
+			return nil
+		})
 	}
+	return usage, nil
 }
 
 // ModifyMulticastGroupEntry modifies the specified multicast group entry
@@ -37,24 +97,73 @@ func (pr *PacketReplication) ModifyMulticastGroupEntry(entry *p4api.MulticastGro
 		return errors.NewNotFound("entry doesn't exist: %v", entry)
 	}
 
+	if err := validateReplicas(entry.Replicas); err != nil {
+		return err
+	}
+
 	pr.multicasts[entry.MulticastGroupId] = entry
 	return nil
 }
 
-// ReadMulticastGroupEntries sends all multicast group entries to the given sender
+// Returns an error if more than one of the replicas has the same egress port and instance
+func validateReplicas(replicas []*p4api.Replica) error {
+	type replicaKey struct {
+		port     uint32
+		instance uint32
+	}
+	seen := make(map[replicaKey]bool, len(replicas))
+	for _, replica := range replicas {
+		key := replicaKey{port: replica.EgressPort, instance: replica.Instance}
+		if seen[key] {
+			return errors.NewInvalid("duplicate replica for port %d and instance %d", replica.EgressPort, replica.Instance)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// ReadMulticastGroupEntries sends the specified multicast group entry to the given sender; group ID of 0 reads
+// all multicast group entries
 func (pr *PacketReplication) ReadMulticastGroupEntries(entry *p4api.MulticastGroupEntry, sender BatchSender) error {
-	buffer := newBuffer(sender)
-	for _, mge := range pr.multicasts {
-		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{
+	buffer := pr.newBuffer(sender)
+	send := func(mge *p4api.MulticastGroupEntry) error {
+		return buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{
 			PacketReplicationEngineEntry: &p4api.PacketReplicationEngineEntry{
 				Type: &p4api.PacketReplicationEngineEntry_MulticastGroupEntry{MulticastGroupEntry: mge},
-			}}}); err != nil {
+			}}})
+	}
+	if entry.MulticastGroupId != 0 {
+		mge, ok := pr.multicasts[entry.MulticastGroupId]
+		if !ok {
+			return errors.NewNotFound("multicast group %d not found", entry.MulticastGroupId)
+		}
+		if err := send(mge); err != nil {
+			return err
+		}
+		return buffer.flush()
+	}
+	for _, mge := range pr.multicasts {
+		if err := send(mge); err != nil {
 			return err
 		}
 	}
 	return buffer.flush()
 }
 
+// ReplicaPorts returns the egress ports to which a packet sent to the specified multicast group is replicated, one
+// per replica, in replica order
+func (pr *PacketReplication) ReplicaPorts(groupID uint32) ([]uint32, error) {
+	mge, ok := pr.multicasts[groupID]
+	if !ok {
+		return nil, errors.NewNotFound("multicast group %d not found", groupID)
+	}
+	ports := make([]uint32, 0, len(mge.Replicas))
+	for _, replica := range mge.Replicas {
+		ports = append(ports, replica.EgressPort)
+	}
+	return ports, nil
+}
+
 // DeleteMulticastGroupEntry deletes the specified multicast group entry
 func (pr *PacketReplication) DeleteMulticastGroupEntry(entry *p4api.MulticastGroupEntry) error {
 	delete(pr.multicasts, entry.MulticastGroupId)
@@ -75,30 +184,91 @@ func (pr *PacketReplication) ModifyCloneSessionEntry(entry *p4api.CloneSessionEn
 		return errors.NewNotFound("entry doesn't exist: %v", entry)
 	}
 
+	if err := validateReplicas(entry.Replicas); err != nil {
+		return err
+	}
+	if err := pr.validateClassOfService(entry); err != nil {
+		return err
+	}
+
 	pr.cloneSessions[entry.SessionId] = entry
 	return nil
 }
 
-// ReadCloneSessionEntries sends all clone session entries to the given sender
+// ReadCloneSessionEntries sends the specified clone session entry to the given sender; session ID of 0 reads all
+// clone session entries
 func (pr *PacketReplication) ReadCloneSessionEntries(entry *p4api.CloneSessionEntry, sender BatchSender) error {
-	buffer := newBuffer(sender)
-	for _, cs := range pr.cloneSessions {
-		if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{
+	buffer := pr.newBuffer(sender)
+	send := func(cs *p4api.CloneSessionEntry) error {
+		return buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{
 			PacketReplicationEngineEntry: &p4api.PacketReplicationEngineEntry{
 				Type: &p4api.PacketReplicationEngineEntry_CloneSessionEntry{CloneSessionEntry: cs},
-			}}}); err != nil {
+			}}})
+	}
+	if entry.SessionId != 0 {
+		cs, ok := pr.cloneSessions[entry.SessionId]
+		if !ok {
+			return errors.NewNotFound("clone session %d not found", entry.SessionId)
+		}
+		if err := send(cs); err != nil {
+			return err
+		}
+		return buffer.flush()
+	}
+	for _, cs := range pr.cloneSessions {
+		if err := send(cs); err != nil {
 			return err
 		}
 	}
 	return buffer.flush()
 }
 
+// ClonedPacket represents a copy of a packet produced by a clone session replica
+type ClonedPacket struct {
+	// EgressPort is the SDN port number via which the copy egresses
+	EgressPort uint32
+	// Instance is the replica instance, distinguishing copies egressing via the same port
+	Instance uint32
+	// ClassOfService is the class of service of the clone session, selecting the egress queue
+	ClassOfService uint32
+	// Packet is the packet copy, truncated to the packet length of the clone session, if any
+	Packet []byte
+}
+
+// Clone produces the copies of the given packet for each replica of the specified clone session, truncated to the
+// session packet length; packet length of 0 disables truncation
+func (pr *PacketReplication) Clone(sessionID uint32, packet []byte) ([]*ClonedPacket, error) {
+	cs, ok := pr.cloneSessions[sessionID]
+	if !ok {
+		return nil, errors.NewNotFound("clone session %d not found", sessionID)
+	}
+	length := len(packet)
+	if cs.PacketLengthBytes > 0 && int(cs.PacketLengthBytes) < length {
+		length = int(cs.PacketLengthBytes)
+	}
+	clones := make([]*ClonedPacket, 0, len(cs.Replicas))
+	for _, replica := range cs.Replicas {
+		clones = append(clones, &ClonedPacket{
+			EgressPort:     replica.EgressPort,
+			Instance:       replica.Instance,
+			ClassOfService: cs.ClassOfService,
+			Packet:         append([]byte(nil), packet[:length]...),
+		})
+	}
+	return clones, nil
+}
+
 // DeleteCloneSessionEntry deletes the specified close session entry
 func (pr *PacketReplication) DeleteCloneSessionEntry(entry *p4api.CloneSessionEntry) error {
 	delete(pr.cloneSessions, entry.SessionId)
 	return nil
 }
 
+// EntryCount returns the total number of multicast group and clone session entries
+func (pr *PacketReplication) EntryCount() int {
+	return len(pr.multicasts) + len(pr.cloneSessions)
+}
+
 // MulticastGroups returns list of multicast groups created in PRE
 func (pr *PacketReplication) MulticastGroups() []*p4api.MulticastGroupEntry {
 	groups := make([]*p4api.MulticastGroupEntry, 0, len(pr.multicasts))
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGroupUsage(t *testing.T) {
+	tables := newExactTables()
+	tables.SetActions(append(testActions,
+		&p4info.Action{Preamble: &p4info.Preamble{Id: 4, Name: "set_mcast_group_id"}, Params: []*p4info.Action_Param{{Id: 1, Name: "group_id", Bitwidth: 16}}}))
+	pre := NewPacketReplication()
+	tables.SetPacketReplication(pre)
+	for _, id := range []uint32{1, 2, 300} {
+		assert.NoError(t, pre.ModifyMulticastGroupEntry(&p4api.MulticastGroupEntry{MulticastGroupId: id}, true))
+	}
+
+	multicast := func(v1 byte, groupID uint16) *p4api.TableEntry {
+		entry := exactEntry(v1, 1)
+		entry.Action = &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{
+			ActionId: 4, Params: []*p4api.Action_Param{{ParamId: 1, Value: EncodeValue(uint64(groupID), 2, BigEndian)}}}}}
+		return entry
+	}
+	assert.NoError(t, tables.ModifyTableEntry(multicast(1, 1), true))
+	assert.NoError(t, tables.ModifyTableEntry(multicast(2, 1), true))
+	assert.NoError(t, tables.ModifyTableEntry(multicast(3, 300), true))
+	// Output port 1 is not multicast group 1
+	unicast := exactEntry(4, 1)
+	unicast.Action = directAction(1, 1)
+	assert.NoError(t, tables.ModifyTableEntry(unicast, true))
+
+	usage, err := pre.GroupUsage(1)
+	assert.NoError(t, err)
+	assert.Len(t, usage, 2)
+	for _, entry := range usage {
+		assert.Equal(t, uint32(4), entry.Action.GetAction().ActionId)
+	}
+
+	usage, err = pre.GroupUsage(300)
+	assert.NoError(t, err)
+	assert.Len(t, usage, 1)
+	assert.Equal(t, []byte{3}, usage[0].Match[0].GetExact().Value)
+
+	usage, err = pre.GroupUsage(2)
+	assert.NoError(t, err)
+	assert.Len(t, usage, 0)
+
+	_, err = pre.GroupUsage(4)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCloneSessionClassOfService(t *testing.T) {
+	pre := NewPacketReplication()
+	session := func(id uint32, cos uint32, ports ...uint32) *p4api.CloneSessionEntry {
+		entry := &p4api.CloneSessionEntry{SessionId: id, ClassOfService: cos}
+		for _, port := range ports {
+			entry.Replicas = append(entry.Replicas, &p4api.Replica{EgressPort: port})
+		}
+		return entry
+	}
+
+	// Without a queue configuration, ports have the default number of queues
+	assert.NoError(t, pre.ModifyCloneSessionEntry(session(1, DefaultEgressQueues-1, 1, 2), true))
+	err := pre.ModifyCloneSessionEntry(session(2, DefaultEgressQueues, 1), true)
+	assert.True(t, errors.IsInvalid(err))
+
+	// Class of service must map to a queue of every replica port
+	pre.SetEgressQueues(func(port uint32) uint32 {
+		if port == 2 {
+			return 2
+		}
+		return 4
+	})
+	assert.NoError(t, pre.ModifyCloneSessionEntry(session(2, 3, 1), true))
+	err = pre.ModifyCloneSessionEntry(session(3, 3, 1, 2), true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.NoError(t, pre.ModifyCloneSessionEntry(session(3, 1, 1, 2), true))
+
+	// Rejected modifies leave the session unchanged
+	err = pre.ModifyCloneSessionEntry(session(1, DefaultEgressQueues-1, 1, 2), false)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Len(t, pre.CloneSessions(), 3)
+	assert.Equal(t, uint32(DefaultEgressQueues-1), pre.cloneSessions[1].ClassOfService)
+}
+
+func TestMulticastGroupReplicas(t *testing.T) {
+	pre := NewPacketReplication()
+	group := func(groupID uint32, replicas ...*p4api.Replica) *p4api.MulticastGroupEntry {
+		return &p4api.MulticastGroupEntry{MulticastGroupId: groupID, Replicas: replicas}
+	}
+
+	// Replicas must be unique by port and instance
+	assert.True(t, errors.IsInvalid(pre.ModifyMulticastGroupEntry(group(1, &p4api.Replica{EgressPort: 1, Instance: 1},
+		&p4api.Replica{EgressPort: 1, Instance: 1}), true)))
+	assert.NoError(t, pre.ModifyMulticastGroupEntry(group(1, &p4api.Replica{EgressPort: 1, Instance: 1},
+		&p4api.Replica{EgressPort: 1, Instance: 2}, &p4api.Replica{EgressPort: 2, Instance: 1}), true))
+	assert.True(t, errors.IsAlreadyExists(pre.ModifyMulticastGroupEntry(group(1), true)))
+	assert.True(t, errors.IsNotFound(pre.ModifyMulticastGroupEntry(group(2), false)))
+	assert.True(t, errors.IsInvalid(pre.ModifyMulticastGroupEntry(group(1, &p4api.Replica{EgressPort: 3, Instance: 1},
+		&p4api.Replica{EgressPort: 3, Instance: 1}), false)))
+
+	// Packets are replicated once per replica
+	ports, err := pre.ReplicaPorts(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 1, 2}, ports)
+	_, err = pre.ReplicaPorts(2)
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.NoError(t, pre.DeleteMulticastGroupEntry(group(1)))
+	_, err = pre.ReplicaPorts(1)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCloneSessionReplicas(t *testing.T) {
+	pre := NewPacketReplication()
+	session := &p4api.CloneSessionEntry{SessionId: 1, ClassOfService: 2, PacketLengthBytes: 4, Replicas: []*p4api.Replica{
+		{EgressPort: 1, Instance: 1}, {EgressPort: 2, Instance: 1},
+	}}
+	duplicate := &p4api.CloneSessionEntry{SessionId: 2, Replicas: []*p4api.Replica{{EgressPort: 1}, {EgressPort: 1}}}
+	assert.True(t, errors.IsInvalid(pre.ModifyCloneSessionEntry(duplicate, true)))
+	assert.NoError(t, pre.ModifyCloneSessionEntry(session, true))
+	assert.NoError(t, pre.ModifyCloneSessionEntry(&p4api.CloneSessionEntry{SessionId: 3, Replicas: []*p4api.Replica{{EgressPort: 3}}}, true))
+
+	// Copies are produced for every replica, truncated to the session packet length
+	packet := []byte{1, 2, 3, 4, 5, 6}
+	clones, err := pre.Clone(1, packet)
+	assert.NoError(t, err)
+	assert.Len(t, clones, 2)
+	assert.Equal(t, &ClonedPacket{EgressPort: 1, Instance: 1, ClassOfService: 2, Packet: []byte{1, 2, 3, 4}}, clones[0])
+	assert.Equal(t, uint32(2), clones[1].EgressPort)
+	clones[0].Packet[0] = 9
+	assert.Equal(t, byte(1), packet[0])
+
+	// Sessions without a packet length clone the packet in full
+	clones, err = pre.Clone(3, packet)
+	assert.NoError(t, err)
+	assert.Equal(t, packet, clones[0].Packet)
+	_, err = pre.Clone(2, packet)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Reads of session 0 return all sessions
+	read := func(sessionID uint32) []uint32 {
+		ids := make([]uint32, 0)
+		assert.NoError(t, pre.ReadCloneSessionEntries(&p4api.CloneSessionEntry{SessionId: sessionID}, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				ids = append(ids, entity.GetPacketReplicationEngineEntry().GetCloneSessionEntry().SessionId)
+			}
+			return nil
+		}))
+		return ids
+	}
+	assert.ElementsMatch(t, []uint32{1, 3}, read(0))
+	assert.Equal(t, []uint32{3}, read(3))
+	err = pre.ReadCloneSessionEntries(&p4api.CloneSessionEntry{SessionId: 2}, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.NoError(t, pre.DeleteCloneSessionEntry(session))
+	assert.Equal(t, []uint32{3}, read(0))
+}
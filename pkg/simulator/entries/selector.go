@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// SelectionMode determines how action selector groups select a member for a flow
+type SelectionMode int
+
+const (
+	// DeterministicSelection selects members using a fixed hash, so that the same flow always selects the same
+	// member across runs; this is the default and suits reproducible tests
+	DeterministicSelection SelectionMode = iota
+	// NondeterministicSelection selects members using a hash salted by a seed, emulating the target-specific
+	// hashing of real devices, so that controllers cannot rely on a particular member being selected
+	NondeterministicSelection
+)
+
+// Member selection settings shared by all groups of a set of action profiles
+type memberSelector struct {
+	lock sync.RWMutex
+	mode SelectionMode
+	seed int64
+}
+
+// SetSelectionMode sets how the groups of all action profiles select a member for a flow; the seed salts the
+// hash in the nondeterministic mode and is ignored otherwise
+func (aps *ActionProfiles) SetSelectionMode(mode SelectionMode, seed int64) {
+	aps.selector.lock.Lock()
+	defer aps.selector.lock.Unlock()
+	aps.selector.mode = mode
+	aps.selector.seed = seed
+}
+
+// Produces the hash of the given flow key according to the selection mode
+func (s *memberSelector) hash(flow []byte) uint64 {
+	hf := fnv.New64a()
+	s.lock.RLock()
+	if s.mode == NondeterministicSelection {
+		seed := uint64(s.seed)
+		_, _ = hf.Write([]byte{byte(seed >> 56), byte(seed >> 48), byte(seed >> 40), byte(seed >> 32),
+			byte(seed >> 24), byte(seed >> 16), byte(seed >> 8), byte(seed)})
+	}
+	s.lock.RUnlock()
+	_, _ = hf.Write(flow)
+	return hf.Sum64()
+}
+
+// SelectMember selects the member of the group for the flow with the given key, e.g. its packed header fields,
+// with each member selected for a share of flows proportional to its weight; members with a weight below one are
+// treated as having a weight of one. Returns false if the group has no members.
+func (g *ActionProfileGroup) SelectMember(flow []byte) (uint32, bool) {
+	if g.entry == nil || len(g.entry.Members) == 0 {
+		return 0, false
+	}
+	weights := make([]int32, 0, len(g.entry.Members))
+	for _, m := range g.entry.Members {
+		weights = append(weights, m.Weight)
+	}
+	i, ok := selectWeighted(g.selector, weights, flow)
+	if !ok {
+		return 0, false
+	}
+	return g.entry.Members[i].MemberId, true
+}
+
+// Selects the index of the weight for the flow with the given key, with each index selected for a share of flows
+// proportional to its weight; uses deterministic selection if no selector is given. Returns false if there are no
+// weights.
+func selectWeighted(selector *memberSelector, weights []int32, flow []byte) (int, bool) {
+	if len(weights) == 0 {
+		return 0, false
+	}
+	total := uint64(0)
+	for _, w := range weights {
+		total += memberWeight(w)
+	}
+	if selector == nil {
+		selector = &memberSelector{}
+	}
+	bucket := selector.hash(flow) % total
+	for i, w := range weights {
+		weight := memberWeight(w)
+		if bucket < weight {
+			return i, true
+		}
+		bucket -= weight
+	}
+	return 0, false
+}
+
+// Returns the effective weight of a group member
+func memberWeight(weight int32) uint64 {
+	if weight < 1 {
+		return 1
+	}
+	return uint64(weight)
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+// SetSlotWidth sets the width, in bits, of the hardware slots in which the table entries are stored; entries whose
+// matches are wider than one slot consume several slots of the table size budget, e.g. wide ternary matches
+// spanning multiple TCAM slices. Width of 0 disables the slot model, making every entry consume a single slot;
+// this is the default. The slot cost of existing entries is recomputed.
+func (t *Table) SetSlotWidth(bits int32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.slotWidth = bits
+	t.slotsUsed = 0
+	for _, row := range t.rows {
+		row.slots = t.slotCost(row)
+		t.slotsUsed += row.slots
+	}
+}
+
+// SlotsUsed returns the number of slots of the table size budget consumed by the table entries
+func (t *Table) SlotsUsed() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.slotsUsed
+}
+
+// Returns the number of slots needed to store the entry of the given row, based on the total width of the fields
+// it matches; every entry consumes at least one slot
+func (t *Table) slotCost(row *Row) int64 {
+	if t.slotWidth <= 0 {
+		return 1
+	}
+	width := int64(0)
+	for _, m := range row.entry.Match {
+		for _, field := range t.info.MatchFields {
+			if field.Id == m.FieldId {
+				width += int64(field.Bitwidth)
+			}
+		}
+	}
+	slots := (width + int64(t.slotWidth) - 1) / int64(t.slotWidth)
+	if slots < 1 {
+		return 1
+	}
+	return slots
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// Snapshot is an immutable copy of the entries of all tables, along with their direct resources
+type Snapshot struct {
+	keySalt []byte
+	tables  map[uint32]*tableSnapshot
+}
+
+// Copy of the rows of a single table
+type tableSnapshot struct {
+	rows           map[string]*Row
+	defaultRow     *Row
+	insertionOrder []string
+}
+
+// Snapshot returns a deep copy of the current state of all tables, which is unaffected by later mutations of
+// the tables and which may be restored any number of times
+func (ts *Tables) Snapshot() *Snapshot {
+	snapshot := &Snapshot{
+		keySalt: append([]byte(nil), ts.keySalt...),
+		tables:  make(map[uint32]*tableSnapshot, len(ts.tables)),
+	}
+	for id, table := range ts.tables {
+		snapshot.tables[id] = table.snapshot()
+	}
+	return snapshot
+}
+
+// Restore replaces the state of all tables with the state recorded in the given snapshot; the snapshot must have
+// been taken of the same set of tables, otherwise no table is changed
+func (ts *Tables) Restore(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return errors.NewInvalid("snapshot is nil")
+	}
+	if len(snapshot.tables) != len(ts.tables) {
+		return errors.NewInvalid("snapshot has %d tables; expected %d", len(snapshot.tables), len(ts.tables))
+	}
+	for id := range ts.tables {
+		if _, ok := snapshot.tables[id]; !ok {
+			return errors.NewInvalid("snapshot has no state for table %d", id)
+		}
+	}
+
+	ts.keySalt = append([]byte(nil), snapshot.keySalt...)
+	for id, table := range ts.tables {
+		table.restore(snapshot.tables[id])
+	}
+	return nil
+}
+
+// Returns copies of the current rows of the table
+func (t *Table) snapshot() *tableSnapshot {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pollCounters()
+	return &tableSnapshot{
+		rows:           copyRows(t.rows),
+		defaultRow:     copyRow(t.defaultRow),
+		insertionOrder: copyKeys(t.insertionOrder),
+	}
+}
+
+// Replaces the rows of the table with copies of the rows of the given snapshot
+func (t *Table) restore(snapshot *tableSnapshot) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.rows = copyRows(snapshot.rows)
+	t.defaultRow = copyRow(snapshot.defaultRow)
+	t.insertionOrder = copyKeys(snapshot.insertionOrder)
+	t.slotsUsed = 0
+	for _, row := range t.rows {
+		row.slots = t.slotCost(row)
+		t.slotsUsed += row.slots
+	}
+	t.stale = nil
+	t.byPriority = nil
+	t.keyGeneration++
+	t.mutated()
+}
+
+// Returns deep copies of the given rows
+func copyRows(rows map[string]*Row) map[string]*Row {
+	c := make(map[string]*Row, len(rows))
+	for key, row := range rows {
+		c[key] = row.checkpoint()
+	}
+	return c
+}
+
+// Returns a deep copy of the given row; nil if the row is nil
+func copyRow(row *Row) *Row {
+	if row == nil {
+		return nil
+	}
+	return row.checkpoint()
+}
+
+// Returns a copy of the given keys, preserving nil
+func copyKeys(keys []string) []string {
+	if keys == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(keys)), keys...)
+}
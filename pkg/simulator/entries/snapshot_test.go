@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(1, 1), 1), true))
+	assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(2, 2), 2), true))
+	assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{
+		TableEntry: exactEntry(1, 1),
+		Data:       &p4api.CounterData{PacketCount: 5},
+	}, false))
+	snapshot := tables.Snapshot()
+
+	// Mutate the live rows, including the protos shared with readers
+	assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(1, 1), 7), false))
+	assert.NoError(t, tables.RemoveTableEntry(exactEntry(2, 2)))
+	assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(3, 3), 3), true))
+	table.rows[mustKey(t, table, exactEntry(1, 1))].counterData.PacketCount = 100
+
+	assert.NoError(t, tables.Restore(snapshot))
+	outputs := func() map[byte]byte {
+		values := make(map[byte]byte)
+		for _, entry := range table.Entries() {
+			values[entry.Match[0].GetExact().Value[0]] = entry.Action.GetAction().Params[0].Value[0]
+		}
+		return values
+	}
+	assert.Equal(t, map[byte]byte{1: 1, 2: 2}, outputs())
+	assert.Equal(t, int64(5), table.rows[mustKey(t, table, exactEntry(1, 1))].counterData.PacketCount)
+
+	// Mutations after a restore do not leak into the snapshot, which can be restored again
+	assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(1, 1), 8), false))
+	table.rows[mustKey(t, table, exactEntry(1, 1))].entry.Action.GetAction().Params[0].Value[0] = 9
+	assert.NoError(t, tables.Restore(snapshot))
+	assert.Equal(t, map[byte]byte{1: 1, 2: 2}, outputs())
+
+	// Snapshots of other sets of tables are rejected
+	other := newLPMTables().Snapshot()
+	assert.True(t, errors.IsInvalid(tables.Restore(other)))
+	assert.True(t, errors.IsInvalid(tables.Restore(nil)))
+	assert.Equal(t, map[byte]byte{1: 1, 2: 2}, outputs())
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import "google.golang.org/grpc/codes"
+
+// TableStats represents table statistics
+type TableStats struct {
+	Entries int
+	// KeyCollisions is the number of times distinct entries were found to share the same key
+	KeyCollisions uint64
+	// InstallSuccesses is the number of successful inserts and modifies of entries
+	InstallSuccesses uint64
+	// InstallFailures is the number of failed inserts and modifies of entries, by error code
+	InstallFailures map[codes.Code]uint64
+	// InstallSuccessRate is the fraction of successful installs among the most recent ones; 0 if there were none
+	InstallSuccessRate float64
+}
+
+// TableOccupancy represents the fill level of a table
+type TableOccupancy struct {
+	Name string
+	// Entries is the number of entries, excluding the default entry
+	Entries int
+	// MaxSize is the maximum number of entries declared in P4Info
+	MaxSize int64
+	// Slots is the number of slots of the declared size consumed by the entries
+	Slots int64
+	// DefaultSet indicates whether a default entry has been programmed
+	DefaultSet bool
+}
+
+// Stats returns the occupancy of all tables, keyed by table ID
+func (ts *Tables) Stats() map[uint32]TableOccupancy {
+	stats := make(map[uint32]TableOccupancy, len(ts.tables))
+	for id, table := range ts.tables {
+		table.lock.RLock()
+		stats[id] = TableOccupancy{
+			Name:       table.Name(),
+			Entries:    len(table.rows),
+			MaxSize:    table.info.Size,
+			Slots:      table.slotsUsed,
+			DefaultSet: table.defaultRow != nil,
+		}
+		table.lock.RUnlock()
+	}
+	return stats
+}
+
+// EntryCount returns the total number of entries of all tables, excluding the default entries
+func (ts *Tables) EntryCount() int {
+	count := 0
+	for _, table := range ts.tables {
+		table.lock.RLock()
+		count += len(table.rows)
+		table.lock.RUnlock()
+	}
+	return count
+}
+
+// Stats returns the table statistics
+func (t *Table) Stats() TableStats {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	stats := TableStats{Entries: t.size(), KeyCollisions: t.keyCollisions}
+	t.installs.report(&stats)
+	return stats
+}
@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTablesStats(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "exact"}, Size: 1024, MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "empty"}, Size: 16},
+	})
+	for i := byte(0); i < 3; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{i}}}},
+		}}, true))
+	}
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+
+	assert.Equal(t, map[uint32]TableOccupancy{
+		1: {Name: "exact", Entries: 3, MaxSize: 1024, Slots: 3, DefaultSet: true},
+		2: {Name: "empty", Entries: 0, MaxSize: 16},
+	}, tables.Stats())
+}
@@ -6,37 +6,115 @@
 package entries
 
 import (
-	"crypto/sha1"
+	"fmt"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
 	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
 	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
 	"hash"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-//var log = logging.GetLogger("simulator", "entries")
+var log = logging.GetLogger("simulator", "entries")
 
 // BatchSender is an abstract function for returning batches of read entities
 type BatchSender func(entities []*p4api.Entity) error
 
 // Table represents a single P4 table
 type Table struct {
+	tables     *Tables
 	info       *p4info.Table
 	rows       map[string]*Row
 	defaultRow *Row
+	modifyMode ModifyMode
+	duplicates DuplicateMatchPolicy
+	ternaries  TernaryPolicy
+
+	overlapCheck bool
+
+	directCounter *p4info.DirectCounter
+	directMeter   *p4info.DirectMeter
+
+	counterReadFault *LatencyFault
+	cache            *readCache
+	finalCounters    FinalCounterReporter
+	insertionOrder   []string
+	lookupLatency    *LookupLatency
+	keyCollisions    uint64
+	misses           uint64
+	installs         installStats
+	installFault     *InstallFault
+
+	pollInterval time.Duration
+	pollEpoch    time.Time
+
+	readStaleness time.Duration
+	stale         map[string]*staleRow
+
+	slotWidth int32
+	slotsUsed int64
+
+	// lock guards the table rows and the state derived from them; reads which also update that state, e.g. by
+	// folding staged counters or filling the read cache, hold it exclusively like writes do
+	lock sync.RWMutex
+
+	dependencies    []TableDependency
+	dependencyStats DependencyStats
+
+	keyGeneration uint64
+	byPriority    []string
+
+	rowGeneration uint64
 }
 
-// Tables represents a set of P4 tables
+// FinalCounterReporter is an abstract function for reporting the final direct counter data of a removed entry
+type FinalCounterReporter func(entry *p4api.DirectCounterEntry)
+
+// Tables represents a set of P4 tables; each table guards its own state, while the set of tables and the
+// configuration shared by them, e.g. the actions and the key scheme, must not be changed concurrently with any
+// other use of the tables, as the device simulator ensures by holding its lock exclusively while doing so
 type Tables struct {
-	tables map[uint32]*Table
+	tables   map[uint32]*Table
+	byName   map[string]uint32
+	actions  map[uint32]*p4info.Action
+	profiles *ActionProfiles
+	clock    Clock
+	keySalt  []byte
+	keyHash  KeyHash
+
+	byteStringFormat ByteStringFormat
+	checkpoints      map[string]map[uint32]*Table
+	// checkpointLock guards the checkpoints; the recorded checkpoints themselves are never changed
+	checkpointLock sync.RWMutex
+
+	batching
 }
 
+// Clock is an abstract source of the current time
+type Clock func() time.Time
+
 // Row represents table row entry and its mutable direct resources
 type Row struct {
 	entry       *p4api.TableEntry
 	counterData *p4api.CounterData
 	meterConfig *p4api.MeterConfig
 	meterData   *p4api.MeterCounterData
+	modifiedAt  time.Time
+
+	lastHit      time.Time
+	idleNotified bool
+	hits         uint64
+	slots        int64
+
+	staged  *stagedCounter
+	buckets *rowBuckets
+
+	// generation identifies the insert which created the row; 0 for rows restored from copies
+	generation uint64
 }
 
 // ReadType specifies whether to read table entry, its direct counter or its direct meter
@@ -51,30 +129,171 @@ const (
 	ReadDirectMeter
 )
 
+// ModifyMode specifies how a modify of an entry whose match fields do not correspond to any existing entry is handled
+type ModifyMode byte
+
+const (
+	// ModifyStrict specifies that a modify of a non-existent entry results in a NotFound error
+	ModifyStrict ModifyMode = iota
+	// ModifyRekey specifies that a modify of a non-existent entry accompanied by a hint of the old entry
+	// is treated as a delete of the old entry followed by an insert of the new one
+	ModifyRekey
+)
+
+// DuplicateMatchPolicy specifies how multiple field matches of the same field within an entry are handled
+type DuplicateMatchPolicy byte
+
+const (
+	// DuplicateMatchReject specifies that entries with more than one match of the same field are rejected
+	DuplicateMatchReject DuplicateMatchPolicy = iota
+	// DuplicateMatchMerge specifies that identical matches of the same field are merged into one; entries
+	// with differing matches of the same field are still rejected
+	DuplicateMatchMerge
+)
+
+// TernaryPolicy specifies how ternary matches whose value has bits set outside of the mask are handled
+type TernaryPolicy byte
+
+const (
+	// TernaryCanonicalize specifies that value bits outside of the mask are cleared, so that entries differing only
+	// in such don't-care bits are one and the same entry
+	TernaryCanonicalize TernaryPolicy = iota
+	// TernaryReject specifies that entries with value bits set outside of the mask are rejected
+	TernaryReject
+)
+
 // NewTables creates a new set of tables from the given P4 info descriptor
 func NewTables(tablesInfo []*p4info.Table) *Tables {
 	ts := &Tables{
-		tables: make(map[uint32]*Table),
+		tables:  make(map[uint32]*Table),
+		actions: make(map[uint32]*p4info.Action),
+		clock:   time.Now,
+		keyHash: KeyHashSHA1,
 	}
 	for _, ti := range tablesInfo {
 		ts.tables[ti.Preamble.Id] = ts.NewTable(ti)
 	}
+	ts.indexNames()
 	return ts
 }
 
+// Rebuilds the index of table IDs by table name; names are unique within a pipeline
+func (ts *Tables) indexNames() {
+	ts.byName = make(map[string]uint32, len(ts.tables))
+	for id, table := range ts.tables {
+		if name := table.Name(); name != "" {
+			ts.byName[name] = id
+		}
+	}
+}
+
 // NewTable creates a new device table
 func (ts *Tables) NewTable(table *p4info.Table) *Table {
 	// Sort the fields into canonical order based on ID
 	sort.SliceStable(table.MatchFields, func(i, j int) bool { return table.MatchFields[i].Id < table.MatchFields[j].Id })
 	return &Table{
-		info: table,
-		rows: make(map[string]*Row),
+		tables: ts,
+		info:   table,
+		rows:   make(map[string]*Row),
+	}
+}
+
+// SetClock sets the clock used to timestamp table modifications; defaults to time.Now
+func (ts *Tables) SetClock(clock Clock) {
+	ts.clock = clock
+}
+
+// SetActions sets the P4 info actions used to interpret table entry actions
+func (ts *Tables) SetActions(actions []*p4info.Action) {
+	ts.actions = make(map[uint32]*p4info.Action, len(actions))
+	for _, action := range actions {
+		ts.actions[action.Preamble.Id] = action
+	}
+}
+
+// SetActionProfiles sets the action profiles used to validate and resolve indirect table actions
+func (ts *Tables) SetActionProfiles(profiles *ActionProfiles) {
+	ts.profiles = profiles
+	if profiles != nil {
+		profiles.tables = ts
+	}
+}
+
+// SetPacketReplication sets the packet replication engine whose multicast groups the table entries may target
+func (ts *Tables) SetPacketReplication(pre *PacketReplication) {
+	if pre != nil {
+		pre.tables = ts
+	}
+}
+
+// BindDirectResources associates the given direct counters and meters with the tables they are declared for
+func (ts *Tables) BindDirectResources(counters []*p4info.DirectCounter, meters []*p4info.DirectMeter) {
+	directCounters := make(map[uint32]*p4info.DirectCounter, len(counters))
+	for _, dc := range counters {
+		directCounters[dc.DirectTableId] = dc
+	}
+	directMeters := make(map[uint32]*p4info.DirectMeter, len(meters))
+	for _, dm := range meters {
+		directMeters[dm.DirectTableId] = dm
+	}
+	for id, table := range ts.tables {
+		table.lock.Lock()
+		table.directCounter = directCounters[id]
+		table.directMeter = directMeters[id]
+		table.lock.Unlock()
+	}
+}
+
+// Reconfigure updates the tables using the specified P4 info; tables that are no longer declared are dropped,
+// newly declared tables are created and any direct resource data no longer backed by the P4 info is cleared
+func (ts *Tables) Reconfigure(info *p4info.P4Info) {
+	tables := make(map[uint32]*Table, len(info.Tables))
+	for _, ti := range info.Tables {
+		if table, ok := ts.tables[ti.Preamble.Id]; ok {
+			sort.SliceStable(ti.MatchFields, func(i, j int) bool { return ti.MatchFields[i].Id < ti.MatchFields[j].Id })
+			table.lock.Lock()
+			table.info = ti
+			table.lock.Unlock()
+			tables[ti.Preamble.Id] = table
+		} else {
+			tables[ti.Preamble.Id] = ts.NewTable(ti)
+		}
+	}
+	ts.tables = tables
+	ts.indexNames()
+	ts.BindDirectResources(info.DirectCounters, info.DirectMeters)
+	for _, table := range ts.tables {
+		table.dropOrphanedDirectResources()
+	}
+}
+
+// Clears any direct counter or meter data of the table rows which is not backed by the table direct resources
+func (t *Table) dropOrphanedDirectResources() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	rows := make([]*Row, 0, len(t.rows)+1)
+	for _, row := range t.rows {
+		rows = append(rows, row)
+	}
+	if t.defaultRow != nil {
+		rows = append(rows, t.defaultRow)
+	}
+	for _, row := range rows {
+		if t.directCounter == nil && row.counterData != nil {
+			row.counterData = nil
+		}
+		if t.directMeter == nil && (row.meterConfig != nil || row.meterData != nil) {
+			row.meterConfig = nil
+			row.meterData = nil
+		}
 	}
+	t.mutated()
 }
 
 // Creates a new table row from the specified table entry
 func (t *Table) newRow(entry *p4api.TableEntry) *Row {
-	row := &Row{entry: entry, meterConfig: entry.MeterConfig, counterData: &p4api.CounterData{}}
+	now := t.tables.clock()
+	row := &Row{entry: entry, meterConfig: entry.MeterConfig, counterData: &p4api.CounterData{}, modifiedAt: now, lastHit: now}
 	if entry.CounterData != nil {
 		row.counterData = entry.CounterData
 	}
@@ -93,6 +312,40 @@ func (ts *Tables) Tables() []*Table {
 	return tables
 }
 
+// Returns the tables ordered by ID
+func (ts *Tables) orderedTables() []*Table {
+	tables := ts.Tables()
+	sort.Slice(tables, func(i, j int) bool { return tables[i].ID() < tables[j].ID() })
+	return tables
+}
+
+// TablesWithDefault returns the tables, ordered by ID, which have a default action, either programmed or const
+func (ts *Tables) TablesWithDefault() []*Table {
+	return ts.tablesByDefault(true)
+}
+
+// TablesWithoutDefault returns the tables, ordered by ID, which have neither a programmed nor a const default action
+func (ts *Tables) TablesWithoutDefault() []*Table {
+	return ts.tablesByDefault(false)
+}
+
+// Returns the tables, ordered by ID, which have or lack a default action, as specified
+func (ts *Tables) tablesByDefault(present bool) []*Table {
+	tables := make([]*Table, 0, len(ts.tables))
+	for _, table := range ts.tables {
+		if table.HasDefaultAction() == present {
+			tables = append(tables, table)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].ID() < tables[j].ID() })
+	return tables
+}
+
+// HasDefaultAction returns true if the table has a programmed or a const default action
+func (t *Table) HasDefaultAction() bool {
+	return t.defaultRow != nil || t.info.ConstDefaultActionId != 0
+}
+
 // ModifyTableEntry modifies the specified table entry in its appropriate table
 func (ts *Tables) ModifyTableEntry(entry *p4api.TableEntry, insert bool) error {
 	table, ok := ts.tables[entry.TableId]
@@ -102,7 +355,18 @@ func (ts *Tables) ModifyTableEntry(entry *p4api.TableEntry, insert bool) error {
 	return table.ModifyTableEntry(entry, insert)
 }
 
-// RemoveTableEntry removes the specified table entry from its appropriate table
+// ModifyTableEntryWithHint modifies the specified table entry in its appropriate table, using the given old
+// entry as a re-keying hint
+func (ts *Tables) ModifyTableEntryWithHint(entry *p4api.TableEntry, oldEntry *p4api.TableEntry) error {
+	table, ok := ts.tables[entry.TableId]
+	if !ok {
+		return errors.NewNotFound("table %d not found", entry.TableId)
+	}
+	return table.ModifyTableEntryWithHint(entry, oldEntry)
+}
+
+// RemoveTableEntry removes the specified table entry from its appropriate table; an entry which does not address a
+// single entry of the table removes all entries matching it
 func (ts *Tables) RemoveTableEntry(entry *p4api.TableEntry) error {
 	table, ok := ts.tables[entry.TableId]
 	if !ok {
@@ -123,10 +387,19 @@ func (ts *Tables) ModifyDirectCounterEntry(entry *p4api.DirectCounterEntry, inse
 	return table.ModifyDirectCounterEntry(entry)
 }
 
+// ResetDirectCounterEntry resets the direct counter data of the specified entry in its appropriate table to zero
+func (ts *Tables) ResetDirectCounterEntry(entry *p4api.DirectCounterEntry) error {
+	table, ok := ts.tables[entry.TableEntry.GetTableId()]
+	if !ok {
+		return errors.NewNotFound("table %d not found", entry.TableEntry.GetTableId())
+	}
+	return table.ResetDirectCounterEntry(entry)
+}
+
 // ModifyDirectMeterEntry modifies the specified direct meter entry in its appropriate table
 func (ts *Tables) ModifyDirectMeterEntry(entry *p4api.DirectMeterEntry, insert bool) error {
 	if insert {
-		return errors.NewInvalid("direct counter entry cannot be inserted")
+		return errors.NewInvalid("direct meter entry cannot be inserted")
 	}
 	table, ok := ts.tables[entry.TableEntry.TableId]
 	if !ok {
@@ -137,9 +410,13 @@ func (ts *Tables) ModifyDirectMeterEntry(entry *p4api.DirectMeterEntry, insert b
 
 // ReadTableEntries reads the table entries matching the specified table entry, from the appropriate table
 func (ts *Tables) ReadTableEntries(request *p4api.TableEntry, readType ReadType, sender BatchSender) error {
-	// If the table ID is 0, read all tables
+	// If the table ID is 0, read all tables, ordered by ID
 	if request.TableId == 0 {
-		for _, table := range ts.tables {
+		for _, table := range ts.orderedTables() {
+			// Skip tables which do not have the requested direct resources
+			if table.validateReadType(readType) != nil {
+				continue
+			}
 			if err := table.ReadTableEntries(request, readType, sender); err != nil {
 				return err
 			}
@@ -160,6 +437,15 @@ func (ts *Tables) Table(id uint32) *Table {
 	return ts.tables[id]
 }
 
+// TableByName returns the table with the specified P4 name; returns nil if there is no such table
+func (ts *Tables) TableByName(name string) *Table {
+	id, ok := ts.byName[name]
+	if !ok {
+		return nil
+	}
+	return ts.tables[id]
+}
+
 // ID returns the table ID
 func (t *Table) ID() uint32 {
 	return t.info.Preamble.Id
@@ -167,6 +453,13 @@ func (t *Table) ID() uint32 {
 
 // Size returns the number of entries in the table
 func (t *Table) Size() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size()
+}
+
+// Returns the number of entries, including the default entry, if any
+func (t *Table) size() int {
 	if t.defaultRow != nil {
 		return len(t.rows) + 1
 	}
@@ -178,10 +471,32 @@ func (t *Table) Name() string {
 	return t.info.Preamble.Name
 }
 
-// Entries returns a copy of the table entries; in no particular order
+// MatchFieldDesc describes a table match field
+type MatchFieldDesc struct {
+	ID        uint32
+	Name      string
+	MatchType p4info.MatchField_MatchType
+	Bitwidth  int32
+}
+
+// MatchFieldOrder returns descriptors of the table match fields in the canonical order expected by the simulator,
+// i.e. sorted by field ID
+func (t *Table) MatchFieldOrder() []MatchFieldDesc {
+	fields := make([]MatchFieldDesc, 0, len(t.info.MatchFields))
+	for _, field := range t.info.MatchFields {
+		fields = append(fields, MatchFieldDesc{ID: field.Id, Name: field.Name, MatchType: field.GetMatchType(), Bitwidth: field.Bitwidth})
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].ID < fields[j].ID })
+	return fields
+}
+
+// Entries returns a copy of the table entries, ordered by descending priority and then by their keys, followed by
+// the default entry, if any
 func (t *Table) Entries() []*p4api.TableEntry {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
 	entries := make([]*p4api.TableEntry, 0, len(t.rows))
-	for _, row := range t.rows {
+	for _, row := range t.orderedRows() {
 		entries = append(entries, row.entry)
 	}
 	if t.defaultRow != nil {
@@ -190,8 +505,196 @@ func (t *Table) Entries() []*p4api.TableEntry {
 	return entries
 }
 
+// A row along with its key
+type keyedRow struct {
+	key string
+	row *Row
+}
+
+// Returns the table rows, excluding the default row, ordered by descending priority and then by their keys, so that
+// repeated reads of an unchanged table yield the entries in the same order
+func (t *Table) orderedRows() []*Row {
+	keyed := t.orderedKeyedRows()
+	rows := make([]*Row, 0, len(keyed))
+	for _, kr := range keyed {
+		rows = append(rows, kr.row)
+	}
+	return rows
+}
+
+// Returns the table rows along with their keys, in the same order as orderedRows
+func (t *Table) orderedKeyedRows() []keyedRow {
+	keyed := make([]keyedRow, 0, len(t.rows))
+	for key, row := range t.rows {
+		keyed = append(keyed, keyedRow{key: key, row: row})
+	}
+	sortRows(keyed)
+	return keyed
+}
+
+// Sorts the keyed rows by descending priority and then by their keys
+func sortRows(rows []keyedRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		pi, pj := rows[i].row.entry.Priority, rows[j].row.entry.Priority
+		if pi != pj {
+			return pi > pj
+		}
+		return rows[i].key < rows[j].key
+	})
+}
+
+// EntryGeneration returns the generation of the entry addressed by the given entry, which identifies the insert
+// which created it, so that an entry which was removed and inserted again can be told apart; false if there is no
+// such entry
+func (t *Table) EntryGeneration(entry *p4api.TableEntry) (uint64, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	entry = proto.Clone(entry).(*p4api.TableEntry)
+	if err := t.canonicalizeMatches(entry); err != nil {
+		return 0, false
+	}
+	key, err := t.entryKey(entry)
+	if err != nil {
+		return 0, false
+	}
+	row, ok := t.rows[key]
+	if !ok || !sameMatches(row.entry.Match, entry.Match) {
+		return 0, false
+	}
+	return row.generation, true
+}
+
+// SetDuplicateMatchPolicy sets how multiple matches of the same field within an entry are handled;
+// DuplicateMatchReject is the default
+func (t *Table) SetDuplicateMatchPolicy(policy DuplicateMatchPolicy) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.duplicates = policy
+}
+
+// SetTernaryPolicy sets how ternary matches with value bits set outside of the mask are handled;
+// TernaryCanonicalize is the default
+func (t *Table) SetTernaryPolicy(policy TernaryPolicy) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.ternaries = policy
+}
+
+// Orders the entry field matches in canonical order based on field ID, handling any duplicate matches of the same
+// field according to the duplicate match policy and any ternary don't-care value bits according to the ternary policy
+func (t *Table) canonicalizeMatches(entry *p4api.TableEntry) error {
+	for _, m := range entry.Match {
+		if err := t.canonicalizeTernary(m); err != nil {
+			return err
+		}
+	}
+	sortFieldMatches(entry.Match)
+	for i := 1; i < len(entry.Match); i++ {
+		if entry.Match[i].FieldId != entry.Match[i-1].FieldId {
+			continue
+		}
+		if t.duplicates != DuplicateMatchMerge || !proto.Equal(entry.Match[i], entry.Match[i-1]) {
+			return errors.NewInvalid("field %d of table %s is matched more than once", entry.Match[i].FieldId, t.Name())
+		}
+		entry.Match = append(entry.Match[:i], entry.Match[i+1:]...)
+		i--
+	}
+	return nil
+}
+
+// Clears the value bits of the ternary match which are outside of its mask, or rejects the match if the table
+// uses the TernaryReject policy; values whose length differs from that of the mask are left to the schema validation
+func (t *Table) canonicalizeTernary(m *p4api.FieldMatch) error {
+	ternary := m.GetTernary()
+	if ternary == nil || len(ternary.Value) != len(ternary.Mask) {
+		return nil
+	}
+	var value []byte
+	for i, b := range ternary.Value {
+		if b&^ternary.Mask[i] == 0 {
+			continue
+		}
+		if t.ternaries == TernaryReject {
+			return errors.NewInvalid("value of field %d of table %s has bits set outside of its mask: %v",
+				m.FieldId, t.Name(), ternary)
+		}
+		if value == nil {
+			// Copy the value, as it may be shared with the caller
+			value = append([]byte(nil), ternary.Value...)
+		}
+		value[i] &= ternary.Mask[i]
+	}
+	if value != nil {
+		ternary.Value = value
+	}
+	return nil
+}
+
+// SetModifyMode sets how modifies of entries with changed match fields are handled; ModifyStrict is the default
+func (t *Table) SetModifyMode(mode ModifyMode) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.modifyMode = mode
+}
+
+// SetCounterReadFault sets the latency fault to be injected into direct counter reads; nil disables the fault
+func (t *Table) SetCounterReadFault(fault *LatencyFault) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.counterReadFault = fault
+}
+
+// SetFinalCounterReporter sets the function to be called with the final direct counter data of each entry
+// just before the entry is removed; nil disables the reporting
+func (t *Table) SetFinalCounterReporter(reporter FinalCounterReporter) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.finalCounters = reporter
+}
+
+// ModifyTableEntryWithHint modifies the specified entry; if the entry does not exist and the table is in
+// ModifyRekey mode, the given old entry is removed and the new entry inserted in its place
+func (t *Table) ModifyTableEntryWithHint(entry *p4api.TableEntry, oldEntry *p4api.TableEntry) error {
+	return t.install(func() error { return t.modifyTableEntryWithHint(entry, oldEntry) })
+}
+
+func (t *Table) modifyTableEntryWithHint(entry *p4api.TableEntry, oldEntry *p4api.TableEntry) error {
+	err := t.modifyTableEntry(entry, false)
+	if err == nil || !errors.IsNotFound(err) || t.modifyMode != ModifyRekey || oldEntry == nil {
+		return err
+	}
+
+	// Make sure the old entry actually exists before re-keying it; the hint is canonicalized as inserts are, so
+	// that it yields the key of the stored entry
+	oldEntry = proto.Clone(oldEntry).(*p4api.TableEntry)
+	if cerr := t.canonicalizeMatches(oldEntry); cerr != nil {
+		return cerr
+	}
+	oldKey, kerr := t.entryKey(oldEntry)
+	if kerr != nil {
+		return kerr
+	}
+	row, ok := t.row(oldKey, oldEntry)
+	if !ok {
+		return err
+	}
+
+	t.removeRow(oldKey)
+	if err = t.modifyTableEntry(entry, true); err != nil {
+		// Put the old entry back if the new one could not be inserted
+		t.addRow(oldKey, row)
+		return err
+	}
+	t.reportFinalCounters(row)
+	return nil
+}
+
 // ModifyTableEntry inserts or modifies the specified entry
 func (t *Table) ModifyTableEntry(entry *p4api.TableEntry, insert bool) error {
+	return t.install(func() error { return t.modifyTableEntry(entry, insert) })
+}
+
+func (t *Table) modifyTableEntry(entry *p4api.TableEntry, insert bool) error {
 	if entry.IsDefaultAction {
 		if insert {
 			return errors.NewInvalid("unable to insert default action entry")
@@ -199,19 +702,46 @@ func (t *Table) ModifyTableEntry(entry *p4api.TableEntry, insert bool) error {
 		if len(entry.Match) > 0 {
 			return errors.NewInvalid("default action entry cannot have any match fields")
 		}
+		// A default entry without an action resets the default action to the const default action, if any
+		if entry.Action == nil {
+			t.defaultRow = nil
+			t.mutated()
+			return nil
+		}
+		if err := t.validateDefaultAction(entry.Action.GetAction()); err != nil {
+			return err
+		}
+		if err := t.validateProfileAction(entry.Action); err != nil {
+			return err
+		}
 		t.defaultRow = t.newRow(entry)
+		t.mutated()
 		return nil
 	}
 
 	// Order field matches in canonical order based on field ID
-	sortFieldMatches(entry.Match)
+	if err := t.canonicalizeMatches(entry); err != nil {
+		return err
+	}
+	if err := t.validateExactMatches(entry); err != nil {
+		return err
+	}
+	if err := t.validatePriority(entry); err != nil {
+		return err
+	}
+	if err := t.validateEntryAction(entry.Action.GetAction()); err != nil {
+		return err
+	}
+	if err := t.validateProfileAction(entry.Action); err != nil {
+		return err
+	}
 
 	// Produce a hash of the priority and the field matches to serve as a key
 	key, err := t.entryKey(entry)
 	if err != nil {
 		return err
 	}
-	row, ok := t.rows[key]
+	row, ok := t.row(key, entry)
 
 	// If the entry exists, and we're supposed to do a new insert, raise error
 	if ok && insert {
@@ -225,40 +755,276 @@ func (t *Table) ModifyTableEntry(entry *p4api.TableEntry, insert bool) error {
 
 	// If the entry doesn't exist and we're supposed to do insert, well... do it
 	if !ok && insert {
+		if _, taken := t.rows[key]; taken {
+			return errors.NewConflict("entry key collides with another entry: %v", entry)
+		}
+		if err := t.validateNoOverlap(entry); err != nil {
+			return err
+		}
+		// The default entry does not count against the declared table size, which is consumed by the entries
+		// according to their slot cost
 		row = t.newRow(entry)
-		t.rows[key] = row
+		if t.info.Size > 0 && t.slotsUsed+t.slotCost(row) > t.info.Size {
+			return NewResourceExhausted("resource exhausted: %v", entry)
+		}
+		t.rowGeneration++
+		row.generation = t.rowGeneration
+		t.addRow(key, row)
 	}
 
 	// Otherwise, update the entry and its direct resources
+	if !insert {
+		t.recordWrite(key)
+	}
 	row.entry = entry
 	row.meterConfig = entry.MeterConfig
+	row.modifiedAt = t.tables.clock()
 
 	// If this is an update and counter data has been given, update it
 	if !insert && entry.CounterData != nil {
 		row.counterData = entry.CounterData
 	}
+	t.checkInstallOrder(entry)
+	t.mutated()
+	return nil
+}
+
+// Validates that the given direct action can be used as the table default action, i.e. that it is among the table
+// action refs and is not restricted to table entries, and that its parameters match the action signature; tables
+// which declare no action refs accept any action
+func (t *Table) validateDefaultAction(action *p4api.Action) error {
+	if action == nil {
+		return nil
+	}
+	if len(t.info.ActionRefs) == 0 {
+		return t.tables.validateActionParams(action)
+	}
+	for _, ref := range t.info.ActionRefs {
+		if ref.Id == action.ActionId {
+			if ref.Scope == p4info.ActionRef_TABLE_ONLY {
+				return errors.NewInvalid("action %d cannot be the default action of table %s", action.ActionId, t.Name())
+			}
+			return t.tables.validateActionParams(action)
+		}
+	}
+	return errors.NewInvalid("action %d is not an action of table %s", action.ActionId, t.Name())
+}
+
+// DefaultEntry returns the programmed default entry; if none has been programmed, returns an entry with the const
+// default action declared in the P4 info, or nil if the table declares no const default action
+func (t *Table) DefaultEntry() *p4api.TableEntry {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.defaultEntry()
+}
+
+// Returns the programmed default entry or the synthesized const default entry; nil if the table has neither
+func (t *Table) defaultEntry() *p4api.TableEntry {
+	if t.defaultRow != nil {
+		return t.defaultRow.entry
+	}
+	if t.info.ConstDefaultActionId != 0 {
+		return &p4api.TableEntry{
+			TableId:         t.ID(),
+			IsDefaultAction: true,
+			Action:          &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{ActionId: t.info.ConstDefaultActionId}}},
+		}
+	}
 	return nil
 }
 
+// DefaultActionOverridesConst returns whether the programmed default action differs from the const default action
+// declared in the P4 info, along with the programmed default action, if any, and the const default action, if any
+func (t *Table) DefaultActionOverridesConst() (bool, *p4api.Action, *p4api.Action) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	var programmed, constDefault *p4api.Action
+	if t.defaultRow != nil {
+		programmed = t.resolveAction(t.defaultRow.entry.Action)
+	}
+	if t.info.ConstDefaultActionId != 0 {
+		constDefault = &p4api.Action{ActionId: t.info.ConstDefaultActionId}
+	}
+	if programmed == nil {
+		return false, nil, constDefault
+	}
+	return constDefault == nil || programmed.ActionId != constDefault.ActionId, programmed, constDefault
+}
+
 // RemoveTableEntry removes the specified table entry and any direct counter data and meter configs for that entry
 func (t *Table) RemoveTableEntry(entry *p4api.TableEntry) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 	if entry.IsDefaultAction {
 		return errors.NewInvalid("unable to remove default action entry")
 	}
+	if t.IsWildcard(entry) {
+		_, err := t.removeMatchingEntries(entry)
+		return err
+	}
 	// Order field matches in canonical order based on field ID
-	sortFieldMatches(entry.Match)
+	if err := t.canonicalizeMatches(entry); err != nil {
+		return err
+	}
 
 	// Produce a hash of the priority and the field matches to serve as a key
 	key, err := t.entryKey(entry)
 	if err != nil {
 		return err
 	}
-	delete(t.rows, key)
+	row, ok := t.row(key, entry)
+	if !ok {
+		return nil
+	}
+	t.deleteRow(key, row)
+	return nil
+}
+
+// Removes the given row, reporting its final counter values if requested
+func (t *Table) deleteRow(key string, row *Row) {
+	t.reportFinalCounters(row)
+	t.removeRow(key)
+}
+
+// Reports the final counter values of the given row, which is being removed, if requested
+func (t *Table) reportFinalCounters(row *Row) {
+	if t.finalCounters != nil && row.counterData != nil {
+		t.finalCounters(&p4api.DirectCounterEntry{TableEntry: row.entry, Data: t.directCounterData(row.counterData)})
+	}
+}
+
+// SetKeySalt sets the salt mixed into the entry keys of all tables, e.g. to scope the keys to a device; the keys of
+// any existing entries are recomputed
+func (ts *Tables) SetKeySalt(salt []byte) error {
+	oldSalt := ts.keySalt
+	ts.keySalt = salt
+	return ts.rekey(func() { ts.keySalt = oldSalt })
+}
+
+// Recomputes the keys of the entries of all tables; if the keys of any table collide, the given function is
+// used to restore the previous key scheme and the previous keys are recomputed
+func (ts *Tables) rekey(restore func()) error {
+	for _, table := range ts.tables {
+		if err := table.rekey(); err != nil {
+			restore()
+			for _, t := range ts.tables {
+				_ = t.rekey()
+			}
+			return err
+		}
+	}
 	return nil
 }
 
+// Recomputes the keys of all the table rows, preserving the insertion order, if tracked
+func (t *Table) rekey() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	rows := make(map[string]*Row, len(t.rows))
+	keys := make(map[string]string, len(t.rows))
+	for oldKey, row := range t.rows {
+		key, err := t.entryKey(row.entry)
+		if err != nil {
+			return err
+		}
+		if _, ok := rows[key]; ok {
+			t.collision(row.entry)
+			return errors.NewConflict("entry keys of table %s collide", t.Name())
+		}
+		rows[key] = row
+		keys[oldKey] = key
+	}
+	t.rows = rows
+	t.stale = nil
+	t.keyGeneration++
+	t.byPriority = nil
+	for i, oldKey := range t.insertionOrder {
+		t.insertionOrder[i] = keys[oldKey]
+	}
+	t.mutated()
+	return nil
+}
+
+// Adds the row under the given key, recording its position in the insertion order, if tracked
+func (t *Table) addRow(key string, row *Row) {
+	t.recordWrite(key)
+	t.rows[key] = row
+	t.indexPriority(key)
+	row.slots = t.slotCost(row)
+	t.slotsUsed += row.slots
+	if t.insertionOrder != nil {
+		t.insertionOrder = append(t.insertionOrder, key)
+	}
+	t.mutated()
+}
+
+// Removes the row with the given key, compacting the insertion order, if tracked
+func (t *Table) removeRow(key string) {
+	row, ok := t.rows[key]
+	if !ok {
+		return
+	}
+	t.recordWrite(key)
+	t.unindexPriority(key)
+	delete(t.rows, key)
+	t.slotsUsed -= row.slots
+	if t.insertionOrder != nil {
+		for i, k := range t.insertionOrder {
+			if k == key {
+				t.insertionOrder = append(t.insertionOrder[:i], t.insertionOrder[i+1:]...)
+				break
+			}
+		}
+	}
+	t.mutated()
+}
+
+// EnableInsertionOrder enables tracking of the order in which entries are inserted; entries already present
+// are recorded in no particular order
+func (t *Table) EnableInsertionOrder() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.insertionOrder != nil {
+		return
+	}
+	t.insertionOrder = make([]string, 0, len(t.rows))
+	for key := range t.rows {
+		t.insertionOrder = append(t.insertionOrder, key)
+	}
+}
+
+// ReadInInsertionOrder reads the table entries matching the specified request in the order in which they were
+// inserted, followed by the default entry, if any; requires insertion order tracking to be enabled
+func (t *Table) ReadInInsertionOrder(request *p4api.TableEntry, readType ReadType, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.insertionOrder == nil {
+		return errors.NewInvalid("insertion order is not tracked for table %s", t.Name())
+	}
+	buffer := t.tables.newBuffer(sender)
+	for _, key := range t.insertionOrder {
+		if row := t.rows[key]; t.tableEntryMatches(request, row.entry) {
+			if err := buffer.sendEntity(t.getEntry(readType, row)); err != nil {
+				return err
+			}
+		}
+	}
+	if t.defaultRow != nil {
+		if err := buffer.sendEntity(t.getEntry(readType, t.defaultRow)); err != nil {
+			return err
+		}
+	}
+	return buffer.flush()
+}
+
 // ModifyDirectCounterEntry modifies the specified direct counter entry data
 func (t *Table) ModifyDirectCounterEntry(entry *p4api.DirectCounterEntry) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.modifyDirectCounterEntry(entry)
+}
+
+func (t *Table) modifyDirectCounterEntry(entry *p4api.DirectCounterEntry) error {
 	// Order field matches in canonical order based on field ID
 	sortFieldMatches(entry.TableEntry.Match)
 
@@ -267,16 +1033,77 @@ func (t *Table) ModifyDirectCounterEntry(entry *p4api.DirectCounterEntry) error
 	if err != nil {
 		return err
 	}
-	row, ok := t.rows[key]
+	row, ok := t.row(key, entry.TableEntry)
 	if !ok {
 		return errors.NewNotFound("entry doesn't exist: %v", entry)
 	}
-	row.counterData = entry.Data
+	row.setCounterData(entry.Data)
+	t.mutated()
 	return nil
 }
 
+// ResetDirectCounterEntry resets the direct counter data of the specified entry to zero, regardless of any data
+// given in the direct counter entry
+func (t *Table) ResetDirectCounterEntry(entry *p4api.DirectCounterEntry) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.directCounter == nil {
+		return errors.NewInvalid("table %s has no direct counter", t.Name())
+	}
+	if entry.TableEntry == nil {
+		return errors.NewInvalid("direct counter entry has no table entry")
+	}
+	return t.modifyDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: entry.TableEntry})
+}
+
+// Sets the row counter data, discarding any staged increments; nil data zeroes the counter, as real targets do
+// on a modify with empty data
+func (r *Row) setCounterData(data *p4api.CounterData) {
+	if data == nil {
+		data = &p4api.CounterData{}
+	}
+	r.counterData = data
+	r.staged = nil
+}
+
+// BulkModifyDirectCounters modifies the data of all the specified direct counter entries in a single pass,
+// skipping entries whose table entries do not exist; returns the skipped entries
+func (t *Table) BulkModifyDirectCounters(entries []*p4api.DirectCounterEntry) ([]*p4api.DirectCounterEntry, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.directCounter == nil {
+		return nil, errors.NewInvalid("table %s has no direct counter", t.Name())
+	}
+	skipped := make([]*p4api.DirectCounterEntry, 0)
+	modified := false
+	for _, entry := range entries {
+		if entry.TableEntry == nil {
+			skipped = append(skipped, entry)
+			continue
+		}
+		sortFieldMatches(entry.TableEntry.Match)
+		key, err := t.entryKey(entry.TableEntry)
+		if err != nil {
+			return nil, err
+		}
+		row, ok := t.row(key, entry.TableEntry)
+		if !ok {
+			skipped = append(skipped, entry)
+			continue
+		}
+		row.setCounterData(entry.Data)
+		modified = true
+	}
+	if modified {
+		t.mutated()
+	}
+	return skipped, nil
+}
+
 // ModifyDirectMeterEntry modifies the specified direct meter entry data
 func (t *Table) ModifyDirectMeterEntry(entry *p4api.DirectMeterEntry) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 	// Order field matches in canonical order based on field ID
 	sortFieldMatches(entry.TableEntry.Match)
 
@@ -285,22 +1112,54 @@ func (t *Table) ModifyDirectMeterEntry(entry *p4api.DirectMeterEntry) error {
 	if err != nil {
 		return err
 	}
-	row, ok := t.rows[key]
+	row, ok := t.row(key, entry.TableEntry)
 	if !ok {
 		return errors.NewNotFound("entry doesn't exist: %v", entry)
 	}
 	row.meterConfig = entry.Config
+	t.mutated()
 	return nil
 }
 
 type entityBuffer struct {
-	entities []*p4api.Entity
-	sender   BatchSender
+	entities   []*p4api.Entity
+	sender     BatchSender
+	bestEffort bool
+	errors     []error
+	sent       bool
 }
 
-func newBuffer(sender BatchSender) *entityBuffer {
+// DefaultBatchSize is the default number of entities sent to a batch sender per batch; it keeps batches of typical
+// entities well below the default gRPC message size limit of 4MB, while amortizing the per-message overhead
+const DefaultBatchSize = 64
+
+// Read batching settings of an entity store
+type batching struct {
+	batchSize int
+}
+
+// SetBatchSize sets the number of entities sent to the batch sender per batch by reads; 0 restores the default
+// batch size
+func (b *batching) SetBatchSize(size int) error {
+	if size < 0 {
+		return errors.NewInvalid("invalid batch size %d", size)
+	}
+	b.batchSize = size
+	return nil
+}
+
+// Creates an accumulation buffer for the given sender, flushing every batch size entities
+func (b *batching) newBuffer(sender BatchSender) *entityBuffer {
+	size := b.batchSize
+	if size == 0 {
+		size = DefaultBatchSize
+	}
+	return newBuffer(sender, size)
+}
+
+func newBuffer(sender BatchSender, size int) *entityBuffer {
 	return &entityBuffer{
-		entities: make([]*p4api.Entity, 0, 64),
+		entities: make([]*p4api.Entity, 0, size),
 		sender:   sender,
 	}
 }
@@ -317,104 +1176,559 @@ func (eb *entityBuffer) sendEntity(entity *p4api.Entity) error {
 	return err
 }
 
-// Flushes the buffer by sending the buffered entities and resets the buffer
+// Flushes the buffer by sending the buffered entities and resets the buffer; an empty buffer is only sent if
+// nothing has been sent yet, so that empty reads still yield a single batch. In best-effort mode, sender errors
+// are accumulated rather than returned.
 func (eb *entityBuffer) flush() error {
+	if len(eb.entities) == 0 && eb.sent {
+		return nil
+	}
 	err := eb.sender(eb.entities)
+	eb.sent = true
 	eb.entities = eb.entities[:0]
+	if err != nil && eb.bestEffort {
+		eb.errors = append(eb.errors, err)
+		return nil
+	}
 	return err
 }
 
+// BatchErrors represents the errors returned by the sender for batches of a best-effort read
+type BatchErrors []error
+
+// Error returns a summary of all the batch errors
+func (be BatchErrors) Error() string {
+	msgs := make([]string, 0, len(be))
+	for _, err := range be {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d batch(es) failed: %s", len(be), strings.Join(msgs, "; "))
+}
+
+// ReadTableEntriesBestEffort reads the table entries matching the specified request, continuing past any batches
+// the sender fails to accept; returns BatchErrors with all sender errors, if any
+func (t *Table) ReadTableEntriesBestEffort(request *p4api.TableEntry, readType ReadType, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	buffer := t.tables.newBuffer(sender)
+	buffer.bestEffort = true
+	if err := t.visitRows(request, func(row *Row) error {
+		return buffer.sendEntity(t.getEntry(readType, row))
+	}); err != nil {
+		return err
+	}
+	_ = buffer.flush()
+	if len(buffer.errors) > 0 {
+		return BatchErrors(buffer.errors)
+	}
+	return nil
+}
+
 // ReadTableEntries reads the table entries matching the specified table entry request
 func (t *Table) ReadTableEntries(request *p4api.TableEntry, readType ReadType, sender BatchSender) error {
-	// TODO: implement exact match
-	buffer := newBuffer(sender)
+	// Inject the counter read latency before taking the table lock, so that the delay does not stall writers
+	if readType == ReadDirectCounter {
+		t.lock.RLock()
+		fault := t.counterReadFault
+		t.lock.RUnlock()
+		fault.inject()
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.validateReadType(readType); err != nil {
+		return err
+	}
+
+	buffer := t.tables.newBuffer(sender)
+	t.pollCounters()
+
+	// If the request fully specifies an entry of an exact match table, look it up directly; the fast path and the
+	// read cache are skipped while writes are pending visibility
+	stale := t.settleWrites()
+	if row, ok := t.exactRow(request); ok && !stale {
+		if err := buffer.sendEntity(t.readEntity(request, readType, row)); err != nil {
+			return err
+		}
+		return buffer.flush()
+	}
+
+	// If the read cache is enabled, serve the read from the cache, unless the time since last hit is requested
+	if t.cache != nil && !stale && request.TimeSinceLastHit == nil {
+		entities, err := t.cachedEntities(request, readType)
+		if err != nil {
+			return err
+		}
+		for _, entity := range entities {
+			if err := buffer.sendEntity(entity); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
 
 	// Otherwise, iterate over all entries, matching each against the request
-	for _, row := range t.rows {
+	if err := t.visitRows(request, func(row *Row) error {
+		return buffer.sendEntity(t.readEntity(request, readType, row))
+	}); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Get the entity with the entry typed according to the specified read type, with the time since the entry was
+// last hit, if requested
+func (t *Table) readEntity(request *p4api.TableEntry, readType ReadType, row *Row) *p4api.Entity {
+	entity := t.getEntry(readType, row)
+	if request.TimeSinceLastHit == nil || readType != ReadTableEntry {
+		return entity
+	}
+	entry := proto.Clone(entity.GetTableEntry()).(*p4api.TableEntry)
+	entry.TimeSinceLastHit = &p4api.TableEntry_IdleTimeout{ElapsedNs: t.tables.clock().Sub(row.lastHit).Nanoseconds()}
+	return &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: entry}}
+}
+
+// Returns the row of the entry fully specified by the request, if the table has only exact match fields, the
+// request has an exact match for each of them and the entry exists; a request for which no row is found this way
+// must still be served by matching all rows, which also compares field values of differing encodings numerically
+func (t *Table) exactRow(request *p4api.TableEntry) (*Row, bool) {
+	if len(request.Match) == 0 || len(request.Match) != len(t.info.MatchFields) {
+		return nil, false
+	}
+	for _, field := range t.info.MatchFields {
+		if field.GetMatchType() != p4info.MatchField_EXACT {
+			return nil, false
+		}
+	}
+	for _, m := range request.Match {
+		if m.GetExact() == nil {
+			return nil, false
+		}
+	}
+
+	// Order a copy of the field matches, leaving the request untouched
+	lookup := &p4api.TableEntry{Match: append([]*p4api.FieldMatch{}, request.Match...)}
+	sortFieldMatches(lookup.Match)
+	key, err := t.entryKey(lookup)
+	if err != nil {
+		return nil, false
+	}
+	row, ok := t.rows[key]
+	if !ok || !sameMatches(row.entry.Match, lookup.Match) || !t.tableEntryMatches(request, row.entry) {
+		return nil, false
+	}
+	return row, true
+}
+
+// ReadTableEntriesLimited reads at most limit table entries matching the specified request; returns true if more
+// entries matched the request than were emitted
+func (t *Table) ReadTableEntriesLimited(request *p4api.TableEntry, readType ReadType, limit int, sender BatchSender) (bool, error) {
+	if limit <= 0 {
+		return false, errors.NewInvalid("read limit must be positive: %d", limit)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.validateReadType(readType); err != nil {
+		return false, err
+	}
+	buffer := t.tables.newBuffer(sender)
+	emitted, truncated := 0, false
+	errLimit := errors.NewInvalid("read limit reached")
+	if err := t.visitRows(request, func(row *Row) error {
+		if emitted == limit {
+			truncated = true
+			return errLimit
+		}
+		emitted++
+		return buffer.sendEntity(t.getEntry(readType, row))
+	}); err != nil && err != errLimit {
+		return false, err
+	}
+	return truncated, buffer.flush()
+}
+
+// Validates that the table declares the direct resource required by the specified read type
+func (t *Table) validateReadType(readType ReadType) error {
+	switch readType {
+	case ReadDirectCounter:
+		if t.directCounter == nil {
+			return errors.NewInvalid("table %s has no direct counter", t.Name())
+		}
+	case ReadDirectMeter:
+		if t.directMeter == nil {
+			return errors.NewInvalid("table %s has no direct meter", t.Name())
+		}
+	}
+	return nil
+}
+
+// Visits all rows matching the specified request, followed by the default row, if any; requests for the default
+// entry visit only the default row
+func (t *Table) visitRows(request *p4api.TableEntry, visitor func(row *Row) error) error {
+	t.pollCounters()
+	if request.IsDefaultAction {
+		return t.visitDefaultRow(visitor)
+	}
+	if err := t.visitVisibleRows(func(row *Row) error {
 		if t.tableEntryMatches(request, row.entry) {
-			if err := buffer.sendEntity(getEntry(readType, row)); err != nil {
+			return visitor(row)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if t.defaultRow != nil && t.tableEntryMatches(request, t.defaultRow.entry) {
+		return visitor(t.defaultRow)
+	}
+	return nil
+}
+
+// Visits only the default row; a table without a programmed default entry yields its const default entry, if any
+func (t *Table) visitDefaultRow(visitor func(row *Row) error) error {
+	if t.defaultRow != nil {
+		return visitor(t.defaultRow)
+	}
+	if entry := t.defaultEntry(); entry != nil {
+		return visitor(t.newRow(entry))
+	}
+	return nil
+}
+
+// ReadModifiedBetween reads the table entries whose last modification time falls within the specified
+// time window, inclusive of both start and end
+func (t *Table) ReadModifiedBetween(start time.Time, end time.Time, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	buffer := t.tables.newBuffer(sender)
+	inWindow := func(row *Row) bool {
+		return !row.modifiedAt.Before(start) && !row.modifiedAt.After(end)
+	}
+	for _, row := range t.orderedRows() {
+		if inWindow(row) {
+			if err := buffer.sendEntity(t.getEntry(ReadTableEntry, row)); err != nil {
 				return err
 			}
 		}
 	}
-	if t.defaultRow != nil {
-		if err := buffer.sendEntity(getEntry(readType, t.defaultRow)); err != nil {
+	if t.defaultRow != nil && inWindow(t.defaultRow) {
+		if err := buffer.sendEntity(t.getEntry(ReadTableEntry, t.defaultRow)); err != nil {
 			return err
 		}
 	}
 	return buffer.flush()
 }
 
+// ReadByCookieMask reads the table entries whose metadata, ANDed with the mask, equals the cookie; the cookie and
+// the mask must be of the same length, with shorter metadata treated as left-padded with zeros, e.g. to select all
+// entries of an application identified by the high bits of the metadata
+func (t *Table) ReadByCookieMask(cookie []byte, mask []byte, sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(cookie) != len(mask) {
+		return errors.NewInvalid("cookie and mask have different lengths: %d and %d bytes", len(cookie), len(mask))
+	}
+	buffer := t.tables.newBuffer(sender)
+	if err := t.visitRows(&p4api.TableEntry{}, func(row *Row) error {
+		if cookieMatches(row.entry.Metadata, cookie, mask) {
+			return buffer.sendEntity(t.getEntry(ReadTableEntry, row))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// Returns true if the metadata ANDed with the mask equals the cookie
+func cookieMatches(metadata []byte, cookie []byte, mask []byte) bool {
+	n := maxLen(len(mask), metadata)
+	md, c, m := padTo(metadata, n), padTo(cookie, n), padTo(mask, n)
+	for i := 0; i < n; i++ {
+		if md[i]&m[i] != c[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadEntriesWithRedDrops reads the table entries whose direct meter data records red-marked traffic
+func (t *Table) ReadEntriesWithRedDrops(sender BatchSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	buffer := t.tables.newBuffer(sender)
+	for _, row := range t.orderedRows() {
+		if row.hasRedDrops() {
+			if err := buffer.sendEntity(t.getEntry(ReadTableEntry, row)); err != nil {
+				return err
+			}
+		}
+	}
+	return buffer.flush()
+}
+
+// Returns true if the row meter data has non-zero red counters
+func (r *Row) hasRedDrops() bool {
+	if r.meterData == nil || r.meterData.Red == nil {
+		return false
+	}
+	return r.meterData.Red.PacketCount > 0 || r.meterData.Red.ByteCount > 0
+}
+
 // Get the entity with the entry typed according to the specified read type
-func getEntry(readType ReadType, row *Row) *p4api.Entity {
+func (t *Table) getEntry(readType ReadType, row *Row) *p4api.Entity {
+	entry := t.formatEntry(row.entry)
 	switch readType {
 	case ReadDirectCounter:
 		return &p4api.Entity{Entity: &p4api.Entity_DirectCounterEntry{DirectCounterEntry: &p4api.DirectCounterEntry{
-			TableEntry: row.entry,
-			Data:       row.counterData,
+			TableEntry: entry,
+			Data:       t.directCounterData(row.counterData),
 		}}}
 	case ReadDirectMeter:
 		return &p4api.Entity{Entity: &p4api.Entity_DirectMeterEntry{DirectMeterEntry: &p4api.DirectMeterEntry{
-			TableEntry:  row.entry,
+			TableEntry:  entry,
 			Config:      row.meterConfig,
 			CounterData: row.meterData,
 		}}}
 	}
-	return &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: row.entry}}
+	return &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: entry}}
+}
+
+// Returns the direct counter data with only the counts tracked by the direct counter unit, zeroing the other count;
+// counters with unspecified unit track both
+func (t *Table) directCounterData(data *p4api.CounterData) *p4api.CounterData {
+	if data == nil {
+		return nil
+	}
+	switch t.directCounter.GetSpec().GetUnit() {
+	case p4info.CounterSpec_PACKETS:
+		if data.ByteCount != 0 {
+			return &p4api.CounterData{PacketCount: data.PacketCount}
+		}
+	case p4info.CounterSpec_BYTES:
+		if data.PacketCount != 0 {
+			return &p4api.CounterData{ByteCount: data.ByteCount}
+		}
+	}
+	return data
 }
 
+// Returns true if the entry matches the read request; the request matches all entries if it has no field matches
+// and no priority, otherwise the entry must have the same priority, if given, and equal field matches for all the
+// fields present in the request
 func (t *Table) tableEntryMatches(request *p4api.TableEntry, entry *p4api.TableEntry) bool {
-	// TODO: implement full spectrum of wildcard matching
+	if request.Priority != 0 && request.Priority != entry.Priority {
+		return false
+	}
+	for _, rm := range request.Match {
+		matched := false
+		for _, m := range entry.Match {
+			if m.FieldId == rm.FieldId {
+				matched = fieldMatchesEqual(rm, m, t.fieldWidth(m.FieldId))
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
 	return true
 }
 
-// Produces a table entry key using a uint64 hash of its field matches; returns error if the matches do not comply
+// Returns true if the two field matches are of the same type and have numerically equal values
+func fieldMatchesEqual(a *p4api.FieldMatch, b *p4api.FieldMatch, width int) bool {
+	switch {
+	case a.GetExact() != nil:
+		return b.GetExact() != nil && bytesEqual(a.GetExact().Value, b.GetExact().Value, width)
+	case a.GetLpm() != nil:
+		return b.GetLpm() != nil && a.GetLpm().PrefixLen == b.GetLpm().PrefixLen &&
+			bytesEqual(a.GetLpm().Value, b.GetLpm().Value, width)
+	case a.GetTernary() != nil:
+		return b.GetTernary() != nil && bytesEqual(a.GetTernary().Value, b.GetTernary().Value, width) &&
+			bytesEqual(a.GetTernary().Mask, b.GetTernary().Mask, width)
+	case a.GetRange() != nil:
+		return b.GetRange() != nil && bytesEqual(a.GetRange().Low, b.GetRange().Low, width) &&
+			bytesEqual(a.GetRange().High, b.GetRange().High, width)
+	case a.GetOptional() != nil:
+		return b.GetOptional() != nil && bytesEqual(a.GetOptional().Value, b.GetOptional().Value, width)
+	}
+	return false
+}
+
+// Produces a table entry key using a hash of its priority and field matches; returns error if the matches do not comply
 // with the table schema
 func (t *Table) entryKey(entry *p4api.TableEntry) (string, error) {
-	hf := sha1.New()
+	hf := t.tables.keyHash()
+	_, _ = hf.Write(t.tables.keySalt)
+	writeHash(hf, entry.Priority)
 
-	// This assumes matches have already been put in canonical order
-	for i, m := range entry.Match {
-		// Validate field ID against the P4Info table schema
-		if err := t.validateMatch(i, m); err != nil {
+	// This assumes matches have already been put in canonical order; fields omitted from the entry are skipped
+	// in the P4Info table schema, which is in the same order
+	j := 0
+	for _, m := range entry.Match {
+		for j < len(t.info.MatchFields) && t.info.MatchFields[j].Id < m.FieldId {
+			j++
+		}
+		// Validate field ID and match type against the P4Info table schema
+		if err := t.validateMatch(j, m); err != nil {
 			return "", err
 		}
+		j++
+		// Field IDs and value lengths are part of the hash, so that values shifting across field boundaries
+		// do not produce the same byte stream; values are hashed in canonical form, so that numerically equal
+		// values, e.g. with and without leading zeros, produce the same key
+		writeHash(hf, int32(m.FieldId))
 		switch {
 		case m.GetExact() != nil:
 			_, _ = hf.Write([]byte{0x01})
-			_, _ = hf.Write(m.GetExact().Value)
+			writeHashValue(hf, m.GetExact().Value)
 		case m.GetLpm() != nil:
 			_, _ = hf.Write([]byte{0x02})
 			writeHash(hf, m.GetLpm().PrefixLen)
-			_, _ = hf.Write(m.GetLpm().Value)
+			writeHashValue(hf, m.GetLpm().Value)
 		case m.GetRange() != nil:
 			_, _ = hf.Write([]byte{0x03})
-			_, _ = hf.Write(m.GetRange().Low)
-			_, _ = hf.Write(m.GetRange().High)
+			writeHashValue(hf, m.GetRange().Low)
+			writeHashValue(hf, m.GetRange().High)
 		case m.GetTernary() != nil:
 			_, _ = hf.Write([]byte{0x04})
-			_, _ = hf.Write(m.GetTernary().Mask)
-			_, _ = hf.Write(m.GetTernary().Value)
+			writeHashValue(hf, m.GetTernary().Mask)
+			writeHashValue(hf, m.GetTernary().Value)
 		case m.GetOptional() != nil:
 			_, _ = hf.Write([]byte{0x05})
-			_, _ = hf.Write(m.GetOptional().Value)
+			writeHashValue(hf, m.GetOptional().Value)
 		}
 	}
 	return string(hf.Sum(nil)), nil
 }
 
+// RequiresPriority returns true if the table entries must be given a priority to order overlapping entries, i.e. if
+// the table has any ternary, range or optional match fields; the priority is part of the entry key, so entries
+// differing only by priority are distinct
+func (t *Table) RequiresPriority() bool {
+	for _, field := range t.info.MatchFields {
+		switch field.GetMatchType() {
+		case p4info.MatchField_TERNARY, p4info.MatchField_RANGE, p4info.MatchField_OPTIONAL:
+			return true
+		}
+	}
+	return false
+}
+
+// Validates that the entry has a priority if the table requires one
+func (t *Table) validatePriority(entry *p4api.TableEntry) error {
+	if entry.Priority <= 0 && t.RequiresPriority() {
+		return errors.NewInvalid("entry of table %s must have a positive priority: %v", t.Name(), entry)
+	}
+	return nil
+}
+
+// Validates that the entry of a table with only exact match fields has a match for each of the declared fields,
+// exactly once; tables with other types of match fields are not validated
+func (t *Table) validateExactMatches(entry *p4api.TableEntry) error {
+	declared := make(map[uint32]bool, len(t.info.MatchFields))
+	for _, field := range t.info.MatchFields {
+		if field.GetMatchType() != p4info.MatchField_EXACT {
+			return nil
+		}
+		declared[field.Id] = false
+	}
+	for _, m := range entry.Match {
+		seen, ok := declared[m.FieldId]
+		if !ok {
+			return errors.NewInvalid("field %d is not a match field of table %s", m.FieldId, t.Name())
+		}
+		if seen {
+			return errors.NewInvalid("field %d of table %s is matched more than once", m.FieldId, t.Name())
+		}
+		declared[m.FieldId] = true
+	}
+	for _, field := range t.info.MatchFields {
+		if !declared[field.Id] {
+			return errors.NewInvalid("exact match field %s of table %s is missing", field.Name, t.Name())
+		}
+	}
+	return nil
+}
+
 // Validates that the specified match corresponds to the expected table schema
 func (t *Table) validateMatch(i int, m *p4api.FieldMatch) error {
-	if i >= len(t.info.MatchFields) {
+	if i >= len(t.info.MatchFields) || t.info.MatchFields[i].Id != m.FieldId {
 		return errors.NewInvalid("unexpected field match %d: %v", i, m)
 	}
 
-	// TODO: implement validation that the match is of expected type
+	field := t.info.MatchFields[i]
+	var ok bool
+	switch field.GetMatchType() {
+	case p4info.MatchField_EXACT:
+		ok = m.GetExact() != nil
+	case p4info.MatchField_LPM:
+		ok = m.GetLpm() != nil
+	case p4info.MatchField_TERNARY:
+		ok = m.GetTernary() != nil
+	case p4info.MatchField_RANGE:
+		ok = m.GetRange() != nil
+	case p4info.MatchField_OPTIONAL:
+		ok = m.GetOptional() != nil
+	default:
+		// Other match types are not validated
+		ok = true
+	}
+	if !ok {
+		return errors.NewInvalid("field %s of table %s requires %s match: %v", field.Name, t.Name(), field.GetMatchType(), m)
+	}
+	return t.validateMatchWidth(field, m)
+}
+
+// Validates that the match values fit the bitwidth of the specified field
+func (t *Table) validateMatchWidth(field *p4info.MatchField, m *p4api.FieldMatch) error {
+	if field.Bitwidth == 0 {
+		return nil
+	}
+	width := int(field.Bitwidth+7) / 8
+	tooWide := func(value []byte) error {
+		if len(value) > width {
+			return errors.NewInvalid("value of field %s of table %s has %d bytes; expected at most %d bytes for %d bits",
+				field.Name, t.Name(), len(value), width, field.Bitwidth)
+		}
+		return nil
+	}
+	switch {
+	case m.GetExact() != nil:
+		return tooWide(m.GetExact().Value)
+	case m.GetOptional() != nil:
+		return tooWide(m.GetOptional().Value)
+	case m.GetLpm() != nil:
+		if m.GetLpm().PrefixLen < 0 || m.GetLpm().PrefixLen > field.Bitwidth {
+			return errors.NewInvalid("prefix length %d of field %s of table %s is not between 0 and %d",
+				m.GetLpm().PrefixLen, field.Name, t.Name(), field.Bitwidth)
+		}
+		return tooWide(m.GetLpm().Value)
+	case m.GetTernary() != nil:
+		if len(m.GetTernary().Value) != len(m.GetTernary().Mask) {
+			return errors.NewInvalid("value and mask of field %s of table %s have different lengths: %d and %d bytes",
+				field.Name, t.Name(), len(m.GetTernary().Value), len(m.GetTernary().Mask))
+		}
+		return tooWide(m.GetTernary().Value)
+	case m.GetRange() != nil:
+		if len(m.GetRange().Low) != len(m.GetRange().High) {
+			return errors.NewInvalid("low and high bounds of field %s of table %s have different lengths: %d and %d bytes",
+				field.Name, t.Name(), len(m.GetRange().Low), len(m.GetRange().High))
+		}
+		return tooWide(m.GetRange().Low)
+	}
 	return nil
 }
 
 func writeHash(hash hash.Hash, n int32) {
-	_, _ = hash.Write([]byte{byte((n & 0xff0000) >> 24), byte((n & 0xff0000) >> 16), byte((n & 0xff00) >> 8), byte(n & 0xff)})
+	_, _ = hash.Write([]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+}
+
+// Writes the length of the canonical form of the given value followed by the canonical value into the specified hash
+func writeHashValue(hash hash.Hash, value []byte) {
+	value = CanonicalValue(value)
+	writeHash(hash, int32(len(value)))
+	_, _ = hash.Write(value)
 }
 
 // Sorts the given array of field matches in place based on the field ID
@@ -5,10 +5,17 @@
 package entries
 
 import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-net-lib/pkg/p4utils"
 	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
 	p4api "github.com/p4lang/p4runtime/go/p4/v1"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestTableBasics(t *testing.T) {
@@ -153,3 +160,1388 @@ func TestTableErrors(t *testing.T) {
 	assert.Error(t, err)
 
 }
+
+// Creates a table with two exact match fields, a direct counter and a direct meter for testing
+func newExactTables() *Tables {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "exact"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "f2", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		},
+		DirectResourceIds: []uint32{11, 12},
+	}})
+	tables.BindDirectResources(
+		[]*p4info.DirectCounter{{Preamble: &p4info.Preamble{Id: 11}, DirectTableId: 1}},
+		[]*p4info.DirectMeter{{Preamble: &p4info.Preamble{Id: 12}, DirectTableId: 1}})
+	return tables
+}
+
+// Creates an exact table entry with the given field values
+func exactEntry(v1 byte, v2 byte) *p4api.TableEntry {
+	return &p4api.TableEntry{
+		TableId: 1,
+		Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{v1}}}},
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{v2}}}},
+		},
+	}
+}
+
+func TestModifyRekey(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 1), true))
+
+	// By default, modify of an entry with changed match fields is rejected, even with a hint
+	err := tables.ModifyTableEntryWithHint(exactEntry(1, 2), exactEntry(1, 1))
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Len(t, table.rows, 1)
+
+	// In re-key mode, the old entry is replaced by the new one
+	table.SetModifyMode(ModifyRekey)
+	assert.NoError(t, tables.ModifyTableEntryWithHint(exactEntry(1, 2), exactEntry(1, 1)))
+	assert.Len(t, table.rows, 1)
+	assert.Error(t, tables.ModifyTableEntry(exactEntry(1, 1), false))
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 2), false))
+
+	// Without a hint, or with a hint of a non-existent entry, modify still fails
+	assert.Error(t, tables.ModifyTableEntryWithHint(exactEntry(1, 3), nil))
+	assert.Error(t, tables.ModifyTableEntryWithHint(exactEntry(1, 3), exactEntry(1, 1)))
+	assert.Len(t, table.rows, 1)
+
+	// Re-keying reports the final counters of the old entry
+	reports := make([]*p4api.DirectCounterEntry, 0)
+	table.SetFinalCounterReporter(func(entry *p4api.DirectCounterEntry) { reports = append(reports, entry) })
+	assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{
+		TableEntry: exactEntry(1, 2), Data: &p4api.CounterData{PacketCount: 7}}, false))
+	assert.NoError(t, tables.ModifyTableEntryWithHint(exactEntry(1, 3), exactEntry(1, 2)))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, int64(7), reports[0].Data.PacketCount)
+	assert.Equal(t, []byte{2}, reports[0].TableEntry.Match[1].GetExact().Value)
+}
+
+func TestModifyRekeyTernaryHint(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+			{Id: 2, Name: "ip_proto", Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		},
+	}})
+	table := tables.Table(1)
+	table.SetModifyMode(ModifyRekey)
+	ternary := func(value []byte, proto byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Priority: 10, Match: []*p4api.FieldMatch{
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{proto}}}},
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: value, Mask: []byte{0xff, 0x00}}}},
+		}}
+	}
+	assert.NoError(t, table.ModifyTableEntry(ternary([]byte{0x08, 0x00}, 6), true))
+
+	// A hint with don't-care bits set and out of order field matches still addresses the stored entry, and is left
+	// as is
+	hint := ternary([]byte{0x08, 0x42}, 6)
+	assert.NoError(t, table.ModifyTableEntryWithHint(ternary([]byte{0x08, 0x00}, 17), hint))
+	assert.Equal(t, 1, table.Size())
+	assert.Equal(t, []byte{17}, table.Entries()[0].Match[1].GetExact().Value)
+	assert.Equal(t, []byte{0x08, 0x42}, hint.Match[1].GetTernary().Value)
+	assert.Equal(t, uint32(2), hint.Match[0].FieldId)
+}
+
+func TestReconfigureDropsOrphanedDirectResources(t *testing.T) {
+	tableInfo := func() *p4info.Table {
+		return &p4info.Table{
+			Preamble: &p4info.Preamble{Id: 1, Name: "exact"},
+			MatchFields: []*p4info.MatchField{
+				{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+				{Id: 2, Name: "f2", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			},
+		}
+	}
+	info := &p4info.P4Info{
+		Tables:         []*p4info.Table{tableInfo()},
+		DirectCounters: []*p4info.DirectCounter{{Preamble: &p4info.Preamble{Id: 11}, DirectTableId: 1}},
+		DirectMeters:   []*p4info.DirectMeter{{Preamble: &p4info.Preamble{Id: 12}, DirectTableId: 1}},
+	}
+	tables := NewTables(info.Tables)
+	tables.BindDirectResources(info.DirectCounters, info.DirectMeters)
+
+	entry := exactEntry(1, 1)
+	entry.MeterConfig = &p4api.MeterConfig{Cir: 100}
+	assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{
+		TableEntry: exactEntry(1, 1), Data: &p4api.CounterData{PacketCount: 10, ByteCount: 1000}}, false))
+
+	// Reconfiguring with the same resources retains the data
+	tables.Reconfigure(info)
+	row := tables.Table(1).rows[mustKey(t, tables.Table(1), exactEntry(1, 1))]
+	assert.Equal(t, int64(10), row.counterData.PacketCount)
+	assert.NotNil(t, row.meterConfig)
+
+	// Drop the direct counter; the meter should remain
+	tables.Reconfigure(&p4info.P4Info{
+		Tables:       []*p4info.Table{tableInfo()},
+		DirectMeters: info.DirectMeters,
+	})
+	assert.Len(t, tables.Table(1).rows, 1)
+	assert.Nil(t, row.counterData)
+	assert.NotNil(t, row.meterConfig)
+
+	// Drop the direct meter as well
+	tables.Reconfigure(&p4info.P4Info{Tables: []*p4info.Table{tableInfo()}})
+	assert.Nil(t, row.meterConfig)
+	assert.Nil(t, row.meterData)
+
+	// Drop the table altogether
+	tables.Reconfigure(&p4info.P4Info{})
+	assert.Nil(t, tables.Table(1))
+}
+
+// Returns the key for the specified entry or fails the test
+func mustKey(t *testing.T, table *Table, entry *p4api.TableEntry) string {
+	sortFieldMatches(entry.Match)
+	key, err := table.entryKey(entry)
+	assert.NoError(t, err)
+	return key
+}
+
+// Fake clock for deterministic tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestReadModifiedBetween(t *testing.T) {
+	tables := newExactTables()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tables.SetClock(clock.Now)
+	table := tables.Table(1)
+
+	t0 := clock.Now()
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 1), true))
+	clock.Advance(time.Minute)
+	t1 := clock.Now()
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(2, 2), true))
+	clock.Advance(time.Minute)
+	t2 := clock.Now()
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(3, 3), true))
+
+	count := func(start time.Time, end time.Time) int {
+		n := 0
+		assert.NoError(t, table.ReadModifiedBetween(start, end, func(entities []*p4api.Entity) error {
+			n += len(entities)
+			return nil
+		}))
+		return n
+	}
+	assert.Equal(t, 3, count(t0, t2))
+	assert.Equal(t, 1, count(t0, t0))
+	assert.Equal(t, 2, count(t1, t2))
+	assert.Equal(t, 0, count(t2.Add(time.Second), t2.Add(time.Hour)))
+
+	// Modifying an entry moves it into a later window
+	clock.Advance(time.Minute)
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 1), false))
+	assert.Equal(t, 1, count(t0, t1))
+	assert.Equal(t, 1, count(clock.Now(), clock.Now()))
+}
+
+func TestReadEntriesWithRedDrops(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+
+	colors := func(green int64, red int64) *p4api.MeterCounterData {
+		return &p4api.MeterCounterData{
+			Green:  &p4api.CounterData{PacketCount: green, ByteCount: green * 100},
+			Yellow: &p4api.CounterData{},
+			Red:    &p4api.CounterData{PacketCount: red, ByteCount: red * 100},
+		}
+	}
+	for i := byte(0); i < 6; i++ {
+		entry := exactEntry(i, 0)
+		switch i % 3 {
+		case 0:
+			entry.MeterCounterData = colors(10, 0)
+		case 1:
+			entry.MeterCounterData = colors(10, int64(i))
+		}
+		assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	}
+
+	red := make([]*p4api.TableEntry, 0)
+	assert.NoError(t, table.ReadEntriesWithRedDrops(func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			red = append(red, entity.GetTableEntry())
+		}
+		return nil
+	}))
+	assert.Len(t, red, 2)
+	for _, entry := range red {
+		assert.Equal(t, byte(1), entry.Match[0].GetExact().Value[0]%3)
+	}
+}
+
+func TestReadBestEffort(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := 0; i < 200; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(byte(i), byte(i>>8)), true))
+	}
+
+	// Fail the second batch; the rest should still be delivered
+	batches := 0
+	delivered := 0
+	sender := func(entities []*p4api.Entity) error {
+		batches++
+		if batches == 2 {
+			return errors.NewUnavailable("transient failure")
+		}
+		delivered += len(entities)
+		return nil
+	}
+	err := table.ReadTableEntriesBestEffort(&p4api.TableEntry{}, ReadTableEntry, sender)
+	assert.Error(t, err)
+	batchErrors, ok := err.(BatchErrors)
+	assert.True(t, ok)
+	assert.Len(t, batchErrors, 1)
+	assert.ErrorContains(t, err, "transient failure")
+	assert.Equal(t, 4, batches)
+	assert.Equal(t, 200-64, delivered)
+
+	// Regular read aborts on the first failure
+	batches = 0
+	delivered = 0
+	assert.Error(t, table.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, sender))
+	assert.Equal(t, 2, batches)
+	assert.Equal(t, 64, delivered)
+
+	// No errors yields nil
+	assert.NoError(t, table.ReadTableEntriesBestEffort(&p4api.TableEntry{}, ReadTableEntry, func([]*p4api.Entity) error { return nil }))
+}
+
+func TestFinalCounterReport(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	reports := make([]*p4api.DirectCounterEntry, 0)
+	table.SetFinalCounterReporter(func(entry *p4api.DirectCounterEntry) {
+		reports = append(reports, entry)
+	})
+
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 1), true))
+	assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{
+		TableEntry: exactEntry(1, 1), Data: &p4api.CounterData{PacketCount: 7, ByteCount: 700}}, false))
+
+	assert.NoError(t, tables.RemoveTableEntry(exactEntry(1, 1)))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, int64(7), reports[0].Data.PacketCount)
+	assert.Equal(t, int64(700), reports[0].Data.ByteCount)
+	assert.Equal(t, []byte{1}, reports[0].TableEntry.Match[0].GetExact().Value)
+
+	// Removing an entry that no longer exists must not report again
+	assert.NoError(t, tables.RemoveTableEntry(exactEntry(1, 1)))
+	assert.Len(t, reports, 1)
+}
+
+func TestInsertionOrder(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	assert.Error(t, table.ReadInInsertionOrder(&p4api.TableEntry{}, ReadTableEntry, func([]*p4api.Entity) error { return nil }))
+
+	table.EnableInsertionOrder()
+	order := []byte{9, 3, 7, 1, 5}
+	for _, v := range order {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(v, 0), true))
+	}
+
+	read := func() []byte {
+		values := make([]byte, 0)
+		assert.NoError(t, table.ReadInInsertionOrder(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				values = append(values, entity.GetTableEntry().Match[0].GetExact().Value[0])
+			}
+			return nil
+		}))
+		return values
+	}
+	assert.Equal(t, order, read())
+
+	// Modify does not change the order
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(3, 0), false))
+	assert.Equal(t, order, read())
+
+	// Removal compacts the order and re-insert goes to the end
+	assert.NoError(t, tables.RemoveTableEntry(exactEntry(7, 0)))
+	assert.Equal(t, []byte{9, 3, 1, 5}, read())
+	assert.NoError(t, tables.ModifyTableEntry(exactEntry(7, 0), true))
+	assert.Equal(t, []byte{9, 3, 1, 5, 7}, read())
+	assert.Len(t, table.insertionOrder, 5)
+}
+
+func TestReadTypeValidation(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "plain"}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "counted"}, DirectResourceIds: []uint32{11}},
+		{Preamble: &p4info.Preamble{Id: 3, Name: "metered"}, DirectResourceIds: []uint32{12}},
+	})
+	tables.BindDirectResources(
+		[]*p4info.DirectCounter{{Preamble: &p4info.Preamble{Id: 11}, DirectTableId: 2}},
+		[]*p4info.DirectMeter{{Preamble: &p4info.Preamble{Id: 12}, DirectTableId: 3}})
+	for id := uint32(1); id <= 3; id++ {
+		assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: id}, true))
+	}
+
+	none := func([]*p4api.Entity) error { return nil }
+	for id := uint32(1); id <= 3; id++ {
+		assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{TableId: id}, ReadTableEntry, none))
+	}
+
+	err := tables.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, none)
+	assert.True(t, errors.IsInvalid(err))
+	err = tables.ReadTableEntries(&p4api.TableEntry{TableId: 3}, ReadDirectCounter, none)
+	assert.True(t, errors.IsInvalid(err))
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{TableId: 2}, ReadDirectCounter, none))
+
+	err = tables.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectMeter, none)
+	assert.True(t, errors.IsInvalid(err))
+	err = tables.ReadTableEntries(&p4api.TableEntry{TableId: 2}, ReadDirectMeter, none)
+	assert.True(t, errors.IsInvalid(err))
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{TableId: 3}, ReadDirectMeter, none))
+
+	// Wildcard reads only cover tables with the requested resource
+	count := 0
+	counting := func(entities []*p4api.Entity) error {
+		count += len(entities)
+		return nil
+	}
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, counting))
+	assert.Equal(t, 1, count)
+	count = 0
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, counting))
+	assert.Equal(t, 3, count)
+}
+
+func TestMatchFieldOrder(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "mixed"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 3, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+			{Id: 1, Name: "vrf", Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+		},
+	}})
+	fields := tables.Table(1).MatchFieldOrder()
+	assert.Equal(t, []MatchFieldDesc{
+		{ID: 1, Name: "vrf", MatchType: p4info.MatchField_EXACT, Bitwidth: 8},
+		{ID: 2, Name: "eth_type", MatchType: p4info.MatchField_TERNARY, Bitwidth: 16},
+		{ID: 3, Name: "ipv4_dst", MatchType: p4info.MatchField_LPM, Bitwidth: 32},
+	}, fields)
+
+	info, err := p4utils.LoadP4Info("../../../pipelines/p4info.txt")
+	assert.NoError(t, err)
+	for _, table := range NewTables(info.Tables).Tables() {
+		fields := table.MatchFieldOrder()
+		assert.Len(t, fields, len(table.info.MatchFields))
+		for i := 1; i < len(fields); i++ {
+			assert.Less(t, fields[i-1].ID, fields[i].ID)
+		}
+	}
+}
+
+func TestBulkModifyDirectCounters(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	bulk := make([]*p4api.DirectCounterEntry, 0)
+	for i := byte(0); i < 200; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, 1), true))
+		bulk = append(bulk, &p4api.DirectCounterEntry{
+			TableEntry: exactEntry(i, 1),
+			Data:       &p4api.CounterData{PacketCount: int64(i), ByteCount: 100 * int64(i)},
+		})
+	}
+	missing := &p4api.DirectCounterEntry{TableEntry: exactEntry(1, 2), Data: &p4api.CounterData{PacketCount: 1}}
+	bulk = append(bulk, missing)
+
+	skipped, err := table.BulkModifyDirectCounters(bulk)
+	assert.NoError(t, err)
+	assert.Equal(t, []*p4api.DirectCounterEntry{missing}, skipped)
+	assert.Equal(t, 200, table.Size())
+
+	count := 0
+	assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{}, ReadDirectCounter, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			dce := entity.GetDirectCounterEntry()
+			v := int64(dce.TableEntry.Match[0].GetExact().Value[0])
+			assert.Equal(t, v, dce.Data.PacketCount)
+			assert.Equal(t, 100*v, dce.Data.ByteCount)
+			count++
+		}
+		return nil
+	}))
+	assert.Equal(t, 200, count)
+
+	// Tables without direct counters reject bulk modifies
+	plain := NewTables([]*p4info.Table{{Preamble: &p4info.Preamble{Id: 2, Name: "plain"}}})
+	_, err = plain.Table(2).BulkModifyDirectCounters(bulk)
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestDefaultActionValidation(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "acl"},
+		ActionRefs: []*p4info.ActionRef{
+			{Id: 1},
+			{Id: 2, Scope: p4info.ActionRef_DEFAULT_ONLY},
+			{Id: 3, Scope: p4info.ActionRef_TABLE_ONLY},
+		},
+	}})
+	table := tables.Table(1)
+	defaultEntry := func(actionID uint32) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(actionID, 0)}
+	}
+
+	assert.NoError(t, table.ModifyTableEntry(defaultEntry(1), false))
+	assert.NoError(t, table.ModifyTableEntry(defaultEntry(2), false))
+
+	err := table.ModifyTableEntry(defaultEntry(3), false)
+	assert.True(t, errors.IsInvalid(err))
+	err = table.ModifyTableEntry(defaultEntry(4), false)
+	assert.True(t, errors.IsInvalid(err))
+
+	// The last valid default action remains in place
+	assert.Equal(t, uint32(2), table.lookupDefault().Action.ActionId)
+}
+
+func TestEntryActionValidation(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "exact"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "f2", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		},
+		ActionRefs: []*p4info.ActionRef{{Id: 1}, {Id: 2, Scope: p4info.ActionRef_DEFAULT_ONLY}, {Id: 3}},
+	}})
+	tables.SetActions(append(testActions, &p4info.Action{Preamble: &p4info.Preamble{Id: 3, Name: "set_vlan"},
+		Params: []*p4info.Action_Param{{Id: 1, Name: "vlan_id", Bitwidth: 12}, {Id: 2, Name: "pcp", Bitwidth: 3}}}))
+	table := tables.Table(1)
+	entry := func(action *p4api.Action) *p4api.TableEntry {
+		entry := exactEntry(1, 2)
+		entry.Action = &p4api.TableAction{Type: &p4api.TableAction_Action{Action: action}}
+		return entry
+	}
+	param := func(id uint32, value ...byte) *p4api.Action_Param {
+		return &p4api.Action_Param{ParamId: id, Value: value}
+	}
+
+	// Actions outside the table action refs, or restricted to the default action, are refused
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(&p4api.Action{ActionId: 4}), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(&p4api.Action{ActionId: 2}), true)))
+
+	// Params must match the action signature and fit the declared bitwidths
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(&p4api.Action{ActionId: 3,
+		Params: []*p4api.Action_Param{param(1, 0x0f, 0xff)}}), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(&p4api.Action{ActionId: 3,
+		Params: []*p4api.Action_Param{param(1, 0x0f, 0xff), param(2, 3), param(3, 1)}}), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(&p4api.Action{ActionId: 3,
+		Params: []*p4api.Action_Param{param(1, 0x0f, 0xff), param(1, 0x0f, 0xff), param(2, 3)}}), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(&p4api.Action{ActionId: 3,
+		Params: []*p4api.Action_Param{param(1, 0x00, 0x0f, 0xff), param(2, 3)}}), true)))
+	assert.Equal(t, 0, table.Size())
+
+	assert.NoError(t, table.ModifyTableEntry(entry(&p4api.Action{ActionId: 3,
+		Params: []*p4api.Action_Param{param(2, 3), param(1, 0x0f, 0xff)}}), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(&p4api.Action{ActionId: 1, Params: []*p4api.Action_Param{param(1, 1)}}), false))
+
+	// The default action params are validated as well
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true,
+		Action: directAction(2, 0)}, false)))
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true,
+		Action: &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{ActionId: 2}}}}, false))
+}
+
+func TestReadTableEntriesLimited(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(0); i < 100; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, 1), true))
+	}
+
+	read := func(limit int) (int, bool, error) {
+		count := 0
+		truncated, err := table.ReadTableEntriesLimited(&p4api.TableEntry{}, ReadTableEntry, limit, func(entities []*p4api.Entity) error {
+			count += len(entities)
+			return nil
+		})
+		return count, truncated, err
+	}
+
+	count, truncated, err := read(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+	assert.True(t, truncated)
+
+	// Limits spanning several batches
+	count, truncated, err = read(70)
+	assert.NoError(t, err)
+	assert.Equal(t, 70, count)
+	assert.True(t, truncated)
+
+	count, truncated, err = read(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, count)
+	assert.False(t, truncated)
+
+	count, truncated, err = read(1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, count)
+	assert.False(t, truncated)
+
+	_, _, err = read(0)
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestKeySalt(t *testing.T) {
+	device1, device2 := newExactTables(), newExactTables()
+	assert.Equal(t, mustKey(t, device1.Table(1), exactEntry(1, 2)), mustKey(t, device2.Table(1), exactEntry(1, 2)))
+
+	assert.NoError(t, device1.SetKeySalt([]byte("device1")))
+	assert.NoError(t, device2.SetKeySalt([]byte("device2")))
+	assert.NotEqual(t, mustKey(t, device1.Table(1), exactEntry(1, 2)), mustKey(t, device2.Table(1), exactEntry(1, 2)))
+
+	for _, tables := range []*Tables{device1, device2} {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(1, 2), true))
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(3, 4), true))
+		assert.True(t, errors.IsAlreadyExists(tables.ModifyTableEntry(exactEntry(1, 2), true)))
+		lr, err := tables.Table(1).Lookup(FieldValues{1: {1}, 2: {2}})
+		assert.NoError(t, err)
+		assert.True(t, lr.Hit)
+	}
+
+	// Changing the salt of a populated table re-keys its entries, keeping the insertion order
+	table := device1.Table(1)
+	table.EnableInsertionOrder()
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(5, 6), true))
+	assert.NoError(t, device1.SetKeySalt([]byte("other")))
+	lr, err := table.Lookup(FieldValues{1: {3}, 2: {4}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(1, 2)))
+	assert.Equal(t, 2, table.Size())
+
+	order := make([]byte, 0)
+	assert.NoError(t, table.ReadInInsertionOrder(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			order = append(order, entity.GetTableEntry().Match[0].GetExact().Value[0])
+		}
+		return nil
+	}))
+	assert.Equal(t, []byte{3, 5}, order)
+}
+
+func TestDefaultActionOverridesConst(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "overridden"}, ConstDefaultActionId: 2},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "untouched"}, ConstDefaultActionId: 2},
+		{Preamble: &p4info.Preamble{Id: 3, Name: "reprogrammed"}, ConstDefaultActionId: 2},
+		{Preamble: &p4info.Preamble{Id: 4, Name: "noconst"}},
+	})
+	for _, id := range []uint32{1, 3, 4} {
+		actionID := uint32(1)
+		if id == 3 {
+			actionID = 2
+		}
+		assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: id, IsDefaultAction: true, Action: directAction(actionID, 9)}, false))
+	}
+
+	overridden, programmed, constDefault := tables.Table(1).DefaultActionOverridesConst()
+	assert.True(t, overridden)
+	assert.Equal(t, uint32(1), programmed.ActionId)
+	assert.Equal(t, uint32(2), constDefault.ActionId)
+
+	overridden, programmed, constDefault = tables.Table(2).DefaultActionOverridesConst()
+	assert.False(t, overridden)
+	assert.Nil(t, programmed)
+	assert.Equal(t, uint32(2), constDefault.ActionId)
+
+	// Programming the const default action itself is not an override
+	overridden, programmed, _ = tables.Table(3).DefaultActionOverridesConst()
+	assert.False(t, overridden)
+	assert.Equal(t, uint32(2), programmed.ActionId)
+
+	overridden, programmed, constDefault = tables.Table(4).DefaultActionOverridesConst()
+	assert.True(t, overridden)
+	assert.Equal(t, uint32(1), programmed.ActionId)
+	assert.Nil(t, constDefault)
+}
+
+func TestDefaultEntry(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "const"}, ConstDefaultActionId: 2,
+			MatchFields: []*p4info.MatchField{{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "noconst"}},
+	})
+	table := tables.Table(1)
+	assert.Equal(t, uint32(2), table.DefaultEntry().Action.GetAction().ActionId)
+	assert.Nil(t, tables.Table(2).DefaultEntry())
+
+	entry := &p4api.TableEntry{TableId: 1, Action: directAction(1, 1),
+		Match: []*p4api.FieldMatch{{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1}}}}}}
+	assert.NoError(t, table.ModifyTableEntry(entry, true))
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 9)}, false))
+	assert.Equal(t, uint32(1), table.DefaultEntry().Action.GetAction().ActionId)
+
+	readDefault := func() []*p4api.TableEntry {
+		entries := make([]*p4api.TableEntry, 0)
+		assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{TableId: 1, IsDefaultAction: true}, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				entries = append(entries, entity.GetTableEntry())
+			}
+			return nil
+		}))
+		return entries
+	}
+
+	// Reads of the default entry return only the default entry
+	entries := readDefault()
+	assert.Len(t, entries, 1)
+	assert.True(t, entries[0].IsDefaultAction)
+	assert.Equal(t, uint32(1), entries[0].Action.GetAction().ActionId)
+
+	// A default entry without an action resets the default action to the const default action
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true}, false))
+	assert.Equal(t, uint32(2), table.DefaultEntry().Action.GetAction().ActionId)
+	overridden, programmed, _ := table.DefaultActionOverridesConst()
+	assert.False(t, overridden)
+	assert.Nil(t, programmed)
+	assert.Equal(t, 1, table.Size())
+	entries = readDefault()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, uint32(2), entries[0].Action.GetAction().ActionId)
+	lr, err := table.Lookup(FieldValues{1: {7}})
+	assert.NoError(t, err)
+	assert.False(t, lr.Hit)
+	assert.Equal(t, uint32(2), lr.Action.ActionId)
+}
+
+func TestExactMatchCompleteness(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+
+	complete := exactEntry(1, 2)
+	assert.NoError(t, table.ModifyTableEntry(complete, true))
+
+	missing := exactEntry(3, 4)
+	missing.Match = missing.Match[:1]
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(missing, true)))
+
+	extra := exactEntry(3, 4)
+	extra.Match = append(extra.Match, &p4api.FieldMatch{FieldId: 3, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{5}}}})
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(extra, true)))
+
+	duplicate := exactEntry(3, 4)
+	duplicate.Match[1].FieldId = 1
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(duplicate, true)))
+
+	// Modifies must be complete as well
+	missing = exactEntry(1, 2)
+	missing.Match = missing.Match[1:]
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(missing, false)))
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(1, 2), false))
+	assert.Equal(t, 1, table.Size())
+
+	// Reads can still omit fields
+	count := 0
+	assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadTableEntry, func(entities []*p4api.Entity) error {
+		count += len(entities)
+		return nil
+	}))
+	assert.Equal(t, 1, count)
+
+	// Tables with non-exact match fields may omit fields, e.g. as wildcards
+	lpm := newLPMTables()
+	entry := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	entry.Match = entry.Match[1:]
+	assert.NoError(t, lpm.ModifyTableEntry(entry, true))
+}
+
+func TestReadMatchFiltering(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(0); i < 4; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, i%2), true))
+	}
+
+	read := func(table *Table, request *p4api.TableEntry) []*p4api.TableEntry {
+		entries := make([]*p4api.TableEntry, 0)
+		assert.NoError(t, table.ReadTableEntries(request, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				if !entity.GetTableEntry().IsDefaultAction {
+					entries = append(entries, entity.GetTableEntry())
+				}
+			}
+			return nil
+		}))
+		return entries
+	}
+
+	// An empty match list reads all entries
+	assert.Len(t, read(table, &p4api.TableEntry{TableId: 1}), 4)
+
+	// A fully specified request reads only the matching entry
+	entries := read(table, exactEntry(2, 0))
+	assert.Len(t, entries, 1)
+	assert.True(t, sameMatches(exactEntry(2, 0).Match, entries[0].Match))
+	assert.Len(t, read(table, exactEntry(2, 1)), 0)
+
+	// A partially specified request reads all entries with the given field values; values compare numerically
+	partial := &p4api.TableEntry{TableId: 1, Match: []*p4api.FieldMatch{
+		{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{0, 1}}}},
+	}}
+	assert.Len(t, read(table, partial), 2)
+
+	// LPM fields must have the same prefix length and value
+	lpmTables := newLPMTables()
+	lpmTable := lpmTables.Table(2)
+	assert.NoError(t, lpmTable.ModifyTableEntry(lpmEntry(1, []byte{10, 0, 0, 0}, 8), true))
+	assert.NoError(t, lpmTable.ModifyTableEntry(lpmEntry(1, []byte{10, 1, 0, 0}, 16), true))
+	assert.Len(t, read(lpmTable, lpmEntry(1, []byte{10, 1, 0, 0}, 16)), 1)
+	assert.Len(t, read(lpmTable, lpmEntry(1, []byte{10, 1, 0, 0}, 24)), 0)
+
+	// A non-zero priority must be equal
+	entry := exactEntry(1, 1)
+	entry.Priority = 10
+	assert.True(t, table.tableEntryMatches(&p4api.TableEntry{TableId: 1, Priority: 10}, entry))
+	assert.False(t, table.tableEntryMatches(&p4api.TableEntry{TableId: 1, Priority: 20}, entry))
+	assert.True(t, table.tableEntryMatches(&p4api.TableEntry{TableId: 1}, entry))
+}
+
+func TestExactReadFastPath(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(0); i < 50; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, i%3), true))
+	}
+
+	read := func(request *p4api.TableEntry) []*p4api.Entity {
+		result := make([]*p4api.Entity, 0)
+		assert.NoError(t, table.ReadTableEntries(request, ReadTableEntry, func(entities []*p4api.Entity) error {
+			result = append(result, entities...)
+			return nil
+		}))
+		return result
+	}
+	scan := func(request *p4api.TableEntry) []*p4api.Entity {
+		result := make([]*p4api.Entity, 0)
+		assert.NoError(t, table.visitRows(request, func(row *Row) error {
+			result = append(result, table.getEntry(ReadTableEntry, row))
+			return nil
+		}))
+		return result
+	}
+
+	// Fully specified requests, in any field order, are looked up directly and return the same as a scan
+	request := exactEntry(7, 1)
+	row, ok := table.exactRow(request)
+	assert.True(t, ok)
+	assert.True(t, sameMatches(exactEntry(7, 1).Match, row.entry.Match))
+	assertSameEntities(t, scan(request), read(request))
+	assert.Len(t, read(request), 1)
+
+	reversed := exactEntry(7, 1)
+	reversed.Match[0], reversed.Match[1] = reversed.Match[1], reversed.Match[0]
+	_, ok = table.exactRow(reversed)
+	assert.True(t, ok)
+	assertSameEntities(t, scan(exactEntry(7, 1)), read(reversed))
+	assert.Equal(t, uint32(2), reversed.Match[0].FieldId)
+
+	// Misses fall back to the scan, whereas padded values are keyed canonically and looked up directly
+	assert.Len(t, read(exactEntry(7, 2)), 0)
+	padded := exactEntry(7, 1)
+	padded.Match[1].GetExact().Value = []byte{0, 1}
+	_, ok = table.exactRow(padded)
+	assert.True(t, ok)
+	assertSameEntities(t, scan(padded), read(padded))
+	assert.Len(t, read(padded), 1)
+
+	// Requests differing in priority are not matched
+	prioritized := exactEntry(7, 1)
+	prioritized.Priority = 10
+	assert.Len(t, read(prioritized), 0)
+
+	// Partial and wildcard requests are served by the scan
+	partial := &p4api.TableEntry{TableId: 1, Match: exactEntry(7, 1).Match[:1]}
+	_, ok = table.exactRow(partial)
+	assert.False(t, ok)
+	assertSameEntities(t, scan(partial), read(partial))
+	assert.Len(t, read(&p4api.TableEntry{TableId: 1}), 50)
+	assert.Equal(t, uint64(0), table.Stats().KeyCollisions)
+}
+
+// Asserts that the two lists of entities are equal
+func assertSameEntities(t *testing.T, expected []*p4api.Entity, actual []*p4api.Entity) {
+	assert.Len(t, actual, len(expected))
+	for i := range expected {
+		if i < len(actual) {
+			assert.True(t, proto.Equal(expected[i], actual[i]))
+		}
+	}
+}
+
+func TestMatchTypeValidation(t *testing.T) {
+	tables := newLPMTables()
+	table := tables.Table(2)
+
+	// Matches of a type other than the declared one are rejected
+	ternary := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	ternary.Match[1] = &p4api.FieldMatch{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Ternary_{
+		Ternary: &p4api.FieldMatch_Ternary{Value: []byte{10, 0, 0, 0}, Mask: []byte{0xff, 0, 0, 0}}}}
+	err := table.ModifyTableEntry(ternary, true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "ipv4_dst")
+
+	lpm := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	lpm.Match[0] = &p4api.FieldMatch{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Lpm{
+		Lpm: &p4api.FieldMatch_LPM{Value: []byte{1}, PrefixLen: 8}}}
+	err = table.ModifyTableEntry(lpm, true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "vrf")
+
+	// Matches of fields not in the schema, or of the same field more than once, are rejected
+	unknown := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	unknown.Match[1].FieldId = 3
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(unknown, true)))
+	duplicate := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+	duplicate.Match[1] = duplicate.Match[0]
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(duplicate, true)))
+	assert.Equal(t, 0, table.Size())
+
+	// Matches of the declared types are accepted, and fields may be omitted
+	assert.NoError(t, table.ModifyTableEntry(lpmEntry(1, []byte{10, 0, 0, 0}, 8), true))
+	omitted := &p4api.TableEntry{TableId: 2, Match: lpmEntry(1, []byte{10, 0, 0, 0}, 8).Match[1:]}
+	assert.NoError(t, table.ModifyTableEntry(omitted, true))
+	assert.Equal(t, 2, table.Size())
+}
+
+func TestMatchWidthValidation(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 3, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "vlan_id", Bitwidth: 12, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+			{Id: 3, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+			{Id: 4, Name: "ig_port", Bitwidth: 9, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_OPTIONAL}},
+		},
+	}})
+	table := tables.Table(3)
+	entry := func(vlan []byte, prefixLen int32, value []byte, mask []byte, port []byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 3, Priority: 10, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: vlan}}},
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Lpm{Lpm: &p4api.FieldMatch_LPM{Value: []byte{10, 0, 0, 0}, PrefixLen: prefixLen}}},
+			{FieldId: 3, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: value, Mask: mask}}},
+			{FieldId: 4, FieldMatchType: &p4api.FieldMatch_Optional_{Optional: &p4api.FieldMatch_Optional{Value: port}}},
+		}}
+	}
+	valid := func() *p4api.TableEntry {
+		return entry([]byte{0x0f, 0xff}, 32, []byte{0x08, 0x00}, []byte{0xff, 0xff}, []byte{0x01, 0xff})
+	}
+	assert.NoError(t, table.ModifyTableEntry(valid(), true))
+
+	rejected := func(entry *p4api.TableEntry, field string) {
+		err := table.ModifyTableEntry(entry, true)
+		assert.True(t, errors.IsInvalid(err))
+		if err != nil {
+			assert.Contains(t, err.Error(), field)
+		}
+	}
+	rejected(entry([]byte{0, 0x0f, 0xff}, 32, []byte{0x08, 0x00}, []byte{0xff, 0xff}, []byte{1}), "vlan_id")
+	rejected(entry([]byte{1}, 33, []byte{0x08, 0x00}, []byte{0xff, 0xff}, []byte{1}), "ipv4_dst")
+	rejected(entry([]byte{1}, -1, []byte{0x08, 0x00}, []byte{0xff, 0xff}, []byte{1}), "ipv4_dst")
+	rejected(entry([]byte{1}, 8, []byte{0x08, 0x00}, []byte{0xff}, []byte{1}), "eth_type")
+	rejected(entry([]byte{1}, 8, []byte{0, 0x08, 0x00}, []byte{0, 0xff, 0xff}, []byte{1}), "eth_type")
+	rejected(entry([]byte{1}, 8, []byte{0x08, 0x00}, []byte{0xff, 0xff}, []byte{0, 1, 0xff}), "ig_port")
+	assert.Equal(t, 1, table.Size())
+}
+
+func TestDeterministicOrder(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 4, Name: "ternary"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+		},
+	}})
+	table := tables.Table(4)
+	entry := func(value byte, priority int32) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 4, Priority: priority, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: []byte{value}, Mask: []byte{0xff}}}},
+		}}
+	}
+	for i := byte(1); i <= 20; i++ {
+		assert.NoError(t, table.ModifyTableEntry(entry(i, int32(i%4+1)), true))
+	}
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 4, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+
+	read := func() []*p4api.TableEntry {
+		entries := make([]*p4api.TableEntry, 0)
+		assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				entries = append(entries, entity.GetTableEntry())
+			}
+			return nil
+		}))
+		return entries
+	}
+
+	// Entries are ordered by descending priority, with the default entry last, identically across reads
+	entries := read()
+	assert.Len(t, entries, 21)
+	for i := 1; i < 20; i++ {
+		assert.GreaterOrEqual(t, entries[i-1].Priority, entries[i].Priority)
+	}
+	assert.True(t, entries[20].IsDefaultAction)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, entries, read())
+		assert.Equal(t, entries, table.Entries())
+	}
+}
+
+func TestOptionalMatchPriority(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 4, Name: "optional"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "ig_port", Bitwidth: 9, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_OPTIONAL}},
+		},
+	}})
+	table := tables.Table(4)
+	assert.True(t, table.RequiresPriority())
+	assert.False(t, newExactTables().Table(1).RequiresPriority())
+	entry := func(priority int32) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 4, Priority: priority, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Optional_{Optional: &p4api.FieldMatch_Optional{Value: []byte{1}}}},
+		}, Action: directAction(1, byte(priority))}
+	}
+
+	// Overlapping entries differing only by priority are stored distinctly
+	assert.NoError(t, table.ModifyTableEntry(entry(10), true))
+	assert.NoError(t, table.ModifyTableEntry(entry(20), true))
+	assert.True(t, errors.IsAlreadyExists(table.ModifyTableEntry(entry(10), true)))
+	assert.Equal(t, 2, table.Size())
+	assert.NotEqual(t, mustKey(t, table, entry(10)), mustKey(t, table, entry(20)))
+	priorities := make(map[int32]byte)
+	assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			priorities[entity.GetTableEntry().Priority] = entity.GetTableEntry().Action.GetAction().Params[0].Value[0]
+		}
+		return nil
+	}))
+	assert.Equal(t, map[int32]byte{10: 10, 20: 20}, priorities)
+
+	// Entries of tables with optional match fields must have a priority
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(0), true)))
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(entry(-1), true)))
+	assert.NoError(t, table.RemoveTableEntry(entry(10)))
+	assert.Equal(t, 1, table.Size())
+}
+
+func TestRangeWidthValidation(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 3, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "l4_dport", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_RANGE}},
+		},
+	}})
+	table := tables.Table(3)
+	entry := func(low []byte, high []byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 3, Priority: 10, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Range_{Range: &p4api.FieldMatch_Range{Low: low, High: high}}},
+		}}
+	}
+	assert.NoError(t, table.ModifyTableEntry(entry([]byte{0x00, 0x50}, []byte{0x1f, 0x90}), true))
+
+	// Bounds of different widths are rejected
+	err := table.ModifyTableEntry(entry([]byte{0x50}, []byte{0x1f, 0x90}), true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "l4_dport")
+
+	// Bounds wider than the field are rejected
+	err = table.ModifyTableEntry(entry([]byte{0, 0x00, 0x50}, []byte{0, 0x1f, 0x90}), true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "l4_dport")
+	assert.Equal(t, 1, table.Size())
+}
+
+func TestTablesByDefault(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 3, Name: "programmed"}},
+		{Preamble: &p4info.Preamble{Id: 1, Name: "const"}, ConstDefaultActionId: 2},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "absent"}},
+		{Preamble: &p4info.Preamble{Id: 4, Name: "absent2"}},
+	})
+	ids := func(tables []*Table) []uint32 {
+		ids := make([]uint32, 0, len(tables))
+		for _, table := range tables {
+			ids = append(ids, table.ID())
+		}
+		return ids
+	}
+	assert.Equal(t, []uint32{1}, ids(tables.TablesWithDefault()))
+	assert.Equal(t, []uint32{2, 3, 4}, ids(tables.TablesWithoutDefault()))
+
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 3, IsDefaultAction: true,
+		Action: &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{ActionId: 1}}}}, false))
+	assert.Equal(t, []uint32{1, 3}, ids(tables.TablesWithDefault()))
+	assert.Equal(t, []uint32{2, 4}, ids(tables.TablesWithoutDefault()))
+	assert.False(t, tables.Table(2).HasDefaultAction())
+}
+
+func TestTableByName(t *testing.T) {
+	tables := NewTables([]*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 1, Name: "ingress.acl"}},
+		{Preamble: &p4info.Preamble{Id: 2, Name: "ingress.routing"}},
+	})
+	assert.Equal(t, uint32(2), tables.TableByName("ingress.routing").ID())
+	assert.Nil(t, tables.TableByName("egress.vlan"))
+
+	// The name index follows pipeline reconfigurations
+	tables.Reconfigure(&p4info.P4Info{Tables: []*p4info.Table{
+		{Preamble: &p4info.Preamble{Id: 2, Name: "ingress.routing"}},
+		{Preamble: &p4info.Preamble{Id: 3, Name: "egress.vlan"}},
+	}})
+	assert.Nil(t, tables.TableByName("ingress.acl"))
+	assert.Equal(t, uint32(2), tables.TableByName("ingress.routing").ID())
+	assert.Equal(t, uint32(3), tables.TableByName("egress.vlan").ID())
+}
+
+func TestDuplicateMatches(t *testing.T) {
+	tables := newLPMTables()
+	table := tables.Table(2)
+	duplicated := func(value byte) *p4api.TableEntry {
+		entry := lpmEntry(1, []byte{10, 0, 0, 0}, 8)
+		entry.Match = append(entry.Match, &p4api.FieldMatch{FieldId: 1,
+			FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{value}}}})
+		return entry
+	}
+
+	// By default, duplicate matches of a field are rejected
+	err := table.ModifyTableEntry(duplicated(1), true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "more than once")
+	assert.Equal(t, 0, table.Size())
+
+	// When merging, identical duplicates are collapsed, but differing ones are still rejected
+	table.SetDuplicateMatchPolicy(DuplicateMatchMerge)
+	assert.True(t, errors.IsInvalid(table.ModifyTableEntry(duplicated(2), true)))
+	entry := duplicated(1)
+	assert.NoError(t, table.ModifyTableEntry(entry, true))
+	assert.Len(t, entry.Match, 2)
+	assert.True(t, errors.IsAlreadyExists(table.ModifyTableEntry(lpmEntry(1, []byte{10, 0, 0, 0}, 8), true)))
+	assert.NoError(t, table.RemoveTableEntry(duplicated(1)))
+	assert.Equal(t, 0, table.Size())
+}
+
+func TestTernaryDontCareBits(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble:    &p4info.Preamble{Id: 1, Name: "acl"},
+		MatchFields: []*p4info.MatchField{{Id: 1, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}}},
+	}})
+	table := tables.Table(1)
+	ternary := func(value []byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Priority: 10, Match: []*p4api.FieldMatch{{FieldId: 1,
+			FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: value, Mask: []byte{0xff, 0x00}}}}}}
+	}
+
+	// Entries differing only in value bits outside of the mask are the same entry
+	assert.NoError(t, table.ModifyTableEntry(ternary([]byte{0x08, 0x00}), true))
+	value := []byte{0x08, 0x06}
+	assert.True(t, errors.IsAlreadyExists(table.ModifyTableEntry(ternary(value), true)))
+	assert.Equal(t, []byte{0x08, 0x06}, value)
+	assert.NoError(t, table.ModifyTableEntry(ternary([]byte{0x08, 0xdd}), false))
+	assert.Equal(t, []byte{0x08, 0x00}, table.Entries()[0].Match[0].GetTernary().Value)
+	assert.NoError(t, table.RemoveTableEntry(ternary([]byte{0x08, 0x42})))
+	assert.Equal(t, 0, table.Size())
+
+	// When rejecting, such entries are refused instead
+	table.SetTernaryPolicy(TernaryReject)
+	err := table.ModifyTableEntry(ternary([]byte{0x08, 0x06}), true)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "outside of its mask")
+	assert.NoError(t, table.ModifyTableEntry(ternary([]byte{0x08, 0x00}), true))
+}
+
+func TestTableSizeLimit(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "exact"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+			{Id: 2, Name: "f2", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		},
+		Size: 4,
+	}})
+	table := tables.Table(1)
+	assert.NoError(t, table.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+	for i := byte(0); i < 4; i++ {
+		assert.NoError(t, table.ModifyTableEntry(exactEntry(i, 0), true))
+	}
+
+	// Inserts beyond the declared size are rejected, but modifies of existing entries are not
+	err := table.ModifyTableEntry(exactEntry(4, 0), true)
+	assert.True(t, IsResourceExhausted(err))
+	assert.Equal(t, codes.ResourceExhausted, ErrorStatus(err).Code())
+	assert.Contains(t, err.Error(), "resource exhausted")
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(3, 0), false))
+	assert.Equal(t, 5, table.Size())
+
+	// Removing an entry frees up space
+	assert.NoError(t, table.RemoveTableEntry(exactEntry(0, 0)))
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(4, 0), true))
+}
+
+func TestReadByCookieMask(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+
+	// Cookies carry the application ID in the high 16 bits and a flow ID in the low 16 bits
+	tagged := func(v1 byte, app byte, flow byte) *p4api.TableEntry {
+		entry := exactEntry(v1, 0)
+		entry.Metadata = []byte{0, app, 0, flow}
+		return entry
+	}
+	assert.NoError(t, table.ModifyTableEntry(tagged(1, 1, 1), true))
+	assert.NoError(t, table.ModifyTableEntry(tagged(2, 1, 2), true))
+	assert.NoError(t, table.ModifyTableEntry(tagged(3, 2, 1), true))
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(4, 0), true))
+	short := exactEntry(5, 0)
+	short.Metadata = []byte{0x07}
+	assert.NoError(t, table.ModifyTableEntry(short, true))
+
+	read := func(cookie []byte, mask []byte) []byte {
+		keys := make([]byte, 0)
+		assert.NoError(t, table.ReadByCookieMask(cookie, mask, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				keys = append(keys, entity.GetTableEntry().Match[0].GetExact().Value[0])
+			}
+			return nil
+		}))
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		return keys
+	}
+	appMask := []byte{0xff, 0xff, 0, 0}
+	assert.Equal(t, []byte{1, 2}, read([]byte{0, 1, 0, 0}, appMask))
+	assert.Equal(t, []byte{3}, read([]byte{0, 2, 0, 0}, appMask))
+	assert.Equal(t, []byte{1, 3}, read([]byte{0, 0, 0, 1}, []byte{0, 0, 0xff, 0xff}))
+	assert.Equal(t, []byte{2}, read([]byte{0, 1, 0, 2}, []byte{0xff, 0xff, 0xff, 0xff}))
+
+	// Entries without metadata, or with shorter metadata, are compared as if padded with zeros
+	assert.Equal(t, []byte{4, 5}, read([]byte{0, 0, 0, 0}, appMask))
+	assert.Equal(t, []byte{5}, read([]byte{0, 0, 0, 7}, []byte{0, 0, 0, 0xff}))
+	assert.Equal(t, []byte{1, 2, 3, 4, 5}, read([]byte{}, []byte{}))
+
+	err := table.ReadByCookieMask([]byte{1}, appMask, func(entities []*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestTableSlotCost(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "eth_type", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+			{Id: 2, Name: "ipv6_dst", Bitwidth: 128, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+		},
+		Size: 8,
+	}})
+	table := tables.Table(1)
+	table.SetSlotWidth(80)
+	ternary := func(id uint32, width int, v byte) *p4api.FieldMatch {
+		value := make([]byte, width)
+		value[width-1] = v
+		mask := make([]byte, width)
+		mask[width-1] = 0xff
+		return &p4api.FieldMatch{FieldId: id, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: value, Mask: mask}}}
+	}
+	narrow := func(v byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Priority: 10, Match: []*p4api.FieldMatch{ternary(1, 2, v)}}
+	}
+	wide := func(v byte) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Priority: 10, Match: []*p4api.FieldMatch{ternary(1, 2, v), ternary(2, 16, v)}}
+	}
+
+	// Narrow entries take a single slot and wide entries, 144 bits, take two
+	assert.NoError(t, table.ModifyTableEntry(narrow(1), true))
+	assert.NoError(t, table.ModifyTableEntry(narrow(2), true))
+	assert.Equal(t, int64(2), table.SlotsUsed())
+	for i := byte(1); i <= 3; i++ {
+		assert.NoError(t, table.ModifyTableEntry(wide(i), true))
+	}
+	assert.Equal(t, int64(8), table.SlotsUsed())
+	assert.Equal(t, 5, table.Size())
+
+	// The budget is exhausted for both wide and narrow entries
+	assert.True(t, IsResourceExhausted(table.ModifyTableEntry(wide(4), true)))
+	assert.True(t, IsResourceExhausted(table.ModifyTableEntry(narrow(4), true)))
+
+	// Removing a wide entry releases both of its slots; modifies do not consume any
+	assert.NoError(t, table.RemoveTableEntry(wide(1)))
+	assert.Equal(t, int64(6), table.SlotsUsed())
+	assert.NoError(t, table.ModifyTableEntry(wide(2), false))
+	assert.NoError(t, table.ModifyTableEntry(narrow(4), true))
+	assert.NoError(t, table.ModifyTableEntry(narrow(5), true))
+	assert.True(t, IsResourceExhausted(table.ModifyTableEntry(narrow(6), true)))
+	assert.Equal(t, int64(8), tables.Stats()[1].Slots)
+
+	// Disabling the slot model makes every entry consume a single slot
+	table.SetSlotWidth(0)
+	assert.Equal(t, int64(6), table.SlotsUsed())
+	assert.NoError(t, table.ModifyTableEntry(narrow(6), true))
+}
+
+func TestReadBatchSize(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(0); i < 10; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(i, i), 1), true))
+	}
+	batches := func(request *p4api.TableEntry) []int {
+		sizes := make([]int, 0)
+		assert.NoError(t, tables.ReadTableEntries(request, ReadTableEntry, func(entities []*p4api.Entity) error {
+			sizes = append(sizes, len(entities))
+			return nil
+		}))
+		return sizes
+	}
+
+	// The default batch size fits all entries in a single batch
+	assert.Equal(t, []int{10}, batches(&p4api.TableEntry{TableId: 1}))
+
+	// A read of N entries with batch size B yields ceil(N/B) batches
+	for _, c := range []struct {
+		size    int
+		batches []int
+	}{
+		{3, []int{3, 3, 3, 1}},
+		{5, []int{5, 5}},
+		{1, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+		{10, []int{10}},
+		{0, []int{10}},
+	} {
+		assert.NoError(t, tables.SetBatchSize(c.size))
+		assert.Equal(t, c.batches, batches(&p4api.TableEntry{TableId: 1}), "batch size %d", c.size)
+	}
+
+	// Empty reads still yield a single empty batch
+	assert.NoError(t, tables.SetBatchSize(4))
+	assert.Equal(t, []int{0}, batches(&p4api.TableEntry{TableId: 1, Priority: 7}))
+	assert.Equal(t, 10, table.Size())
+	assert.True(t, errors.IsInvalid(tables.SetBatchSize(-1)))
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	tables := newExactTables()
+	tables.SetActions(testActions)
+	table := tables.Table(1)
+	table.EnableReadCache(16)
+
+	// Writers insert, modify, count and remove entries of their own while readers read and look up all entries
+	// and the table is reconfigured; run with -race to detect unsynchronized accesses
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			table.SetSlotWidth(0)
+			table.SetCounterPollInterval(0)
+			table.SetOverlapCheck(false)
+		}
+	}()
+	for w := byte(0); w < 4; w++ {
+		wg.Add(1)
+		go func(w byte) {
+			defer wg.Done()
+			for i := byte(0); i < 50; i++ {
+				assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(w, i), 1), true))
+				assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(w, i), 2), false))
+				assert.NoError(t, table.IncrementDirectCounter(exactEntry(w, i), 1, 100))
+				if i%2 == 0 {
+					assert.NoError(t, tables.RemoveTableEntry(exactEntry(w, i)))
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := byte(0); i < 50; i++ {
+				assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, func(entities []*p4api.Entity) error {
+					for _, entity := range entities {
+						assert.NotNil(t, entity.GetDirectCounterEntry().Data)
+					}
+					return nil
+				}))
+				_, err := table.Lookup(FieldValues{1: {0}, 2: {i}})
+				assert.NoError(t, err)
+				assert.True(t, table.Stats().Entries <= 4*50)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each writer left its odd entries, each counted once
+	assert.Equal(t, 4*25, table.Size())
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, func(entities []*p4api.Entity) error {
+		for _, entity := range entities {
+			assert.Equal(t, int64(1), entity.GetDirectCounterEntry().Data.PacketCount)
+		}
+		return nil
+	}))
+}
+
+func TestConcurrentCounterAccess(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for i := byte(0); i < 10; i++ {
+		assert.NoError(t, tables.ModifyTableEntry(exactEntry(0, i), true))
+	}
+
+	// Writers reset and bulk modify the direct counters while readers read them; run with -race to detect
+	// unsynchronized accesses
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := byte(0); i < 50; i++ {
+			assert.NoError(t, table.ResetDirectCounterEntry(&p4api.DirectCounterEntry{TableEntry: exactEntry(0, i%10)}))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := int64(0); i < 50; i++ {
+			skipped, err := table.BulkModifyDirectCounters([]*p4api.DirectCounterEntry{
+				{TableEntry: exactEntry(0, 1), Data: &p4api.CounterData{PacketCount: i}},
+				{TableEntry: exactEntry(0, 2), Data: &p4api.CounterData{PacketCount: i}},
+			})
+			assert.NoError(t, err)
+			assert.Empty(t, skipped)
+		}
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_, err := table.ReadTableEntriesLimited(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, 5, func([]*p4api.Entity) error { return nil })
+				assert.NoError(t, err)
+				assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{TableId: 1}, ReadDirectCounter, func([]*p4api.Entity) error { return nil }))
+				table.DefaultActionOverridesConst()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, table.Size())
+}
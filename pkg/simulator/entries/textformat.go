@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Headers of the schema and entries sections of the text format export of a table
+const (
+	schemaTextHeader  = "# proto-message: p4.config.v1.P4Info\n"
+	entriesTextHeader = "# proto-message: p4.v1.ReadResponse\n"
+)
+
+// ExportText exports the table as protobuf text format in two sections: the P4 info schema of the table, along with
+// its direct counter and meter, if any, followed by the table entries, with their direct counter data and meter
+// configs, as the entities of a read response. Entries are ordered by descending priority and then by their keys,
+// followed by the default entry, if any, so that exports of tables with the same entries are identical.
+func (t *Table) ExportText() ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	schema := &p4info.P4Info{Tables: []*p4info.Table{t.info}}
+	if t.directCounter != nil {
+		schema.DirectCounters = []*p4info.DirectCounter{t.directCounter}
+	}
+	if t.directMeter != nil {
+		schema.DirectMeters = []*p4info.DirectMeter{t.directMeter}
+	}
+
+	t.pollCounters()
+	rows := t.orderedRows()
+	if t.defaultRow != nil {
+		rows = append(rows, t.defaultRow)
+	}
+	response := &p4api.ReadResponse{Entities: make([]*p4api.Entity, 0, len(rows))}
+	for _, row := range rows {
+		response.Entities = append(response.Entities, &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: t.exportEntry(row)}})
+	}
+
+	options := prototext.MarshalOptions{Multiline: true}
+	schemaText, err := options.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	entriesText, err := options.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	text.WriteString(schemaTextHeader)
+	text.Write(schemaText)
+	text.WriteString(entriesTextHeader)
+	text.Write(entriesText)
+	return text.Bytes(), nil
+}
+
+// Returns a copy of the row entry carrying the state of the direct resources of the table
+func (t *Table) exportEntry(row *Row) *p4api.TableEntry {
+	entry := proto.Clone(t.formatEntry(row.entry)).(*p4api.TableEntry)
+	entry.CounterData = nil
+	entry.MeterConfig = nil
+	if t.directCounter != nil {
+		entry.CounterData = t.directCounterData(row.counterData)
+	}
+	if t.directMeter != nil {
+		entry.MeterConfig = row.meterConfig
+	}
+	return entry
+}
+
+// ImportTableText creates a table from the given text format export, as produced by ExportText, and installs the
+// exported entries in it; the table replaces any table with the same ID only if all entries were installed
+func (ts *Tables) ImportTableText(text []byte) (*Table, error) {
+	// Comments, e.g. license headers, may precede the schema section
+	s, i := bytes.Index(text, []byte(schemaTextHeader)), bytes.Index(text, []byte(entriesTextHeader))
+	if s < 0 || i < s {
+		return nil, errors.NewInvalid("text is not a table export")
+	}
+	schema := &p4info.P4Info{}
+	if err := prototext.Unmarshal(text[s:i], schema); err != nil {
+		return nil, errors.NewInvalid("invalid table schema: %v", err)
+	}
+	response := &p4api.ReadResponse{}
+	if err := prototext.Unmarshal(text[i:], response); err != nil {
+		return nil, errors.NewInvalid("invalid table entries: %v", err)
+	}
+	if len(schema.Tables) != 1 {
+		return nil, errors.NewInvalid("table export must declare exactly one table; found %d", len(schema.Tables))
+	}
+
+	table := ts.NewTable(schema.Tables[0])
+	for _, dc := range schema.DirectCounters {
+		if dc.DirectTableId == table.ID() {
+			table.directCounter = dc
+		}
+	}
+	for _, dm := range schema.DirectMeters {
+		if dm.DirectTableId == table.ID() {
+			table.directMeter = dm
+		}
+	}
+	for _, entity := range response.Entities {
+		entry := entity.GetTableEntry()
+		if entry == nil || entry.TableId != table.ID() {
+			return nil, errors.NewInvalid("entity is not an entry of table %s: %v", table.Name(), entity)
+		}
+		if err := table.modifyTableEntry(entry, !entry.IsDefaultAction); err != nil {
+			return nil, err
+		}
+	}
+	ts.tables[table.ID()] = table
+	ts.indexNames()
+	return table, nil
+}
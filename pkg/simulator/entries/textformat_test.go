@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Creates the exact tables populated with the entries of the golden text format export
+func newExportedTables(t *testing.T) *Tables {
+	tables := newExactTables()
+	for i := byte(1); i <= 3; i++ {
+		entry := exactEntry(i, i+1)
+		entry.Action = directAction(1, i)
+		assert.NoError(t, tables.ModifyTableEntry(entry, true))
+	}
+	assert.NoError(t, tables.ModifyDirectCounterEntry(&p4api.DirectCounterEntry{
+		TableEntry: exactEntry(2, 3), Data: &p4api.CounterData{PacketCount: 5, ByteCount: 320}}, false))
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 9)}, false))
+	return tables
+}
+
+func TestExportTextRoundTrip(t *testing.T) {
+	tables := newExportedTables(t)
+	text, err := tables.Table(1).ExportText()
+	assert.NoError(t, err)
+
+	imported := NewTables(nil)
+	table, err := imported.ImportTableText(text)
+	assert.NoError(t, err)
+	assert.Equal(t, table, imported.Table(1))
+	assert.Equal(t, 4, table.Size())
+	assert.True(t, proto.Equal(tables.Table(1).info, table.info))
+	assert.NotNil(t, table.directCounter)
+	assert.NotNil(t, table.directMeter)
+
+	lr, err := table.Lookup(FieldValues{1: {2}, 2: {3}})
+	assert.NoError(t, err)
+	assert.True(t, lr.Hit)
+	assert.Equal(t, []byte{2}, lr.Action.Params[0].Value)
+	key := mustKey(t, table, exactEntry(2, 3))
+	assert.Equal(t, int64(5), table.rows[key].counterData.PacketCount)
+	assert.Equal(t, []byte{9}, table.DefaultEntry().Action.GetAction().Params[0].Value)
+
+	// Exporting the imported table reproduces the original export
+	again, err := table.ExportText()
+	assert.NoError(t, err)
+	assert.Equal(t, string(text), string(again))
+
+	_, err = imported.ImportTableText([]byte("tables {}"))
+	assert.True(t, errors.IsInvalid(err))
+	schema := string(text[:strings.Index(string(text), entriesTextHeader)])
+	_, err = imported.ImportTableText([]byte(schema + entriesTextHeader + "entities { table_entry { table_id: 2 } }"))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestExportTextGolden(t *testing.T) {
+	text, err := newExportedTables(t).Table(1).ExportText()
+	assert.NoError(t, err)
+	golden, err := os.ReadFile("testdata/exact_table.textproto")
+	assert.NoError(t, err)
+
+	// The protobuf text format does not guarantee stable whitespace, so compare the exports token by token, skipping
+	// the license header of the golden file
+	export := string(golden[strings.Index(string(golden), schemaTextHeader):])
+	assert.Equal(t, strings.Fields(export), strings.Fields(string(text)))
+
+	// The golden file imports as the original table
+	table, err := NewTables(nil).ImportTableText(golden)
+	assert.NoError(t, err)
+	again, err := table.ExportText()
+	assert.NoError(t, err)
+	assert.Equal(t, string(text), string(again))
+}
@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"bytes"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ByteOrder specifies the byte order in which values are presented to tooling; values are always stored
+// in the canonical P4Runtime big-endian form
+type ByteOrder byte
+
+const (
+	// BigEndian is the canonical P4Runtime byte order
+	BigEndian ByteOrder = iota
+	// LittleEndian presents values with their least significant byte first
+	LittleEndian
+)
+
+// ConvertByteOrder returns a copy of the value converted from one byte order to the other
+func ConvertByteOrder(value []byte, from ByteOrder, to ByteOrder) []byte {
+	converted := make([]byte, len(value))
+	copy(converted, value)
+	if from != to {
+		for i, j := 0, len(converted)-1; i < j; i, j = i+1, j-1 {
+			converted[i], converted[j] = converted[j], converted[i]
+		}
+	}
+	return converted
+}
+
+// DecodeValue decodes the value presented in the given byte order as an unsigned integer; only the least
+// significant 8 bytes are considered
+func DecodeValue(value []byte, order ByteOrder) uint64 {
+	be := ConvertByteOrder(value, order, BigEndian)
+	v := uint64(0)
+	for _, b := range be {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// EncodeValue encodes the unsigned integer as a value of the specified width in bytes in the given byte order
+func EncodeValue(v uint64, width int, order ByteOrder) []byte {
+	be := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		be[i] = byte(v & 0xff)
+		v >>= 8
+	}
+	return ConvertByteOrder(be, BigEndian, order)
+}
+
+// FieldMatchInByteOrder returns a copy of the canonical field match with its values presented in the given byte order
+func FieldMatchInByteOrder(m *p4api.FieldMatch, order ByteOrder) *p4api.FieldMatch {
+	fm := proto.Clone(m).(*p4api.FieldMatch)
+	convert := func(v []byte) []byte { return ConvertByteOrder(v, BigEndian, order) }
+	switch {
+	case fm.GetExact() != nil:
+		fm.GetExact().Value = convert(fm.GetExact().Value)
+	case fm.GetLpm() != nil:
+		fm.GetLpm().Value = convert(fm.GetLpm().Value)
+	case fm.GetTernary() != nil:
+		fm.GetTernary().Value = convert(fm.GetTernary().Value)
+		fm.GetTernary().Mask = convert(fm.GetTernary().Mask)
+	case fm.GetRange() != nil:
+		fm.GetRange().Low = convert(fm.GetRange().Low)
+		fm.GetRange().High = convert(fm.GetRange().High)
+	case fm.GetOptional() != nil:
+		fm.GetOptional().Value = convert(fm.GetOptional().Value)
+	}
+	return fm
+}
+
+// ActionInByteOrder returns a copy of the canonical action with its parameter values presented in the given byte order
+func ActionInByteOrder(action *p4api.Action, order ByteOrder) *p4api.Action {
+	a := proto.Clone(action).(*p4api.Action)
+	for _, param := range a.Params {
+		param.Value = ConvertByteOrder(param.Value, BigEndian, order)
+	}
+	return a
+}
+
+// ByteStringFormat specifies the form in which match field and action parameter values are returned by reads
+type ByteStringFormat byte
+
+const (
+	// CanonicalByteStrings returns values without leading zero bytes, with zero represented by a single zero byte
+	CanonicalByteStrings ByteStringFormat = iota
+	// PaddedByteStrings returns values left-padded with zero bytes to the declared bitwidth
+	PaddedByteStrings
+)
+
+// CanonicalValue returns the value without its leading zero bytes; zero is represented by a single zero byte
+func CanonicalValue(value []byte) []byte {
+	i := 0
+	for i < len(value)-1 && value[i] == 0 {
+		i++
+	}
+	if len(value) == 0 {
+		return []byte{0}
+	}
+	return value[i:]
+}
+
+// PaddedValue returns the canonical value left-padded with zero bytes to the specified width in bytes
+func PaddedValue(value []byte, width int) []byte {
+	return padTo(CanonicalValue(value), width)
+}
+
+// SetByteStringFormat sets the form in which match field and action parameter values are returned by reads of
+// all tables; values are stored as written
+func (ts *Tables) SetByteStringFormat(format ByteStringFormat) {
+	ts.byteStringFormat = format
+	for _, table := range ts.tables {
+		table.mutated()
+	}
+}
+
+// Returns the entry with its match field and action parameter values in the configured byte string format; the
+// entry is copied only if some of its values need to be changed
+func (t *Table) formatEntry(entry *p4api.TableEntry) *p4api.TableEntry {
+	if !t.visitEntryValues(entry, false) {
+		return entry
+	}
+	formatted := proto.Clone(entry).(*p4api.TableEntry)
+	t.visitEntryValues(formatted, true)
+	return formatted
+}
+
+// Visits the match field and action parameter values of the entry, either rewriting them in the configured byte
+// string format or merely checking if they are in that format; returns true if any value is not in the format
+func (t *Table) visitEntryValues(entry *p4api.TableEntry, rewrite bool) bool {
+	changed := false
+	visit := func(value *[]byte, width int) {
+		formatted := CanonicalValue(*value)
+		if t.tables.byteStringFormat == PaddedByteStrings {
+			formatted = padTo(formatted, width)
+		}
+		if !bytes.Equal(formatted, *value) {
+			changed = true
+			if rewrite {
+				*value = formatted
+			}
+		}
+	}
+	for _, m := range entry.Match {
+		width := t.fieldWidth(m.FieldId)
+		switch {
+		case m.GetExact() != nil:
+			visit(&m.GetExact().Value, width)
+		case m.GetLpm() != nil:
+			visit(&m.GetLpm().Value, width)
+		case m.GetTernary() != nil:
+			visit(&m.GetTernary().Value, width)
+			visit(&m.GetTernary().Mask, width)
+		case m.GetRange() != nil:
+			visit(&m.GetRange().Low, width)
+			visit(&m.GetRange().High, width)
+		case m.GetOptional() != nil:
+			visit(&m.GetOptional().Value, width)
+		}
+	}
+	actions := make([]*p4api.Action, 0, 1)
+	if action := entry.Action.GetAction(); action != nil {
+		actions = append(actions, action)
+	}
+	for _, a := range entry.Action.GetActionProfileActionSet().GetActionProfileActions() {
+		if a.Action != nil {
+			actions = append(actions, a.Action)
+		}
+	}
+	for _, action := range actions {
+		for _, param := range action.Params {
+			visit(&param.Value, t.paramWidth(action.ActionId, param.ParamId))
+		}
+	}
+	return changed
+}
+
+// Returns the width in bytes of the specified action parameter, or 0 if not known
+func (t *Table) paramWidth(actionID uint32, paramID uint32) int {
+	if info, ok := t.tables.actions[actionID]; ok {
+		for _, param := range info.Params {
+			if param.Id == paramID {
+				return int(param.Bitwidth+7) / 8
+			}
+		}
+	}
+	return 0
+}
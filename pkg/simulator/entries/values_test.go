@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestByteOrderConversion(t *testing.T) {
+	be := []byte{0x01, 0x02, 0x03}
+	le := ConvertByteOrder(be, BigEndian, LittleEndian)
+	assert.Equal(t, []byte{0x03, 0x02, 0x01}, le)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, be)
+	assert.Equal(t, be, ConvertByteOrder(le, LittleEndian, BigEndian))
+	assert.Equal(t, be, ConvertByteOrder(be, BigEndian, BigEndian))
+
+	assert.Equal(t, uint64(0x010203), DecodeValue(be, BigEndian))
+	assert.Equal(t, uint64(0x010203), DecodeValue(le, LittleEndian))
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0x03}, EncodeValue(0x010203, 4, BigEndian))
+	assert.Equal(t, []byte{0x03, 0x02, 0x01, 0x00}, EncodeValue(0x010203, 4, LittleEndian))
+}
+
+func TestByteOrderLeavesStoredFormUnchanged(t *testing.T) {
+	tables := newExactTables()
+	entry := exactEntry(1, 2)
+	entry.Match[0].GetExact().Value = []byte{0x0a, 0x0b}
+	entry.Action = &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{
+		ActionId: 1,
+		Params:   []*p4api.Action_Param{{ParamId: 1, Value: []byte{0x01, 0x00}}},
+	}}}
+	assert.NoError(t, tables.ModifyTableEntry(entry, true))
+
+	var stored *p4api.TableEntry
+	assert.NoError(t, tables.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+		stored = entities[0].GetTableEntry()
+		return nil
+	}))
+
+	fm := FieldMatchInByteOrder(stored.Match[0], LittleEndian)
+	assert.Equal(t, []byte{0x0b, 0x0a}, fm.GetExact().Value)
+	action := ActionInByteOrder(stored.Action.GetAction(), LittleEndian)
+	assert.Equal(t, []byte{0x00, 0x01}, action.Params[0].Value)
+	assert.Equal(t, uint64(0x100), DecodeValue(action.Params[0].Value, LittleEndian))
+
+	// The stored canonical form must remain big-endian
+	assert.Equal(t, []byte{0x0a, 0x0b}, stored.Match[0].GetExact().Value)
+	assert.Equal(t, []byte{0x01, 0x00}, stored.Action.GetAction().Params[0].Value)
+
+	ternary := &p4api.FieldMatch{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Ternary_{
+		Ternary: &p4api.FieldMatch_Ternary{Value: []byte{1, 2}, Mask: []byte{0xff, 0x00}}}}
+	fm = FieldMatchInByteOrder(ternary, LittleEndian)
+	assert.Equal(t, []byte{2, 1}, fm.GetTernary().Value)
+	assert.Equal(t, []byte{0x00, 0xff}, fm.GetTernary().Mask)
+	assert.Equal(t, []byte{1, 2}, ternary.GetTernary().Value)
+}
+
+func TestByteStringFormat(t *testing.T) {
+	assert.Equal(t, []byte{0x01, 0x02}, CanonicalValue([]byte{0x00, 0x00, 0x01, 0x02}))
+	assert.Equal(t, []byte{0x00}, CanonicalValue([]byte{0x00, 0x00}))
+	assert.Equal(t, []byte{0x00}, CanonicalValue(nil))
+	assert.Equal(t, []byte{0x00, 0x00, 0x01}, PaddedValue([]byte{0x01}, 3))
+	assert.Equal(t, []byte{0x00, 0x01}, PaddedValue([]byte{0x00, 0x00, 0x01}, 2))
+
+	tables := newExactTables()
+	tables.SetActions(testActions)
+	table := tables.Table(1)
+	entry := &p4api.TableEntry{
+		TableId: 1,
+		Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{0x00, 0x05}}}},
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{0x00}}}},
+		},
+		Action: &p4api.TableAction{Type: &p4api.TableAction_Action{Action: &p4api.Action{
+			ActionId: 1, Params: []*p4api.Action_Param{{ParamId: 1, Value: []byte{0x00, 0x03}}}}}},
+	}
+	assert.NoError(t, table.ModifyTableEntry(entry, true))
+
+	read := func() *p4api.TableEntry {
+		var result *p4api.TableEntry
+		assert.NoError(t, table.ReadTableEntries(&p4api.TableEntry{}, ReadTableEntry, func(entities []*p4api.Entity) error {
+			result = entities[0].GetTableEntry()
+			return nil
+		}))
+		return result
+	}
+
+	// By default, values are returned in canonical form
+	canonical := read()
+	assert.Equal(t, []byte{0x05}, canonical.Match[0].GetExact().Value)
+	assert.Equal(t, []byte{0x00}, canonical.Match[1].GetExact().Value)
+	assert.Equal(t, []byte{0x03}, canonical.Action.GetAction().Params[0].Value)
+
+	// Optionally, values are returned padded to their bitwidth
+	tables.SetByteStringFormat(PaddedByteStrings)
+	padded := read()
+	assert.Equal(t, []byte{0x00, 0x05}, padded.Match[0].GetExact().Value)
+	assert.Equal(t, []byte{0x00, 0x00}, padded.Match[1].GetExact().Value)
+	assert.Equal(t, []byte{0x00, 0x03}, padded.Action.GetAction().Params[0].Value)
+
+	// Values are stored as written
+	assert.Equal(t, []byte{0x00, 0x05}, entry.Match[0].GetExact().Value)
+	assert.Equal(t, []byte{0x00}, entry.Match[1].GetExact().Value)
+
+	// Entries already in the requested form are returned as they are
+	tables.SetByteStringFormat(CanonicalByteStrings)
+	assert.NoError(t, table.ModifyTableEntry(exactEntry(7, 8), true))
+	row, ok := table.exactRow(exactEntry(7, 8))
+	assert.True(t, ok)
+	assert.Same(t, row.entry, table.formatEntry(row.entry))
+}
+
+func TestCanonicalEntryKeys(t *testing.T) {
+	tables := NewTables([]*p4info.Table{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "acl"},
+		MatchFields: []*p4info.MatchField{
+			{Id: 1, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}},
+			{Id: 2, Name: "l4_port", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_RANGE}},
+			{Id: 3, Name: "vlan_id", Bitwidth: 12, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_OPTIONAL}},
+		},
+	}})
+	table := tables.Table(1)
+	entry := func(width int) *p4api.TableEntry {
+		return &p4api.TableEntry{TableId: 1, Priority: 10, Match: []*p4api.FieldMatch{
+			{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Lpm{Lpm: &p4api.FieldMatch_LPM{Value: PaddedValue([]byte{10, 0, 0}, width+2), PrefixLen: 16}}},
+			{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Range_{Range: &p4api.FieldMatch_Range{Low: PaddedValue([]byte{1}, width), High: PaddedValue([]byte{2}, width)}}},
+			{FieldId: 3, FieldMatchType: &p4api.FieldMatch_Optional_{Optional: &p4api.FieldMatch_Optional{Value: PaddedValue([]byte{5}, width)}}},
+		}}
+	}
+
+	// Numerically equal values with and without leading zeros address the same entry
+	assert.NoError(t, table.ModifyTableEntry(entry(2), true))
+	assert.True(t, errors.IsAlreadyExists(table.ModifyTableEntry(entry(1), true)))
+	exact := newExactTables().Table(1)
+	assert.NoError(t, exact.ModifyTableEntry(exactEntry(1, 2), true))
+	padded := exactEntry(1, 2)
+	padded.Match[0].GetExact().Value = []byte{0x00, 0x01}
+	assert.True(t, errors.IsAlreadyExists(exact.ModifyTableEntry(padded, true)))
+	assert.Equal(t, 1, exact.Size())
+	assert.NoError(t, table.ModifyTableEntry(entry(1), false))
+	assert.NoError(t, table.RemoveTableEntry(entry(1)))
+	assert.Equal(t, 0, table.Size())
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"sort"
+)
+
+// ValueSet represents a P4 parser value set and its members
+type ValueSet struct {
+	info *p4info.ValueSet
+	// Table holding the value set match spec in canonical order, used to validate the member matches
+	schema *Table
+	entry  *p4api.ValueSetEntry
+}
+
+// ValueSets represents a set of P4 parser value sets
+type ValueSets struct {
+	valueSets map[uint32]*ValueSet
+
+	batching
+}
+
+// NewValueSets creates a new value sets store
+func NewValueSets(info []*p4info.ValueSet) *ValueSets {
+	vss := &ValueSets{
+		valueSets: make(map[uint32]*ValueSet, len(info)),
+	}
+	for _, vi := range info {
+		vss.valueSets[vi.Preamble.Id] = vss.NewValueSet(vi)
+	}
+	return vss
+}
+
+// NewValueSet creates a new value set without any members
+func (vss *ValueSets) NewValueSet(info *p4info.ValueSet) *ValueSet {
+	fields := append([]*p4info.MatchField{}, info.Match...)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Id < fields[j].Id })
+	return &ValueSet{
+		info:   info,
+		schema: &Table{info: &p4info.Table{Preamble: info.Preamble, MatchFields: fields}},
+		entry:  &p4api.ValueSetEntry{ValueSetId: info.Preamble.Id},
+	}
+}
+
+// ValueSets returns the list of value sets
+func (vss *ValueSets) ValueSets() []*ValueSet {
+	valueSets := make([]*ValueSet, 0, len(vss.valueSets))
+	for _, vs := range vss.valueSets {
+		valueSets = append(valueSets, vs)
+	}
+	return valueSets
+}
+
+// ModifyValueSetEntry replaces the members of the specified value set with the members of the given entry; the
+// member matches are validated against the value set match spec and the members may not exceed the value set size
+func (vss *ValueSets) ModifyValueSetEntry(entry *p4api.ValueSetEntry, insert bool) error {
+	if insert {
+		return errors.NewInvalid("value set cannot be inserted")
+	}
+
+	vs, ok := vss.valueSets[entry.ValueSetId]
+	if !ok {
+		return errors.NewNotFound("value set not found")
+	}
+	if vs.info.Size > 0 && len(entry.Members) > int(vs.info.Size) {
+		return NewResourceExhausted("resource exhausted: %v", entry)
+	}
+	for _, member := range entry.Members {
+		if err := vs.validateMember(member); err != nil {
+			return err
+		}
+	}
+
+	vs.entry = entry
+	return nil
+}
+
+// Validates the member matches against the value set match spec, putting them in canonical order
+func (vs *ValueSet) validateMember(member *p4api.ValueSetMember) error {
+	if err := vs.schema.canonicalizeMatches(&p4api.TableEntry{Match: member.Match}); err != nil {
+		return err
+	}
+	j := 0
+	for _, m := range member.Match {
+		for j < len(vs.schema.info.MatchFields) && vs.schema.info.MatchFields[j].Id < m.FieldId {
+			j++
+		}
+		if err := vs.schema.validateMatch(j, m); err != nil {
+			return err
+		}
+		j++
+	}
+	return nil
+}
+
+// ReadValueSetEntries reads the specified value set entry; value set ID of 0 reads all value sets
+func (vss *ValueSets) ReadValueSetEntries(request *p4api.ValueSetEntry, sender BatchSender) error {
+	buffer := vss.newBuffer(sender)
+	if request.ValueSetId == 0 {
+		for _, vs := range vss.valueSets {
+			if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_ValueSetEntry{ValueSetEntry: vs.entry}}); err != nil {
+				return err
+			}
+		}
+		return buffer.flush()
+	}
+
+	vs, ok := vss.valueSets[request.ValueSetId]
+	if !ok {
+		return errors.NewNotFound("value set not found")
+	}
+	if err := buffer.sendEntity(&p4api.Entity{Entity: &p4api.Entity_ValueSetEntry{ValueSetEntry: vs.entry}}); err != nil {
+		return err
+	}
+	return buffer.flush()
+}
+
+// ID returns the value set ID
+func (vs *ValueSet) ID() uint32 {
+	return vs.info.Preamble.Id
+}
+
+// Size returns the number of members of the value set
+func (vs *ValueSet) Size() int {
+	return len(vs.entry.Members)
+}
+
+// Name returns the value set name
+func (vs *ValueSet) Name() string {
+	return vs.info.Preamble.Name
+}
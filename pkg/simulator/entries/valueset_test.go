@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValueSets(t *testing.T) {
+	valueSets := NewValueSets([]*p4info.ValueSet{{
+		Preamble: &p4info.Preamble{Id: 1, Name: "udp_ports"},
+		Match: []*p4info.MatchField{
+			{Id: 2, Name: "tos", Bitwidth: 8, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_TERNARY}},
+			{Id: 1, Name: "dport", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}},
+		},
+		Size: 2,
+	}})
+	exact := &p4api.FieldMatch{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{0x12, 0xb5}}}}
+	ternary := &p4api.FieldMatch{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Ternary_{Ternary: &p4api.FieldMatch_Ternary{Value: []byte{0x10}, Mask: []byte{0xf0}}}}
+	valueSet := func(members ...[]*p4api.FieldMatch) *p4api.ValueSetEntry {
+		entry := &p4api.ValueSetEntry{ValueSetId: 1}
+		for _, match := range members {
+			entry.Members = append(entry.Members, &p4api.ValueSetMember{Match: match})
+		}
+		return entry
+	}
+
+	// Value sets can only be modified, with members complying with the match spec and within the size
+	assert.True(t, errors.IsInvalid(valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{exact}), true)))
+	assert.True(t, errors.IsNotFound(valueSets.ModifyValueSetEntry(&p4api.ValueSetEntry{ValueSetId: 2}, false)))
+	wrongType := &p4api.FieldMatch{FieldId: 2, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1}}}}
+	assert.True(t, errors.IsInvalid(valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{wrongType}), false)))
+	tooWide := &p4api.FieldMatch{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1, 2, 3}}}}
+	assert.True(t, errors.IsInvalid(valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{tooWide}), false)))
+	unknown := &p4api.FieldMatch{FieldId: 3, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{1}}}}
+	assert.True(t, errors.IsInvalid(valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{unknown}), false)))
+	assert.True(t, errors.IsInvalid(valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{exact, exact}), false)))
+	assert.True(t, IsResourceExhausted(valueSets.ModifyValueSetEntry(valueSet(
+		[]*p4api.FieldMatch{exact}, []*p4api.FieldMatch{ternary}, []*p4api.FieldMatch{exact, ternary}), false)))
+	assert.NoError(t, valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{ternary, exact}, []*p4api.FieldMatch{ternary}), false))
+	assert.Equal(t, 2, valueSets.ValueSets()[0].Size())
+
+	// Reads return the full member list; modifies replace it
+	read := func(valueSetID uint32) []*p4api.ValueSetEntry {
+		read := make([]*p4api.ValueSetEntry, 0)
+		assert.NoError(t, valueSets.ReadValueSetEntries(&p4api.ValueSetEntry{ValueSetId: valueSetID}, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				read = append(read, entity.GetValueSetEntry())
+			}
+			return nil
+		}))
+		return read
+	}
+	entries := read(0)
+	assert.Len(t, entries, 1)
+	assert.Len(t, entries[0].Members, 2)
+	assert.Equal(t, uint32(1), entries[0].Members[0].Match[0].FieldId)
+	assert.NoError(t, valueSets.ModifyValueSetEntry(valueSet([]*p4api.FieldMatch{exact}), false))
+	entries = read(1)
+	assert.Len(t, entries, 1)
+	assert.Len(t, entries[0].Members, 1)
+	err := valueSets.ReadValueSetEntries(&p4api.ValueSetEntry{ValueSetId: 2}, func([]*p4api.Entity) error { return nil })
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	"strings"
+)
+
+// VerifyP4Info checks consistency of the specified P4 info, i.e. that all entity IDs are unique and that all
+// references between entities (actions, action profiles, direct resources) are valid; returns an error
+// describing all problems found
+func VerifyP4Info(info *p4info.P4Info) error {
+	if info == nil {
+		return errors.NewInvalid("P4 info is missing")
+	}
+	problems := make([]string, 0)
+	problem := func(msg string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(msg, args...))
+	}
+
+	// Make sure that all entity IDs are unique
+	ids := make(map[uint32]string)
+	checkID := func(kind string, preamble *p4info.Preamble) {
+		if preamble == nil {
+			problem("%s without preamble", kind)
+			return
+		}
+		if other, ok := ids[preamble.Id]; ok {
+			problem("%s %s has the same ID %d as %s", kind, preamble.Name, preamble.Id, other)
+			return
+		}
+		ids[preamble.Id] = fmt.Sprintf("%s %s", kind, preamble.Name)
+	}
+
+	tables := make(map[uint32]*p4info.Table)
+	for _, t := range info.Tables {
+		checkID("table", t.Preamble)
+		if t.Preamble != nil {
+			tables[t.Preamble.Id] = t
+		}
+	}
+	actions := make(map[uint32]*p4info.Action)
+	for _, a := range info.Actions {
+		checkID("action", a.Preamble)
+		if a.Preamble != nil {
+			actions[a.Preamble.Id] = a
+		}
+	}
+	profiles := make(map[uint32]*p4info.ActionProfile)
+	for _, ap := range info.ActionProfiles {
+		checkID("action profile", ap.Preamble)
+		if ap.Preamble != nil {
+			profiles[ap.Preamble.Id] = ap
+		}
+	}
+	directResources := make(map[uint32]uint32)
+	directResourceIDs := make([]uint32, 0, len(info.DirectCounters)+len(info.DirectMeters))
+	for _, dc := range info.DirectCounters {
+		checkID("direct counter", dc.Preamble)
+		if dc.Preamble != nil {
+			directResources[dc.Preamble.Id] = dc.DirectTableId
+			directResourceIDs = append(directResourceIDs, dc.Preamble.Id)
+		}
+	}
+	for _, dm := range info.DirectMeters {
+		checkID("direct meter", dm.Preamble)
+		if dm.Preamble != nil {
+			directResources[dm.Preamble.Id] = dm.DirectTableId
+			directResourceIDs = append(directResourceIDs, dm.Preamble.Id)
+		}
+	}
+	for _, c := range info.Counters {
+		checkID("counter", c.Preamble)
+	}
+	for _, m := range info.Meters {
+		checkID("meter", m.Preamble)
+	}
+	for _, r := range info.Registers {
+		checkID("register", r.Preamble)
+	}
+	for _, d := range info.Digests {
+		checkID("digest", d.Preamble)
+	}
+	for _, vs := range info.ValueSets {
+		checkID("value set", vs.Preamble)
+	}
+
+	// Validate references from the tables, in the order of the P4 info so that the problems are reported
+	// deterministically
+	for _, t := range info.Tables {
+		if t.Preamble == nil {
+			continue
+		}
+		refs := make(map[uint32]bool)
+		for _, ref := range t.ActionRefs {
+			refs[ref.Id] = true
+			if _, ok := actions[ref.Id]; !ok {
+				problem("table %s references unknown action %d", t.Preamble.Name, ref.Id)
+			}
+		}
+		if t.ConstDefaultActionId != 0 && !refs[t.ConstDefaultActionId] {
+			problem("table %s has const default action %d not among its actions", t.Preamble.Name, t.ConstDefaultActionId)
+		}
+		if t.ImplementationId != 0 {
+			if _, ok := profiles[t.ImplementationId]; !ok {
+				problem("table %s references unknown action profile %d", t.Preamble.Name, t.ImplementationId)
+			}
+		}
+		for _, id := range t.DirectResourceIds {
+			if tid, ok := directResources[id]; !ok {
+				problem("table %s references unknown direct resource %d", t.Preamble.Name, id)
+			} else if tid != t.Preamble.Id {
+				problem("table %s references direct resource %d of table %d", t.Preamble.Name, id, tid)
+			}
+		}
+	}
+
+	// Validate references from the action profiles and direct resources back to the tables
+	for _, ap := range info.ActionProfiles {
+		if ap.Preamble == nil {
+			continue
+		}
+		for _, tid := range ap.TableIds {
+			t, ok := tables[tid]
+			if !ok {
+				problem("action profile %s references unknown table %d", ap.Preamble.Name, tid)
+			} else if t.ImplementationId != ap.Preamble.Id {
+				problem("action profile %s references table %s which is not implemented by it", ap.Preamble.Name, t.Preamble.Name)
+			}
+		}
+	}
+	for _, id := range directResourceIDs {
+		if tid := directResources[id]; tables[tid] == nil {
+			problem("direct resource %d references unknown table %d", id, tid)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.NewInvalid("invalid P4 info: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
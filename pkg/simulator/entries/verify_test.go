@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-net-lib/pkg/p4utils"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerifyP4Info(t *testing.T) {
+	info, err := p4utils.LoadP4Info("../../../pipelines/p4info.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyP4Info(info))
+	assert.Error(t, VerifyP4Info(nil))
+}
+
+func TestVerifyMalformedP4Info(t *testing.T) {
+	valid := func() *p4info.P4Info {
+		return &p4info.P4Info{
+			Tables: []*p4info.Table{{
+				Preamble:          &p4info.Preamble{Id: 1, Name: "t1"},
+				ActionRefs:        []*p4info.ActionRef{{Id: 10}},
+				ImplementationId:  20,
+				DirectResourceIds: []uint32{30},
+			}},
+			Actions:        []*p4info.Action{{Preamble: &p4info.Preamble{Id: 10, Name: "a10"}}},
+			ActionProfiles: []*p4info.ActionProfile{{Preamble: &p4info.Preamble{Id: 20, Name: "ap20"}, TableIds: []uint32{1}}},
+			DirectCounters: []*p4info.DirectCounter{{Preamble: &p4info.Preamble{Id: 30, Name: "dc30"}, DirectTableId: 1}},
+		}
+	}
+	assert.NoError(t, VerifyP4Info(valid()))
+
+	info := valid()
+	info.Actions = append(info.Actions, &p4info.Action{Preamble: &p4info.Preamble{Id: 1, Name: "dup"}})
+	assert.ErrorContains(t, VerifyP4Info(info), "same ID 1")
+
+	info = valid()
+	info.Tables[0].ActionRefs = append(info.Tables[0].ActionRefs, &p4info.ActionRef{Id: 11})
+	assert.ErrorContains(t, VerifyP4Info(info), "unknown action 11")
+
+	info = valid()
+	info.Tables[0].ConstDefaultActionId = 12
+	assert.ErrorContains(t, VerifyP4Info(info), "const default action 12")
+
+	info = valid()
+	info.Tables[0].ImplementationId = 21
+	err := VerifyP4Info(info)
+	assert.ErrorContains(t, err, "unknown action profile 21")
+	assert.ErrorContains(t, err, "not implemented by it")
+
+	info = valid()
+	info.ActionProfiles[0].TableIds = []uint32{2}
+	assert.ErrorContains(t, VerifyP4Info(info), "unknown table 2")
+
+	info = valid()
+	info.DirectCounters[0].DirectTableId = 3
+	err = VerifyP4Info(info)
+	assert.ErrorContains(t, err, "direct resource 30 of table 3")
+	assert.ErrorContains(t, err, "direct resource 30 references unknown table 3")
+
+	info = valid()
+	info.Tables[0].DirectResourceIds = []uint32{31}
+	assert.ErrorContains(t, VerifyP4Info(info), "unknown direct resource 31")
+
+	// Problems are reported in the order of the P4 info
+	info = valid()
+	for i := uint32(2); i <= 8; i++ {
+		info.Tables = append(info.Tables, &p4info.Table{
+			Preamble:   &p4info.Preamble{Id: i, Name: fmt.Sprintf("t%d", i)},
+			ActionRefs: []*p4info.ActionRef{{Id: 10 + i}},
+		})
+	}
+	expected := "invalid P4 info: "
+	for i := uint32(2); i <= 8; i++ {
+		if i > 2 {
+			expected += "; "
+		}
+		expected += fmt.Sprintf("table t%d references unknown action %d", i, 10+i)
+	}
+	for i := 0; i < 10; i++ {
+		assert.EqualError(t, VerifyP4Info(info), expected)
+	}
+}
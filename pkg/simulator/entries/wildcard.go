@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// LargeWildcardDelete is the number of entries above which a delete of all entries of a table is logged
+const LargeWildcardDelete = 1000
+
+// IsWildcard returns true if the given entry cannot address a single entry of the table and therefore stands for
+// all entries matching it, i.e. if it omits an exact match field of a table with only exact match fields, or if it
+// has no priority while the table requires one
+func (t *Table) IsWildcard(entry *p4api.TableEntry) bool {
+	if entry.IsDefaultAction {
+		return false
+	}
+	if entry.Priority == 0 && t.RequiresPriority() {
+		return true
+	}
+	present := make(map[uint32]bool, len(entry.Match))
+	for _, m := range entry.Match {
+		present[m.FieldId] = true
+	}
+	for _, field := range t.info.MatchFields {
+		if field.GetMatchType() != p4info.MatchField_EXACT {
+			return false
+		}
+	}
+	for _, field := range t.info.MatchFields {
+		if !present[field.Id] {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveMatchingEntries removes all entries matching the given wildcard entry with the same semantics as wildcard
+// reads, i.e. a request without field matches and priority removes all entries; the default entry is retained.
+// This is how RemoveTableEntry handles wildcard entries. Returns the number of removed entries.
+func (t *Table) RemoveMatchingEntries(request *p4api.TableEntry) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.removeMatchingEntries(request)
+}
+
+func (t *Table) removeMatchingEntries(request *p4api.TableEntry) (int, error) {
+	if err := t.canonicalizeMatches(request); err != nil {
+		return 0, err
+	}
+	if len(request.Match) == 0 && request.Priority == 0 && len(t.rows) > LargeWildcardDelete {
+		log.Warnf("Table %s: wildcard delete removes all of its %d entries", t.Name(), len(t.rows))
+	}
+	keys := t.matchingKeys(request)
+	for _, key := range keys {
+		t.deleteRow(key, t.rows[key])
+	}
+	return len(keys), nil
+}
+
+// Returns the keys of all rows whose entries match the given wildcard entry, in deterministic order
+func (t *Table) matchingKeys(request *p4api.TableEntry) []string {
+	keyed := make([]keyedRow, 0)
+	for key, row := range t.rows {
+		if t.tableEntryMatches(request, row.entry) {
+			keyed = append(keyed, keyedRow{key: key, row: row})
+		}
+	}
+	sortRows(keyed)
+	keys := make([]string, 0, len(keyed))
+	for _, kr := range keyed {
+		keys = append(keys, kr.key)
+	}
+	return keys
+}
+
+// Records the current state of all rows matching the given wildcard entry
+func (t *Table) recordWildcardUndo(request *p4api.TableEntry) Undo {
+	keys := t.matchingKeys(request)
+	saved := make(map[string]*Row, len(keys))
+	for _, key := range keys {
+		saved[key] = t.rows[key].checkpoint()
+	}
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		for _, key := range keys {
+			t.restoreRow(key, saved[key])
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entries
+
+import (
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWildcardDelete(t *testing.T) {
+	tables := newExactTables()
+	table := tables.Table(1)
+	for _, v := range [][2]byte{{1, 1}, {1, 2}, {2, 1}, {2, 2}} {
+		assert.NoError(t, tables.ModifyTableEntry(withAction(exactEntry(v[0], v[1]), 1), true))
+	}
+
+	// A complete entry is not a wildcard, a partial one is
+	assert.False(t, table.IsWildcard(exactEntry(1, 1)))
+	partial := exactEntry(1, 0)
+	partial.Match = partial.Match[:1]
+	assert.True(t, table.IsWildcard(partial))
+
+	// Delete all entries with f1 == 1
+	assert.NoError(t, tables.RemoveTableEntry(partial))
+	assert.Equal(t, 2, table.Size())
+	for _, entry := range table.Entries() {
+		assert.Equal(t, []byte{2}, entry.Match[0].GetExact().Value)
+	}
+
+	// Roll back a wildcard delete of all remaining entries, restoring each removed entry
+	empty := &p4api.TableEntry{TableId: 1}
+	for _, atomicity := range []p4api.WriteRequest_Atomicity{p4api.WriteRequest_ROLLBACK_ON_ERROR, p4api.WriteRequest_DATAPLANE_ATOMIC} {
+		err := tables.ApplyUpdates(atomicity, []*p4api.Update{
+			tableUpdate(p4api.Update_DELETE, empty),
+			tableUpdate(p4api.Update_INSERT, withAction(exactEntry(3, 3), 1)),
+			tableUpdate(p4api.Update_INSERT, withAction(exactEntry(3, 3), 1)),
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 2, table.Size())
+		for _, v := range [][2]byte{{2, 1}, {2, 2}} {
+			_, err := table.Lookup(FieldValues{1: {v[0]}, 2: {v[1]}})
+			assert.NoError(t, err)
+		}
+	}
+
+	// Delete all remaining entries in a batch
+	assert.NoError(t, tables.ApplyUpdates(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		tableUpdate(p4api.Update_DELETE, &p4api.TableEntry{TableId: 1}),
+		tableUpdate(p4api.Update_INSERT, withAction(exactEntry(2, 1), 1)),
+		tableUpdate(p4api.Update_INSERT, withAction(exactEntry(2, 2), 1)),
+	}))
+	assert.Equal(t, 2, table.Size())
+
+	// Clear the whole table, retaining the default entry
+	assert.NoError(t, tables.ModifyTableEntry(&p4api.TableEntry{TableId: 1, IsDefaultAction: true, Action: directAction(1, 1)}, false))
+	n, err := table.RemoveMatchingEntries(&p4api.TableEntry{TableId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, table.Size())
+	assert.NotNil(t, table.DefaultEntry())
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// EntryUsage represents the number of entries held by a device against its cap on the total number of entries
+type EntryUsage struct {
+	// Total is the number of table entries, excluding default entries, action profile members and groups,
+	// multicast groups and clone sessions held by the device
+	Total int
+	// Max is the maximum total number of entries; 0 if unlimited
+	Max int
+}
+
+// SetMaxEntries sets the maximum total number of entries across all entity stores of the device, beyond which
+// inserts fail with entries.ResourceExhaustedError, regardless of the sizes declared in P4Info; 0 removes the cap
+func (ds *DeviceSimulator) SetMaxEntries(max int) error {
+	if max < 0 {
+		return errors.NewInvalid("invalid maximum number of entries %d", max)
+	}
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.maxEntries = max
+	return nil
+}
+
+// EntryUsage returns the total number of entries held by the device along with the cap on the total
+func (ds *DeviceSimulator) EntryUsage() EntryUsage {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+	return EntryUsage{Total: ds.entryCount(), Max: ds.maxEntries}
+}
+
+// Returns the total number of entries held by the entity stores of the device
+func (ds *DeviceSimulator) entryCount() int {
+	if ds.tables == nil {
+		return 0
+	}
+	return ds.tables.EntryCount() + ds.profiles.EntryCount() + ds.pre.EntryCount()
+}
+
+// Returns entries.ResourceExhaustedError if the device already holds the maximum total number of entries
+func (ds *DeviceSimulator) checkEntryLimit() error {
+	if ds.maxEntries == 0 {
+		return nil
+	}
+	if total := ds.entryCount(); total >= ds.maxEntries {
+		return entries.NewResourceExhausted("Device %s: maximum of %d entries reached", ds.Device.ID, ds.maxEntries)
+	}
+	return nil
+}
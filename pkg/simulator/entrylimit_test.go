@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"testing"
+)
+
+func TestMaxEntries(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	assert.Equal(t, EntryUsage{}, ds.EntryUsage())
+	assert.NoError(t, ds.SetMaxEntries(3))
+	assert.True(t, errors.IsInvalid(ds.SetMaxEntries(-1)))
+
+	// Table entries and multicast groups count against the same cap
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		insertUpdate(1),
+		insertUpdate(2),
+		multicastUpdate(p4api.Update_INSERT, 7, 1, 2),
+	}))
+	assert.Equal(t, EntryUsage{Total: 3, Max: 3}, ds.EntryUsage())
+
+	details, err := ds.ProcessWriteWithDetails(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(3)})
+	assert.Error(t, err)
+	exhausted, ok := details[0].(*entries.ResourceExhaustedError)
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, exhausted.GRPCStatus().Code())
+
+	// Modifies and deletes are not affected; deletes make room for new inserts
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		multicastUpdate(p4api.Update_MODIFY, 7, 1),
+		{Type: p4api.Update_DELETE, Entity: insertUpdate(1).Entity},
+		insertUpdate(3),
+	}))
+	assert.Equal(t, EntryUsage{Total: 3, Max: 3}, ds.EntryUsage())
+
+	// Removing the cap allows any number of entries
+	assert.NoError(t, ds.SetMaxEntries(0))
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(4)}))
+	assert.Equal(t, EntryUsage{Total: 4}, ds.EntryUsage())
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"fmt"
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// ForwardingModel describes how the device pipeline forwards packets: the table stages through which packets are
+// processed and the metadata which determine where packets egress
+type ForwardingModel struct {
+	// Stages are the pipeline stages, in processing order
+	Stages []*entries.PipelineStage
+	// EgressPort is the name of the metadata holding the number of the port via which the packet egresses
+	EgressPort string
+	// MulticastGroup is the name of the metadata holding the ID of the multicast group to which the packet is sent;
+	// takes precedence over the egress port
+	MulticastGroup string
+	// FlowKey lists the names of the metadata hashed to select the member of action profile groups for the packet
+	FlowKey []string
+	// Counters lists the indirect counters which count the packets, when packet counting is enabled
+	Counters []*CounterBinding
+}
+
+// CounterBinding describes which cell of an indirect counter counts a packet
+type CounterBinding struct {
+	// CounterID is the ID of the indirect counter
+	CounterID uint32
+	// Index is the name of the metadata holding the index of the counter cell; packets without it are not counted
+	Index string
+}
+
+// ForwardingDecision represents where the device forwards a packet
+type ForwardingDecision struct {
+	// EgressPorts are the ports via which the packet egresses; empty if the packet is dropped
+	EgressPorts []simapi.PortID
+	// DropReason describes why the packet is dropped; empty if the packet egresses
+	DropReason string
+	// Pipeline is the outcome of the walk of the packet through the pipeline stages
+	Pipeline *entries.PipelineResult
+}
+
+// SetForwardingModel sets the model used to determine the forwarding decisions of the device; nil clears the model
+func (ds *DeviceSimulator) SetForwardingModel(model *ForwardingModel) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.forwardingModel = model
+}
+
+// SetPacketCounting enables or disables the incrementing of the direct counters of the entries hit by the packets
+// forwarded via ForwardPacket, and of the indirect counters of the forwarding model; disabled by default, so that
+// counters reflect only the controller writes
+func (ds *DeviceSimulator) SetPacketCounting(enabled bool) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.packetCounting = enabled
+}
+
+// ForwardingDecision determines where the device would forward a synthetic packet with the given header field and
+// metadata values by walking it through the pipeline stages of the forwarding model, applying the actions of the
+// matched entries and resolving action profile groups, per the packet flow key, and multicast groups
+func (ds *DeviceSimulator) ForwardingDecision(packet entries.Metadata) (*ForwardingDecision, error) {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+	decision, _, err := ds.forwardingDecision(packet)
+	return decision, err
+}
+
+// SetMetering enables or disables the metering of the packets forwarded via ForwardPacket by the direct meters
+// of the entries they hit; disabled by default, so that direct meter data reflect only the controller writes
+func (ds *DeviceSimulator) SetMetering(enabled bool) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.metering = enabled
+}
+
+// ForwardPacket forwards a synthetic packet of the given size in bytes, determining its forwarding decision as
+// ForwardingDecision does and, if packet counting is enabled, incrementing the direct counters of the entries it
+// hit and the indirect counter cells it selected by one packet and its size; if metering is enabled, the packet is also
+// marked by the direct meters of those entries, with the colors recorded in the pipeline stage results. The size is
+// that of the Ethernet frame: the packet egresses only via the ports whose MTU it does not exceed, as by Transmit.
+func (ds *DeviceSimulator) ForwardPacket(packet entries.Metadata, size int) (*ForwardingDecision, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	decision, pipeline, err := ds.forwardingDecision(packet)
+	if err != nil {
+		return nil, err
+	}
+	if ds.packetCounting {
+		if err := pipeline.CountPackets(decision.Pipeline, 1, int64(size)); err != nil {
+			return nil, err
+		}
+		if err := ds.countIndirect(decision.Pipeline.Metadata, int64(size)); err != nil {
+			return nil, err
+		}
+	}
+	if ds.metering {
+		if err := pipeline.MeterPackets(decision.Pipeline, int64(size)); err != nil {
+			return nil, err
+		}
+	}
+	if len(decision.EgressPorts) > 0 {
+		decision.EgressPorts, decision.DropReason = ds.egressPacket(decision.EgressPorts, size)
+	}
+	return decision, nil
+}
+
+// Simulates the egress of a packet of the given size via the specified ports; returns the ports via which it
+// egressed or, if none, the reason for which it is dropped
+func (ds *DeviceSimulator) egressPacket(ports []simapi.PortID, size int) ([]simapi.PortID, string) {
+	egressed := make([]simapi.PortID, 0, len(ports))
+	dropReason := ""
+	for _, id := range ports {
+		if _, err := ds.egress(ds.Ports[id], size); err != nil {
+			dropReason = err.Error()
+			continue
+		}
+		egressed = append(egressed, id)
+	}
+	if len(egressed) == 0 {
+		return nil, dropReason
+	}
+	return egressed, ""
+}
+
+// Increments the indirect counter cells selected by the given packet metadata by one packet of the given size;
+// increments at an index outside of the counter size are dropped, as by the datapath, and counted by the counter
+func (ds *DeviceSimulator) countIndirect(metadata entries.Metadata, size int64) error {
+	for _, binding := range ds.forwardingModel.Counters {
+		value, ok := metadata[binding.Index]
+		if !ok {
+			continue
+		}
+		if ds.counters.Counter(binding.CounterID) == nil {
+			return errors.NewNotFound("counter %d not found", binding.CounterID)
+		}
+		_ = ds.counters.Increment(binding.CounterID, int64(valueToUint(value)), 1, size)
+	}
+	return nil
+}
+
+// Determines the forwarding decision for the given packet, returning it along with the pipeline it was walked through
+func (ds *DeviceSimulator) forwardingDecision(packet entries.Metadata) (*ForwardingDecision, *entries.Pipeline, error) {
+	if ds.forwardingPipelineConfig == nil {
+		return nil, nil, errors.NewUnavailable("Device %s: Pipeline configuration not set yet", ds.Device.ID)
+	}
+	model := ds.forwardingModel
+	if model == nil {
+		return nil, nil, errors.NewUnavailable("Device %s: Forwarding model not set yet", ds.Device.ID)
+	}
+
+	// The pipeline is created for each decision, validating the stages against the current pipeline configuration
+	pipeline, err := entries.NewPipeline(ds.tables, model.Stages)
+	if err != nil {
+		return nil, nil, err
+	}
+	flow := make([]byte, 0)
+	for _, name := range model.FlowKey {
+		flow = append(flow, packet[name]...)
+	}
+	result, err := pipeline.WalkFlow(packet, flow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decision := &ForwardingDecision{Pipeline: result, DropReason: result.DropReason}
+	if decision.DropReason == "" {
+		decision.EgressPorts, decision.DropReason = ds.egressPorts(model, result.Metadata)
+	}
+	return decision, pipeline, nil
+}
+
+// Returns the enabled ports via which a packet with the given metadata egresses or the reason for which it is dropped
+func (ds *DeviceSimulator) egressPorts(model *ForwardingModel, metadata entries.Metadata) ([]simapi.PortID, string) {
+	if value, ok := metadata[model.MulticastGroup]; ok && model.MulticastGroup != "" {
+		groupID := uint32(valueToUint(value))
+		replicaPorts, err := ds.pre.ReplicaPorts(groupID)
+		if err != nil {
+			return nil, fmt.Sprintf("multicast group %d not found", groupID)
+		}
+		ports := make([]simapi.PortID, 0, len(replicaPorts))
+		for _, sdnPort := range replicaPorts {
+			if port, ok := ds.sdnPorts[sdnPort]; ok && port.Enabled {
+				ports = append(ports, port.ID)
+			}
+		}
+		if len(ports) == 0 {
+			return nil, fmt.Sprintf("no replica of multicast group %d egresses via an enabled port", groupID)
+		}
+		return ports, ""
+	}
+
+	value, ok := metadata[model.EgressPort]
+	if !ok || model.EgressPort == "" {
+		return nil, "no egress port"
+	}
+	sdnPort := uint32(valueToUint(value))
+	port, ok := ds.sdnPorts[sdnPort]
+	if !ok {
+		return nil, fmt.Sprintf("egress port %d not found", sdnPort)
+	}
+	if !port.Enabled {
+		return nil, fmt.Sprintf("egress port %s is disabled", port.ID)
+	}
+	return []simapi.PortID{port.ID}, ""
+}
+
+// Returns the unsigned integer value of the given big-endian byte string
+func valueToUint(value []byte) uint64 {
+	n := uint64(0)
+	for _, b := range value {
+		n = n<<8 | uint64(b)
+	}
+	return n
+}
@@ -0,0 +1,315 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	"github.com/onosproject/fabric-sim/pkg/topo"
+	"github.com/onosproject/onos-api/go/onos/fabricsim"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Creates a device with a simple L2/L3 pipeline: a routing table (LPM on ipv4_dst) setting the next ID, a next
+// table (exact on next ID, implemented by an action profile) setting the egress port and a bridging table (exact
+// on eth_dst, with a direct counter and meter) setting either the egress port or the multicast group
+func newForwardingDevice(t *testing.T) *DeviceSimulator {
+	topology := &topo.Topology{}
+	assert.NoError(t, topo.LoadTopologyFile("../../topologies/custom.yaml", topology))
+	ds := NewDeviceSimulator(topo.ConstructDevice(topology.Devices[0]), nil, nil)
+	port := &p4info.Action_Param{Id: 1, Name: "port_num", Bitwidth: 9}
+	assert.NoError(t, ds.SetPipelineConfig(&p4api.ForwardingPipelineConfig{
+		P4Info: &p4info.P4Info{
+			Tables: []*p4info.Table{
+				{
+					Preamble:    &p4info.Preamble{Id: 1, Name: "routing"},
+					MatchFields: []*p4info.MatchField{{Id: 1, Name: "ipv4_dst", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_LPM}}},
+				},
+				{
+					Preamble:         &p4info.Preamble{Id: 2, Name: "next"},
+					MatchFields:      []*p4info.MatchField{{Id: 1, Name: "next_id", Bitwidth: 32, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+					ImplementationId: 100,
+				},
+				{
+					Preamble:          &p4info.Preamble{Id: 3, Name: "bridging"},
+					MatchFields:       []*p4info.MatchField{{Id: 1, Name: "eth_dst", Bitwidth: 48, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+					DirectResourceIds: []uint32{300, 301},
+				},
+			},
+			DirectCounters: []*p4info.DirectCounter{{
+				Preamble:      &p4info.Preamble{Id: 300, Name: "bridging_counter"},
+				Spec:          &p4info.CounterSpec{Unit: p4info.CounterSpec_BOTH},
+				DirectTableId: 3,
+			}},
+			DirectMeters: []*p4info.DirectMeter{{
+				Preamble:      &p4info.Preamble{Id: 301, Name: "bridging_meter"},
+				Spec:          &p4info.MeterSpec{Unit: p4info.MeterSpec_PACKETS},
+				DirectTableId: 3,
+			}},
+			Actions: []*p4info.Action{
+				{Preamble: &p4info.Preamble{Id: 1, Name: "output"}, Params: []*p4info.Action_Param{port}},
+				{Preamble: &p4info.Preamble{Id: 2, Name: "drop"}},
+				{Preamble: &p4info.Preamble{Id: 3, Name: "set_next_id"}, Params: []*p4info.Action_Param{{Id: 1, Name: "next_id", Bitwidth: 32}}},
+				{Preamble: &p4info.Preamble{Id: 4, Name: "set_mcast_group"}, Params: []*p4info.Action_Param{{Id: 1, Name: "group_id", Bitwidth: 16}}},
+			},
+			ActionProfiles: []*p4info.ActionProfile{{Preamble: &p4info.Preamble{Id: 100, Name: "hashed"}, TableIds: []uint32{2}, WithSelector: true}},
+			Counters:       []*p4info.Counter{{Preamble: &p4info.Preamble{Id: 400, Name: "next_counter"}, Size: 4}},
+		},
+		Cookie: &p4api.ForwardingPipelineConfig_Cookie{Cookie: 1},
+	}))
+	ds.SetForwardingModel(&ForwardingModel{
+		Stages: []*entries.PipelineStage{
+			{TableID: 1, Keys: map[uint32]string{1: "ipv4_dst"}, Writes: map[string]string{"next_id": "next_id"}},
+			{TableID: 2, Keys: map[uint32]string{1: "next_id"}, Writes: map[string]string{"port_num": "egress_port"}},
+			{TableID: 3, Keys: map[uint32]string{1: "eth_dst"}, Writes: map[string]string{"port_num": "egress_port", "group_id": "mcast_group"}},
+		},
+		EgressPort:     "egress_port",
+		MulticastGroup: "mcast_group",
+		FlowKey:        []string{"ipv4_src", "ipv4_dst"},
+		Counters:       []*CounterBinding{{CounterID: 400, Index: "next_id"}},
+	})
+	return ds
+}
+
+// Creates an insert update of the given entity
+func entityInsert(entity *p4api.Entity) *p4api.Update {
+	return &p4api.Update{Type: p4api.Update_INSERT, Entity: entity}
+}
+
+// Creates an insert update of an entry of the given table with a single field match and the given action
+func entryInsert(tableID uint32, match *p4api.FieldMatch, action *p4api.TableAction) *p4api.Update {
+	return entityInsert(&p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: &p4api.TableEntry{
+		TableId: tableID, Match: []*p4api.FieldMatch{match}, Action: action,
+	}}})
+}
+
+// Creates a direct table action with the given ID and parameter value, if any
+func tableAction(actionID uint32, value ...byte) *p4api.TableAction {
+	action := &p4api.Action{ActionId: actionID}
+	if len(value) > 0 {
+		action.Params = []*p4api.Action_Param{{ParamId: 1, Value: value}}
+	}
+	return &p4api.TableAction{Type: &p4api.TableAction_Action{Action: action}}
+}
+
+func exactMatch(value ...byte) *p4api.FieldMatch {
+	return &p4api.FieldMatch{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: value}}}
+}
+
+func lpmMatch(prefixLen int32, value ...byte) *p4api.FieldMatch {
+	return &p4api.FieldMatch{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Lpm{Lpm: &p4api.FieldMatch_LPM{Value: value, PrefixLen: prefixLen}}}
+}
+
+func TestForwardingDecision(t *testing.T) {
+	ds := newForwardingDevice(t)
+	ports := ds.Device.Ports
+	assert.True(t, len(ports) >= 3)
+	portNum := func(i int) byte {
+		assert.True(t, ports[i].InternalNumber < 256)
+		return byte(ports[i].InternalNumber)
+	}
+	member := func(memberID uint32, port byte) *p4api.Update {
+		return entityInsert(&p4api.Entity{Entity: &p4api.Entity_ActionProfileMember{ActionProfileMember: &p4api.ActionProfileMember{
+			ActionProfileId: 100, MemberId: memberID, Action: tableAction(1, port).GetAction(),
+		}}})
+	}
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		member(1, portNum(0)),
+		member(2, portNum(1)),
+		entityInsert(&p4api.Entity{Entity: &p4api.Entity_ActionProfileGroup{ActionProfileGroup: &p4api.ActionProfileGroup{
+			ActionProfileId: 100, GroupId: 10, Members: []*p4api.ActionProfileGroup_Member{{MemberId: 1, Weight: 1}, {MemberId: 2, Weight: 1}},
+		}}}),
+		multicastUpdate(p4api.Update_INSERT, 7, ports[1].InternalNumber, ports[2].InternalNumber),
+		entryInsert(1, lpmMatch(8, 10, 0, 0, 0), tableAction(3, 1)),
+		entryInsert(1, lpmMatch(16, 10, 1, 0, 0), tableAction(3, 2)),
+		entryInsert(1, lpmMatch(16, 10, 2, 0, 0), tableAction(3, 3)),
+		entryInsert(2, exactMatch(1), &p4api.TableAction{Type: &p4api.TableAction_ActionProfileGroupId{ActionProfileGroupId: 10}}),
+		entryInsert(2, exactMatch(2), &p4api.TableAction{Type: &p4api.TableAction_ActionProfileMemberId{ActionProfileMemberId: 2}}),
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 1), tableAction(1, portNum(2))),
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 2), tableAction(4, 7)),
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 3), tableAction(2)),
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 4), tableAction(1, 0xfe)),
+	}))
+
+	decide := func(packet entries.Metadata) *ForwardingDecision {
+		decision, err := ds.ForwardingDecision(packet)
+		assert.NoError(t, err)
+		return decision
+	}
+
+	// Bridged packets egress via the port of the bridging entry or the replicas of its multicast group
+	decision := decide(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 1}})
+	assert.Equal(t, []fabricsim.PortID{ports[2].ID}, decision.EgressPorts)
+	assert.Empty(t, decision.DropReason)
+	assert.Len(t, decision.Pipeline.Stages, 3)
+	decision = decide(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 2}})
+	assert.Equal(t, []fabricsim.PortID{ports[1].ID, ports[2].ID}, decision.EgressPorts)
+
+	// Routed packets egress via the next hop member, or the group member selected for their flow
+	decision = decide(entries.Metadata{"ipv4_dst": {10, 1, 2, 3}})
+	assert.Equal(t, []fabricsim.PortID{ports[1].ID}, decision.EgressPorts)
+	egressed := make(map[fabricsim.PortID]bool)
+	for i := byte(0); i < 32; i++ {
+		packet := entries.Metadata{"ipv4_src": {192, 168, 0, i}, "ipv4_dst": {10, 9, 2, 3}}
+		decision = decide(packet)
+		assert.Len(t, decision.EgressPorts, 1)
+		egressed[decision.EgressPorts[0]] = true
+		assert.Equal(t, decision.EgressPorts, decide(packet).EgressPorts)
+	}
+	assert.Equal(t, map[fabricsim.PortID]bool{ports[0].ID: true, ports[1].ID: true}, egressed)
+
+	// Packets are dropped by drop actions, misses and unknown ports
+	decision = decide(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 3}})
+	assert.Empty(t, decision.EgressPorts)
+	assert.Contains(t, decision.DropReason, "drop")
+	decision = decide(entries.Metadata{"ipv4_dst": {10, 2, 2, 3}})
+	assert.Equal(t, "no egress port", decision.DropReason)
+	decision = decide(entries.Metadata{"ipv4_dst": {11, 2, 2, 3}})
+	assert.Equal(t, "no egress port", decision.DropReason)
+	decision = decide(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 4}})
+	assert.Contains(t, decision.DropReason, "egress port 254 not found")
+
+	ds.SetForwardingModel(nil)
+	_, err := ds.ForwardingDecision(entries.Metadata{})
+	assert.True(t, errors.IsUnavailable(err))
+}
+
+func TestPacketCounting(t *testing.T) {
+	ds := newForwardingDevice(t)
+	port := byte(ds.Device.Ports[0].InternalNumber)
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 1), tableAction(1, port)),
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 2), tableAction(2)),
+	}))
+	counts := func(value byte) *p4api.CounterData {
+		var data *p4api.CounterData
+		request := &p4api.TableEntry{TableId: 3, Match: []*p4api.FieldMatch{exactMatch(0, 0, 0, 0, 0, value)}}
+		assert.NoError(t, ds.tables.ReadTableEntries(request, entries.ReadDirectCounter, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				data = entity.GetDirectCounterEntry().Data
+			}
+			return nil
+		}))
+		return data
+	}
+	forward := func(value byte, size int) {
+		_, err := ds.ForwardPacket(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, value}}, size)
+		assert.NoError(t, err)
+	}
+
+	// Packets are not counted unless enabled
+	forward(1, 100)
+	assert.Equal(t, int64(0), counts(1).PacketCount)
+
+	// Entries count the packets which hit them, whether forwarded or dropped; misses are not counted
+	ds.SetPacketCounting(true)
+	forward(1, 100)
+	forward(1, 64)
+	forward(2, 1500)
+	forward(3, 1500)
+	assert.Equal(t, int64(2), counts(1).PacketCount)
+	assert.Equal(t, int64(164), counts(1).ByteCount)
+	assert.Equal(t, int64(1), counts(2).PacketCount)
+	assert.Equal(t, int64(1500), counts(2).ByteCount)
+
+	// Forwarding decisions alone never count
+	_, err := ds.ForwardingDecision(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), counts(1).PacketCount)
+}
+
+func TestIndirectPacketCounting(t *testing.T) {
+	ds := newForwardingDevice(t)
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		entryInsert(1, lpmMatch(8, 10, 0, 0, 0), tableAction(3, 1)),
+		entryInsert(1, lpmMatch(8, 11, 0, 0, 0), tableAction(3, 9)),
+	}))
+	counter := ds.Counters().Counter(400)
+	forward := func(ipv4Dst byte, size int) {
+		_, err := ds.ForwardPacket(entries.Metadata{"ipv4_dst": {ipv4Dst, 1, 2, 3}}, size)
+		assert.NoError(t, err)
+	}
+
+	// Packets are not counted unless enabled
+	forward(10, 100)
+	assert.Equal(t, int64(0), counter.Cell(1).Data.PacketCount)
+
+	// Packets are counted in the cell selected by their next ID; those without one are not counted
+	ds.SetPacketCounting(true)
+	forward(10, 100)
+	forward(10, 64)
+	_, err := ds.ForwardPacket(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 1}}, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, &p4api.CounterData{PacketCount: 2, ByteCount: 164}, counter.Cell(1).Data)
+	for _, index := range []int64{0, 2, 3} {
+		assert.Equal(t, int64(0), counter.Cell(index).Data.PacketCount)
+	}
+
+	// Packets selecting a cell outside of the counter size are still forwarded, with the increment dropped
+	forward(11, 100)
+	assert.Equal(t, uint64(1), counter.OutOfRangeIncrements())
+}
+
+func TestMetering(t *testing.T) {
+	ds := newForwardingDevice(t)
+	port := byte(ds.Device.Ports[0].InternalNumber)
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 1), tableAction(1, port)),
+		{Type: p4api.Update_MODIFY, Entity: &p4api.Entity{Entity: &p4api.Entity_DirectMeterEntry{DirectMeterEntry: &p4api.DirectMeterEntry{
+			TableEntry: &p4api.TableEntry{TableId: 3, Match: []*p4api.FieldMatch{exactMatch(0, 0, 0, 0, 0, 1)}},
+			Config:     &p4api.MeterConfig{Cir: 1, Cburst: 1, Pir: 1, Pburst: 2},
+		}}}},
+	}))
+	colors := func() []entries.Color {
+		colors := make([]entries.Color, 0)
+		for i := 0; i < 3; i++ {
+			decision, err := ds.ForwardPacket(entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 1}}, 100)
+			assert.NoError(t, err)
+			stage := decision.Pipeline.Stages[2]
+			if stage.Metered {
+				colors = append(colors, stage.Color)
+			}
+		}
+		return colors
+	}
+
+	// Packets are not metered unless enabled; metered packets are marked per the entry's meter configuration
+	assert.Empty(t, colors())
+	ds.SetMetering(true)
+	assert.Equal(t, []entries.Color{entries.Green, entries.Yellow, entries.Red}, colors())
+}
+
+func TestForwardedPacketMTU(t *testing.T) {
+	ds := newForwardingDevice(t)
+	port := ds.Device.Ports[0]
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{
+		entryInsert(3, exactMatch(0, 0, 0, 0, 0, 1), tableAction(1, byte(port.InternalNumber))),
+	}))
+	assert.NoError(t, ds.SetPortMTU(port.ID, 1000))
+	packet := entries.Metadata{"eth_dst": {0, 0, 0, 0, 0, 1}}
+
+	// Packets within the MTU egress
+	decision, err := ds.ForwardPacket(packet, 1014)
+	assert.NoError(t, err)
+	assert.Equal(t, []fabricsim.PortID{port.ID}, decision.EgressPorts)
+	assert.Empty(t, decision.DropReason)
+
+	// Oversized packets are dropped at egress and counted
+	decision, err = ds.ForwardPacket(packet, 1015)
+	assert.NoError(t, err)
+	assert.Empty(t, decision.EgressPorts)
+	assert.Contains(t, decision.DropReason, "exceeds MTU")
+	model, err := ds.GetPortModel(port.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), model.MTUDrops)
+
+	// Forwarding decisions alone never egress
+	decision, err = ds.ForwardingDecision(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, []fabricsim.PortID{port.ID}, decision.EgressPorts)
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"time"
+)
+
+// IdleSweepInterval is the interval at which the device tables are swept for entries exceeding their idle timeout
+const IdleSweepInterval = time.Second
+
+// Periodically sweeps the device tables for idle entries until the context is cancelled
+func (ds *DeviceSimulator) simulateIdleTimeouts(ctx context.Context, interval time.Duration) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+				ds.SweepIdleEntries()
+			}
+		}
+	}()
+}
+
+// SweepIdleEntries sends an idle timeout notification for the table entries which have not been hit for at least
+// their idle timeout, in tables supporting idle timeout notifications; returns the number of notified entries
+func (ds *DeviceSimulator) SweepIdleEntries() int {
+	ds.lock.Lock()
+	expired := make([]*p4api.TableEntry, 0)
+	if ds.tables != nil {
+		for _, table := range ds.tables.Tables() {
+			expired = append(expired, table.SweepIdleEntries()...)
+		}
+	}
+	ds.lock.Unlock()
+
+	if len(expired) > 0 {
+		log.Debugf("Device %s: Notifying %d idle entries", ds.Device.ID, len(expired))
+		ds.SendToAllResponders(&p4api.StreamMessageResponse{
+			Update: &p4api.StreamMessageResponse_IdleTimeoutNotification{
+				IdleTimeoutNotification: &p4api.IdleTimeoutNotification{
+					TableEntry: expired,
+					Timestamp:  time.Now().UnixNano(),
+				},
+			},
+		})
+	}
+	return len(expired)
+}
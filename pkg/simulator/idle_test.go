@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/topo"
+	"github.com/onosproject/onos-api/go/onos/misc"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSweepIdleEntries(t *testing.T) {
+	topology := &topo.Topology{}
+	assert.NoError(t, topo.LoadTopologyFile("../../topologies/custom.yaml", topology))
+	ds := NewDeviceSimulator(topo.ConstructDevice(topology.Devices[0]), nil, nil)
+	assert.NoError(t, ds.SetPipelineConfig(&p4api.ForwardingPipelineConfig{
+		P4Info: &p4info.P4Info{Tables: []*p4info.Table{{
+			Preamble:            &p4info.Preamble{Id: 1, Name: "exact"},
+			MatchFields:         []*p4info.MatchField{{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+			IdleTimeoutBehavior: p4info.Table_NOTIFY_CONTROL,
+		}}},
+		Cookie: &p4api.ForwardingPipelineConfig_Cookie{Cookie: 1},
+	}))
+	now := time.Unix(1000, 0)
+	ds.Tables().SetClock(func() time.Time { return now })
+
+	controller := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c1"}}
+	ds.AddStreamResponder(controller)
+	for i := 1; i <= 3; i++ {
+		update := insertUpdate(byte(i))
+		update.Entity.GetTableEntry().IdleTimeoutNs = int64(10 * time.Second)
+		assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{update}))
+	}
+
+	assert.Equal(t, 0, ds.SweepIdleEntries())
+	assert.Len(t, controller.responses, 0)
+
+	// Entries which were not hit are notified in a single message, and only once
+	now = now.Add(8 * time.Second)
+	assert.NoError(t, ds.Tables().Table(1).RecordHit(insertUpdate(2).Entity.GetTableEntry()))
+	now = now.Add(4 * time.Second)
+	assert.Equal(t, 2, ds.SweepIdleEntries())
+	assert.Len(t, controller.responses, 1)
+	notification := controller.responses[0].GetIdleTimeoutNotification()
+	assert.NotNil(t, notification)
+	assert.Len(t, notification.TableEntry, 2)
+	assert.Equal(t, 0, ds.SweepIdleEntries())
+	assert.Len(t, controller.responses, 1)
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
+)
+
+// TransmitMulticast simulates the egress of the given Ethernet frame sent to the specified multicast group,
+// replicating it to the egress port of each replica of the group; returns the ports via which the frame egressed.
+// Replicas for ports unknown to the device, and replicas dropped at egress, e.g. due to the port MTU, are skipped.
+func (ds *DeviceSimulator) TransmitMulticast(groupID uint32, frame []byte) ([]simapi.PortID, error) {
+	ds.lock.RLock()
+	replicaPorts, err := ds.pre.ReplicaPorts(groupID)
+	ds.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	egressed := make([]simapi.PortID, 0, len(replicaPorts))
+	for _, sdnPort := range replicaPorts {
+		port, ok := ds.sdnPorts[sdnPort]
+		if !ok {
+			log.Debugf("Device %s: Skipped replica of multicast group %d for unknown port %d", ds.Device.ID, groupID, sdnPort)
+			continue
+		}
+		if _, err := ds.transmit(port, frame); err != nil {
+			continue
+		}
+		egressed = append(egressed, port.ID)
+	}
+	return egressed, nil
+}
+
+// TransmitClone simulates the egress of the copies of the given Ethernet frame produced by a P4 program cloning it
+// to the specified clone session, each truncated to the session packet length, if any; returns the ports via which
+// the copies egressed. Copies for ports unknown to the device, and copies dropped at egress, are skipped.
+func (ds *DeviceSimulator) TransmitClone(sessionID uint32, frame []byte) ([]simapi.PortID, error) {
+	ds.lock.RLock()
+	clones, err := ds.pre.Clone(sessionID, frame)
+	ds.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	egressed := make([]simapi.PortID, 0, len(clones))
+	for _, clone := range clones {
+		port, ok := ds.sdnPorts[clone.EgressPort]
+		if !ok {
+			log.Debugf("Device %s: Skipped replica of clone session %d for unknown port %d", ds.Device.ID, sessionID, clone.EgressPort)
+			continue
+		}
+		if _, err := ds.transmit(port, clone.Packet); err != nil {
+			continue
+		}
+		egressed = append(egressed, port.ID)
+	}
+	return egressed, nil
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Creates an update of the multicast group with the given ID and replicas for the specified SDN ports
+func multicastUpdate(updateType p4api.Update_Type, groupID uint32, ports ...uint32) *p4api.Update {
+	replicas := make([]*p4api.Replica, 0, len(ports))
+	for _, port := range ports {
+		replicas = append(replicas, &p4api.Replica{EgressPort: port, Instance: 1})
+	}
+	return &p4api.Update{Type: updateType, Entity: &p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{
+		PacketReplicationEngineEntry: &p4api.PacketReplicationEngineEntry{Type: &p4api.PacketReplicationEngineEntry_MulticastGroupEntry{
+			MulticastGroupEntry: &p4api.MulticastGroupEntry{MulticastGroupId: groupID, Replicas: replicas},
+		}},
+	}}}
+}
+
+func TestTransmitMulticast(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	ports := ds.Device.Ports
+	assert.True(t, len(ports) >= 3)
+	p1, p2, p3 := ports[0].InternalNumber, ports[1].InternalNumber, ports[2].InternalNumber
+
+	// Duplicate replicas within a group are rejected
+	assert.Error(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{multicastUpdate(p4api.Update_INSERT, 1, p1, p1)}))
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{multicastUpdate(p4api.Update_INSERT, 1, p1, p2, 9999)}))
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{multicastUpdate(p4api.Update_INSERT, 2, p3)}))
+
+	// Frames sent to the group are replicated to each replica port known to the device
+	egressed, err := ds.TransmitMulticast(1, make([]byte, 64))
+	assert.NoError(t, err)
+	assert.Equal(t, []simapi.PortID{ports[0].ID, ports[1].ID}, egressed)
+	_, err = ds.TransmitMulticast(3, make([]byte, 64))
+	assert.True(t, errors.IsNotFound(err))
+
+	// Replicas dropped at egress are skipped
+	assert.NoError(t, ds.SetPortMTU(ports[1].ID, 100))
+	egressed, err = ds.TransmitMulticast(1, make([]byte, 200))
+	assert.NoError(t, err)
+	assert.Equal(t, []simapi.PortID{ports[0].ID}, egressed)
+
+	// Reads of group 0 return all groups
+	groups := func(groupID uint32) []uint32 {
+		ids := make([]uint32, 0)
+		errs := ds.ProcessRead([]*p4api.Entity{multicastUpdate(p4api.Update_INSERT, groupID).Entity}, func(entities []*p4api.Entity) error {
+			for _, entity := range entities {
+				ids = append(ids, entity.GetPacketReplicationEngineEntry().GetMulticastGroupEntry().MulticastGroupId)
+			}
+			return nil
+		})
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		return ids
+	}
+	assert.ElementsMatch(t, []uint32{1, 2}, groups(0))
+	assert.Equal(t, []uint32{2}, groups(2))
+}
+
+func TestTransmitClone(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	ports := ds.Device.Ports
+	session := &p4api.CloneSessionEntry{SessionId: 5, PacketLengthBytes: 128, Replicas: []*p4api.Replica{
+		{EgressPort: ports[0].InternalNumber}, {EgressPort: ports[1].InternalNumber}, {EgressPort: 9999},
+	}}
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{{Type: p4api.Update_INSERT,
+		Entity: &p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{PacketReplicationEngineEntry: &p4api.PacketReplicationEngineEntry{
+			Type: &p4api.PacketReplicationEngineEntry_CloneSessionEntry{CloneSessionEntry: session},
+		}}}}}))
+
+	// Truncated copies fit within the MTU of ports which would drop the full frame
+	assert.NoError(t, ds.SetPortMTU(ports[1].ID, 500))
+	egressed, err := ds.TransmitClone(5, make([]byte, 1000))
+	assert.NoError(t, err)
+	assert.Equal(t, []simapi.PortID{ports[0].ID, ports[1].ID}, egressed)
+	model, _ := ds.GetPortModel(ports[1].ID)
+	assert.Equal(t, uint64(0), model.MTUDrops)
+
+	_, err = ds.TransmitClone(6, make([]byte, 1000))
+	assert.True(t, errors.IsNotFound(err))
+}
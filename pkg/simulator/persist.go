@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/protobuf/proto"
+	"os"
+)
+
+// MarshalState serializes the state of all device entities as a binary read response: action profile members and
+// groups, multicast groups, clone sessions, digest entries, counter, meter and register cells, value sets and
+// finally the table entries with their direct resources, so that entities precede the entries referring to them
+func (ds *DeviceSimulator) MarshalState() ([]byte, error) {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+	if ds.tables == nil {
+		return nil, errors.NewConflict("forwarding pipeline not set for %s", ds.Device.ID)
+	}
+
+	response := &p4api.ReadResponse{}
+	sender := func(entities []*p4api.Entity) error {
+		response.Entities = append(response.Entities, entities...)
+		return nil
+	}
+	reads := []func() error{
+		func() error { return ds.profiles.ReadAll(0, sender) },
+		func() error { return ds.pre.ReadMulticastGroupEntries(&p4api.MulticastGroupEntry{}, sender) },
+		func() error { return ds.pre.ReadCloneSessionEntries(&p4api.CloneSessionEntry{}, sender) },
+		func() error { return ds.digests.ReadDigestEntries(&p4api.DigestEntry{}, sender) },
+		func() error { return ds.counters.ReadCounterEntries(&p4api.CounterEntry{}, sender) },
+		func() error { return ds.meters.ReadMeterEntries(&p4api.MeterEntry{}, sender) },
+		func() error { return ds.registers.ReadRegisterEntries(&p4api.RegisterEntry{}, sender) },
+		func() error { return ds.valueSets.ReadValueSetEntries(&p4api.ValueSetEntry{}, sender) },
+	}
+	for _, read := range reads {
+		if err := read(); err != nil {
+			return nil, err
+		}
+	}
+	response.Entities = append(response.Entities, ds.tables.ExportEntities()...)
+	return proto.Marshal(response)
+}
+
+// UnmarshalState replaces the state of all device entities with the state serialized by MarshalState under the
+// same pipeline config; if any entity cannot be restored, the device state is left unchanged
+func (ds *DeviceSimulator) UnmarshalState(data []byte) error {
+	response := &p4api.ReadResponse{}
+	if err := proto.Unmarshal(data, response); err != nil {
+		return errors.NewInvalid("invalid device state: %v", err)
+	}
+
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	if ds.tables == nil {
+		return errors.NewConflict("forwarding pipeline not set for %s", ds.Device.ID)
+	}
+
+	// Populate fresh stores, putting the current ones back on failure
+	tables, counters, meters, registers := ds.tables, ds.counters, ds.meters, ds.registers
+	valueSets, profiles, pre, digests := ds.valueSets, ds.profiles, ds.pre, ds.digests
+	ds.newStores(ds.forwardingPipelineConfig.P4Info)
+	if err := ds.restoreEntities(response.Entities); err != nil {
+		ds.tables, ds.counters, ds.meters, ds.registers = tables, counters, meters, registers
+		ds.valueSets, ds.profiles, ds.pre, ds.digests = valueSets, profiles, pre, digests
+		ds.findPuntToCPUTables()
+		ds.checkPuntToCPU()
+		ds.SnapshotStats()
+		return err
+	}
+	ds.checkPuntToCPU()
+	ds.SnapshotStats()
+	return nil
+}
+
+// Installs the given entities into the device stores; entities which are configured rather than inserted, e.g.
+// counter cells, are modified
+func (ds *DeviceSimulator) restoreEntities(entities []*p4api.Entity) error {
+	tableEntities := make([]*p4api.Entity, 0, len(entities))
+	for _, entity := range entities {
+		if entity.GetTableEntry() != nil {
+			tableEntities = append(tableEntities, entity)
+			continue
+		}
+		insert := entity.GetActionProfileMember() != nil || entity.GetActionProfileGroup() != nil ||
+			entity.GetPacketReplicationEngineEntry() != nil || entity.GetDigestEntry() != nil
+		if err := ds.processModify(&p4api.Update{Entity: entity}, insert); err != nil {
+			return err
+		}
+	}
+	return ds.tables.ImportEntities(tableEntities)
+}
+
+// SaveState writes the state of all device entities to the specified file, as serialized by MarshalState
+func (ds *DeviceSimulator) SaveState(path string) error {
+	data, err := ds.MarshalState()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// RestoreState replaces the state of all device entities with the state saved by SaveState in the specified file
+func (ds *DeviceSimulator) RestoreState(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return ds.UnmarshalState(data)
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceState(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	group := &p4api.Update{Type: p4api.Update_INSERT, Entity: &p4api.Entity{Entity: &p4api.Entity_PacketReplicationEngineEntry{
+		PacketReplicationEngineEntry: &p4api.PacketReplicationEngineEntry{Type: &p4api.PacketReplicationEngineEntry_MulticastGroupEntry{
+			MulticastGroupEntry: &p4api.MulticastGroupEntry{MulticastGroupId: 7, Replicas: []*p4api.Replica{{Instance: 1}}},
+		}}}}}
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR,
+		[]*p4api.Update{insertUpdate(1), insertUpdate(2), group}))
+
+	path := filepath.Join(t.TempDir(), "state.bin")
+	assert.NoError(t, ds.SaveState(path))
+
+	// A fresh device with the same pipeline restores the same entities
+	restored := newProgrammableDevice(t)
+	assert.NoError(t, restored.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(3)}))
+	assert.NoError(t, restored.RestoreState(path))
+	expected, actual := ds.Tables().Table(1).Entries(), restored.Tables().Table(1).Entries()
+	assert.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.True(t, proto.Equal(expected[i], actual[i]))
+	}
+	read := func(ds *DeviceSimulator) []*p4api.Entity {
+		entities := make([]*p4api.Entity, 0)
+		errs := ds.ProcessRead([]*p4api.Entity{{Entity: &p4api.Entity_PacketReplicationEngineEntry{
+			PacketReplicationEngineEntry: &p4api.PacketReplicationEngineEntry{Type: &p4api.PacketReplicationEngineEntry_MulticastGroupEntry{
+				MulticastGroupEntry: &p4api.MulticastGroupEntry{},
+			}}}}}, func(batch []*p4api.Entity) error {
+			entities = append(entities, batch...)
+			return nil
+		})
+		assert.NoError(t, errs[0])
+		return entities
+	}
+	groups := read(restored)
+	assert.Len(t, groups, 1)
+	assert.True(t, proto.Equal(read(ds)[0], groups[0]))
+
+	// Corrupt state leaves the device unchanged
+	assert.True(t, errors.IsInvalid(restored.UnmarshalState([]byte{0xff})))
+	assert.Equal(t, 2, restored.Tables().Table(1).Size())
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	simapi "github.com/onosproject/onos-api/go/onos/fabricsim"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMTU is the MTU of ports for which no MTU has been set
+const DefaultMTU = 1500
+
+// Length of the Ethernet header, which does not count towards the MTU
+const ethernetHeaderLength = 14
+
+// PortModel represents the egress characteristics of a port
+type PortModel struct {
+	// MTU is the largest frame payload, excluding the Ethernet header, which can egress the port
+	MTU int
+	// Speed is the port speed, in bits per second; 0 means unbounded
+	Speed uint64
+	// Queues is the number of egress queues of the port
+	Queues uint32
+	// MTUDrops is the number of frames dropped due to exceeding the MTU
+	MTUDrops uint64
+}
+
+// Returns the port model of the specified port, creating it with the default MTU and the configured speed if needed
+func (ds *DeviceSimulator) portModel(port *simapi.Port) *PortModel {
+	if ds.portModels == nil {
+		ds.portModels = make(map[simapi.PortID]*PortModel)
+	}
+	model, ok := ds.portModels[port.ID]
+	if !ok {
+		model = &PortModel{MTU: DefaultMTU, Speed: parsePortSpeed(port.Speed), Queues: entries.DefaultEgressQueues}
+		ds.portModels[port.ID] = model
+	}
+	return model
+}
+
+// SetPortMTU sets the MTU of the specified port
+func (ds *DeviceSimulator) SetPortMTU(id simapi.PortID, mtu int) error {
+	port, ok := ds.Ports[id]
+	if !ok {
+		return errors.NewNotFound("port %s not found", id)
+	}
+	if mtu <= 0 {
+		return errors.NewInvalid("MTU must be positive: %d", mtu)
+	}
+	ds.portModelLock.Lock()
+	defer ds.portModelLock.Unlock()
+	ds.portModel(port).MTU = mtu
+	return nil
+}
+
+// SetPortQueues sets the number of egress queues of the specified port
+func (ds *DeviceSimulator) SetPortQueues(id simapi.PortID, queues uint32) error {
+	port, ok := ds.Ports[id]
+	if !ok {
+		return errors.NewNotFound("port %s not found", id)
+	}
+	if queues == 0 {
+		return errors.NewInvalid("port must have at least one queue")
+	}
+	ds.portModelLock.Lock()
+	defer ds.portModelLock.Unlock()
+	ds.portModel(port).Queues = queues
+	return nil
+}
+
+// Returns the number of egress queues of the port with the specified SDN port number; ports not known to the
+// device get the default number of queues
+func (ds *DeviceSimulator) egressQueues(sdnPort uint32) uint32 {
+	port, ok := ds.sdnPorts[sdnPort]
+	if !ok {
+		return entries.DefaultEgressQueues
+	}
+	ds.portModelLock.Lock()
+	defer ds.portModelLock.Unlock()
+	return ds.portModel(port).Queues
+}
+
+// GetPortModel returns a copy of the egress model of the specified port
+func (ds *DeviceSimulator) GetPortModel(id simapi.PortID) (PortModel, error) {
+	port, ok := ds.Ports[id]
+	if !ok {
+		return PortModel{}, errors.NewNotFound("port %s not found", id)
+	}
+	ds.portModelLock.Lock()
+	defer ds.portModelLock.Unlock()
+	return *ds.portModel(port), nil
+}
+
+// Transmit simulates the egress of the given Ethernet frame via the specified port; frames exceeding the port MTU
+// are dropped and counted; returns the time needed to serialize the frame at the port speed
+func (ds *DeviceSimulator) Transmit(id simapi.PortID, frame []byte) (time.Duration, error) {
+	port, ok := ds.Ports[id]
+	if !ok {
+		return 0, errors.NewNotFound("port %s not found", id)
+	}
+	return ds.transmit(port, frame)
+}
+
+func (ds *DeviceSimulator) transmit(port *simapi.Port, frame []byte) (time.Duration, error) {
+	return ds.egress(port, len(frame))
+}
+
+// Simulates the egress of an Ethernet frame of the given length in bytes via the specified port
+func (ds *DeviceSimulator) egress(port *simapi.Port, length int) (time.Duration, error) {
+	ds.portModelLock.Lock()
+	defer ds.portModelLock.Unlock()
+	model := ds.portModel(port)
+	if length-ethernetHeaderLength > model.MTU {
+		model.MTUDrops++
+		log.Debugf("Device %s: Dropped %d byte frame exceeding MTU %d of port %s", ds.Device.ID, length, model.MTU, port.ID)
+		return 0, errors.NewInvalid("frame of %d bytes exceeds MTU %d of port %s", length, model.MTU, port.ID)
+	}
+	if model.Speed == 0 {
+		return 0, nil
+	}
+	return time.Duration(uint64(length) * 8 * uint64(time.Second) / model.Speed), nil
+}
+
+// Parses port speed designations, e.g. 100GB or 10MB, into bits per second; returns 0 if the speed is not known
+func parsePortSpeed(speed string) uint64 {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(speed)), "B")
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "T"):
+		multiplier = 1000 * 1000 * 1000 * 1000
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1000 * 1000 * 1000
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1000 * 1000
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1000
+	}
+	s = strings.TrimRight(s, "TGMK")
+	value, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value * multiplier
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	"github.com/onosproject/fabric-sim/pkg/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-net-lib/pkg/p4utils"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParsePortSpeed(t *testing.T) {
+	assert.Equal(t, uint64(100000000000), parsePortSpeed("100GB"))
+	assert.Equal(t, uint64(10000000000), parsePortSpeed("10G"))
+	assert.Equal(t, uint64(100000000), parsePortSpeed("100MB"))
+	assert.Equal(t, uint64(0), parsePortSpeed("fast"))
+}
+
+func TestPortMTU(t *testing.T) {
+	topology := &topo.Topology{}
+	assert.NoError(t, topo.LoadTopologyFile("../../topologies/custom.yaml", topology))
+	ds := NewDeviceSimulator(topo.ConstructDevice(topology.Devices[0]), nil, nil)
+	port := ds.Device.Ports[0]
+	port.Speed = "1GB"
+
+	model, err := ds.GetPortModel(port.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMTU, model.MTU)
+	assert.Equal(t, uint64(1000000000), model.Speed)
+
+	// Frames within the MTU egress at the port speed
+	d, err := ds.Transmit(port.ID, make([]byte, 1514))
+	assert.NoError(t, err)
+	assert.Equal(t, 12112*time.Nanosecond, d)
+
+	// Oversized frames are dropped and counted
+	assert.NoError(t, ds.SetPortMTU(port.ID, 1000))
+	_, err = ds.Transmit(port.ID, make([]byte, 1015))
+	assert.True(t, errors.IsInvalid(err))
+	_, err = ds.Transmit(port.ID, make([]byte, 1014))
+	assert.NoError(t, err)
+	model, _ = ds.GetPortModel(port.ID)
+	assert.Equal(t, uint64(1), model.MTUDrops)
+
+	// Oversized packet-outs are dropped at egress
+	lldp := gopacket.NewSerializeBuffer()
+	assert.NoError(t, gopacket.SerializeLayers(lldp, gopacket.SerializeOptions{},
+		&layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+			DstMAC:       net.HardwareAddr{0x01, 0x80, 0xc2, 0, 0, 0x0e},
+			EthernetType: layers.EthernetTypeLinkLayerDiscovery,
+		},
+		gopacket.Payload(make([]byte, 2000))))
+	packetOut := &p4api.PacketOut{Payload: lldp.Bytes()}
+	ds.processLLDPPacket(gopacket.NewPacket(packetOut.Payload, layers.LayerTypeEthernet, gopacket.Default),
+		packetOut, &p4utils.PacketOutMetadata{EgressPort: port.InternalNumber})
+	model, _ = ds.GetPortModel(port.ID)
+	assert.Equal(t, uint64(2), model.MTUDrops)
+
+	assert.True(t, errors.IsInvalid(ds.SetPortMTU(port.ID, 0)))
+	assert.True(t, errors.IsNotFound(ds.SetPortMTU("nonexistent", 1500)))
+	_, err = ds.Transmit("nonexistent", nil)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestPortQueues(t *testing.T) {
+	topology := &topo.Topology{}
+	assert.NoError(t, topo.LoadTopologyFile("../../topologies/custom.yaml", topology))
+	ds := NewDeviceSimulator(topo.ConstructDevice(topology.Devices[0]), nil, nil)
+	port := ds.Device.Ports[0]
+
+	model, err := ds.GetPortModel(port.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(entries.DefaultEgressQueues), model.Queues)
+
+	assert.True(t, errors.IsInvalid(ds.SetPortQueues(port.ID, 0)))
+	assert.True(t, errors.IsNotFound(ds.SetPortQueues("nonexistent", 4)))
+	assert.NoError(t, ds.SetPortQueues(port.ID, 4))
+	assert.Equal(t, uint32(4), ds.egressQueues(port.InternalNumber))
+	assert.Equal(t, uint32(entries.DefaultEgressQueues), ds.egressQueues(0xfffffff0))
+
+	// Clone sessions are validated against the queues of the device ports
+	pre := entries.NewPacketReplication()
+	pre.SetEgressQueues(ds.egressQueues)
+	session := &p4api.CloneSessionEntry{SessionId: 1, ClassOfService: 4,
+		Replicas: []*p4api.Replica{{EgressPort: port.InternalNumber}}}
+	assert.True(t, errors.IsInvalid(pre.ModifyCloneSessionEntry(session, true)))
+	session.ClassOfService = 3
+	assert.NoError(t, pre.ModifyCloneSessionEntry(session, true))
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/simulator/entries"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// AsyncProgramFault simulates table entries which are accepted by the software layer, but which later fail
+// to be programmed in hardware; such entries are rolled back and reported to controllers via a stream error
+type AsyncProgramFault struct {
+	lock     sync.Mutex
+	fraction float64
+	delay    time.Duration
+	rand     *rand.Rand
+	schedule func(delay time.Duration, f func()) (stop func() bool)
+}
+
+// Programming failure scheduled for an inserted entry; the table and generation identify the row which
+// was inserted, so that the failure does not affect a row inserted anew with the same match
+type pendingProgramFault struct {
+	entry      *p4api.TableEntry
+	table      *entries.Table
+	generation uint64
+	stop       func() bool
+}
+
+// NewAsyncProgramFault creates a new asynchronous programming fault which fails the given fraction (0.0 - 1.0)
+// of inserted table entries after the specified delay; the seed allows the fault pattern to be reproduced
+func NewAsyncProgramFault(fraction float64, delay time.Duration, seed int64) *AsyncProgramFault {
+	return &AsyncProgramFault{
+		fraction: fraction,
+		delay:    delay,
+		rand:     rand.New(rand.NewSource(seed)),
+		schedule: func(delay time.Duration, f func()) func() bool { return time.AfterFunc(delay, f).Stop },
+	}
+}
+
+// Returns true if the next programmed entry falls within the faulty fraction
+func (f *AsyncProgramFault) hit() bool {
+	if f == nil {
+		return false
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.rand.Float64() < f.fraction
+}
+
+// SetAsyncProgramFault sets the asynchronous programming fault for table entry inserts; nil disables the fault
+func (ds *DeviceSimulator) SetAsyncProgramFault(fault *AsyncProgramFault) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.programFault = fault
+}
+
+// Schedules the asynchronous programming failure of the specified entry if it falls within the faulty fraction
+func (ds *DeviceSimulator) injectProgramFault(entry *p4api.TableEntry) {
+	fault := ds.programFault
+	if !fault.hit() {
+		return
+	}
+	table := ds.tables.Table(entry.TableId)
+	if table == nil {
+		return
+	}
+	generation, ok := table.EntryGeneration(entry)
+	if !ok {
+		return
+	}
+	pending := &pendingProgramFault{
+		entry:      proto.Clone(entry).(*p4api.TableEntry),
+		table:      table,
+		generation: generation,
+	}
+	pending.stop = fault.schedule(fault.delay, func() {
+		ds.failProgramming(pending)
+	})
+	if ds.programFaults == nil {
+		ds.programFaults = make(map[*pendingProgramFault]bool)
+	}
+	ds.programFaults[pending] = true
+}
+
+// Returns true if the row for which the failure was scheduled is still in place
+func (ds *DeviceSimulator) isProgramFaultCurrent(pending *pendingProgramFault) bool {
+	if ds.tables.Table(pending.entry.TableId) != pending.table {
+		return false
+	}
+	generation, ok := pending.table.EntryGeneration(pending.entry)
+	return ok && generation == pending.generation
+}
+
+// Stops the scheduled failures of entries which have since been deleted, rolled back or replaced
+func (ds *DeviceSimulator) cancelStaleProgramFaults() {
+	for pending := range ds.programFaults {
+		if !ds.isProgramFaultCurrent(pending) {
+			pending.stop()
+			delete(ds.programFaults, pending)
+		}
+	}
+}
+
+// Rolls back the specified entry and notifies all controllers of the failure to program it
+func (ds *DeviceSimulator) failProgramming(pending *pendingProgramFault) {
+	ds.lock.Lock()
+	removed := false
+	if ds.programFaults[pending] {
+		delete(ds.programFaults, pending)
+		if ds.isProgramFaultCurrent(pending) {
+			if err := pending.table.RemoveTableEntry(pending.entry); err == nil {
+				ds.checkPuntToCPU()
+				removed = true
+			}
+		}
+	}
+	ds.lock.Unlock()
+	if !removed {
+		// The entry or its pipeline has been removed in the meantime; there is nothing to roll back
+		return
+	}
+
+	entry := pending.entry
+	log.Warnf("Device %s: Failed to program table entry in hardware: %+v", ds.Device.ID, entry)
+	streamError := &p4api.StreamError{
+		CanonicalCode: int32(code.Code_INTERNAL),
+		Message:       "failed to program table entry in hardware",
+	}
+	if other, err := anypb.New(entry); err == nil {
+		streamError.Details = &p4api.StreamError_Other{Other: &p4api.StreamOtherError{Other: other}}
+	}
+	ds.SendToAllResponders(&p4api.StreamMessageResponse{
+		Update: &p4api.StreamMessageResponse_Error{Error: streamError},
+	})
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022-present Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"github.com/onosproject/fabric-sim/pkg/topo"
+	"github.com/onosproject/onos-api/go/onos/misc"
+	p4info "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4api "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"testing"
+	"time"
+)
+
+// Creates a device simulator with a pipeline containing a single exact match table
+func newProgrammableDevice(t *testing.T) *DeviceSimulator {
+	topology := &topo.Topology{}
+	assert.NoError(t, topo.LoadTopologyFile("../../topologies/custom.yaml", topology))
+	ds := NewDeviceSimulator(topo.ConstructDevice(topology.Devices[0]), nil, nil)
+	assert.NoError(t, ds.SetPipelineConfig(&p4api.ForwardingPipelineConfig{
+		P4Info: &p4info.P4Info{Tables: []*p4info.Table{{
+			Preamble:    &p4info.Preamble{Id: 1, Name: "exact"},
+			MatchFields: []*p4info.MatchField{{Id: 1, Name: "f1", Bitwidth: 16, Match: &p4info.MatchField_MatchType_{MatchType: p4info.MatchField_EXACT}}},
+		}}},
+		Cookie: &p4api.ForwardingPipelineConfig_Cookie{Cookie: 1},
+	}))
+	return ds
+}
+
+// Creates an insert update for an exact match entry with the given value
+func insertUpdate(value byte) *p4api.Update {
+	return &p4api.Update{Type: p4api.Update_INSERT, Entity: &p4api.Entity{Entity: &p4api.Entity_TableEntry{TableEntry: &p4api.TableEntry{
+		TableId: 1,
+		Match:   []*p4api.FieldMatch{{FieldId: 1, FieldMatchType: &p4api.FieldMatch_Exact_{Exact: &p4api.FieldMatch_Exact{Value: []byte{value}}}}},
+	}}}}
+}
+
+func TestAsyncProgramFault(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	controller := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c1"}}
+	ds.AddStreamResponder(controller)
+
+	fault := NewAsyncProgramFault(0.5, time.Second, 1)
+	pending := make([]func(), 0)
+	delays := make([]time.Duration, 0)
+	fault.schedule = func(delay time.Duration, f func()) func() bool {
+		delays = append(delays, delay)
+		pending = append(pending, f)
+		return func() bool { return true }
+	}
+	ds.SetAsyncProgramFault(fault)
+
+	// All writes succeed synchronously
+	for i := 1; i <= 100; i++ {
+		assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(byte(i))}))
+	}
+	table := ds.Tables().Table(1)
+	assert.Equal(t, 100, table.Size())
+	assert.True(t, len(pending) > 30 && len(pending) < 70, "failed %d entries", len(pending))
+	for _, delay := range delays {
+		assert.Equal(t, time.Second, delay)
+	}
+	assert.Len(t, controller.responses, 0)
+
+	// Failures roll back the entries and emit stream errors
+	for _, f := range pending {
+		f()
+	}
+	assert.Equal(t, 100-len(pending), table.Size())
+	assert.Len(t, controller.responses, len(pending))
+	for _, response := range controller.responses {
+		assert.Equal(t, int32(code.Code_INTERNAL), response.GetError().CanonicalCode)
+		entry := &p4api.TableEntry{}
+		assert.NoError(t, response.GetError().GetOther().Other.UnmarshalTo(entry))
+		assert.Equal(t, uint32(1), entry.TableId)
+	}
+
+	// Same seed yields the same failures
+	ds2 := newProgrammableDevice(t)
+	fault2 := NewAsyncProgramFault(0.5, time.Second, 1)
+	count := 0
+	fault2.schedule = func(delay time.Duration, f func()) func() bool {
+		count++
+		return func() bool { return true }
+	}
+	ds2.SetAsyncProgramFault(fault2)
+	for i := 1; i <= 100; i++ {
+		assert.NoError(t, ds2.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(byte(i))}))
+	}
+	assert.Equal(t, len(pending), count)
+
+	// Entries deleted before the failure are not reported
+	ds.SetAsyncProgramFault(NewAsyncProgramFault(1.0, 0, 1))
+	ds.programFault.schedule = func(delay time.Duration, f func()) func() bool {
+		pending = []func(){f}
+		return func() bool { return true }
+	}
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(200)}))
+	update := insertUpdate(200)
+	update.Type = p4api.Update_DELETE
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{update}))
+	reported := len(controller.responses)
+	pending[0]()
+	assert.Len(t, controller.responses, reported)
+
+	// Disabled fault never fails entries
+	ds.SetAsyncProgramFault(nil)
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(201)}))
+	assert.Len(t, controller.responses, reported)
+}
+
+// Schedules failures manually, recording which of them were stopped
+type manualSchedule struct {
+	pending []func()
+	stopped []bool
+}
+
+func (s *manualSchedule) schedule(delay time.Duration, f func()) func() bool {
+	i := len(s.pending)
+	s.pending = append(s.pending, f)
+	s.stopped = append(s.stopped, false)
+	return func() bool {
+		s.stopped[i] = true
+		return true
+	}
+}
+
+func TestAsyncProgramFaultCancel(t *testing.T) {
+	ds := newProgrammableDevice(t)
+	controller := &arbitrationResponder{connection: &misc.Connection{FromAddress: "c1"}}
+	ds.AddStreamResponder(controller)
+	fault := NewAsyncProgramFault(1.0, time.Second, 1)
+	manual := &manualSchedule{}
+	fault.schedule = manual.schedule
+	ds.SetAsyncProgramFault(fault)
+	table := ds.Tables().Table(1)
+
+	// Rolled back inserts stop their failures
+	duplicate := insertUpdate(1)
+	assert.Error(t, ds.ProcessWrite(p4api.WriteRequest_ROLLBACK_ON_ERROR, []*p4api.Update{insertUpdate(1), duplicate}))
+	assert.Equal(t, 0, table.Size())
+	assert.Len(t, manual.pending, 1)
+	assert.True(t, manual.stopped[0])
+	assert.Len(t, ds.programFaults, 0)
+
+	// Deleted entries stop their failures, and stale failures leave entries inserted anew in place
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(2)}))
+	update := insertUpdate(2)
+	update.Type = p4api.Update_DELETE
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{update}))
+	assert.True(t, manual.stopped[1])
+	assert.NoError(t, ds.ProcessWrite(p4api.WriteRequest_CONTINUE_ON_ERROR, []*p4api.Update{insertUpdate(2)}))
+	assert.False(t, manual.stopped[2])
+	manual.pending[1]()
+	assert.Equal(t, 1, table.Size())
+	assert.Len(t, controller.responses, 0)
+
+	// Failure of the current entry still removes it
+	manual.pending[2]()
+	assert.Equal(t, 0, table.Size())
+	assert.Len(t, controller.responses, 1)
+	assert.Len(t, ds.programFaults, 0)
+}
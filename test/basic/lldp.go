@@ -121,7 +121,8 @@ func InstallPuntRule(ctx context.Context, p4sw2a p4api.P4RuntimeClient, chassisI
 			Type: p4api.Update_INSERT,
 			Entity: &p4api.Entity{Entity: &p4api.Entity_TableEntry{
 				TableEntry: &p4api.TableEntry{
-					TableId: 39601850,
+					TableId:  39601850,
+					Priority: 10,
 					Match: []*p4api.FieldMatch{{
 						FieldId: 5,
 						FieldMatchType: &p4api.FieldMatch_Ternary_{